@@ -25,7 +25,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // NewTransactor is a utility method to easily create a transaction signer from
@@ -45,14 +44,26 @@ func NewTransactor(keyin io.Reader, passphrase string) (*TransactOpts, error) {
 // NewKeyedTransactor is a utility method to easily create a transaction signer
 // from a single private key.
 func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
-	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	return NewSignerTransactor(accounts.NewKeySigner(key))
+}
+
+// NewAccountTransactor creates a transaction signer for an account of an account
+// backend, e.g. an unlocked keystore account or an account of an external signer.
+func NewAccountTransactor(backend accounts.Backend, account common.Address) *TransactOpts {
+	return NewSignerTransactor(accounts.NewAccountSigner(backend, account))
+}
+
+// NewSignerTransactor creates a transaction signer from an account signer.
+// Transactions are checked against the signing policy of the signer's backend.
+func NewSignerTransactor(s accounts.Signer) *TransactOpts {
+	keyAddr := s.Address()
 	return &TransactOpts{
 		From: keyAddr,
 		Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
 			if address != keyAddr {
 				return nil, errors.New("not authorized to sign this account")
 			}
-			signature, err := crypto.Sign(signer.Hash(tx).Bytes(), key)
+			signature, err := accounts.SignRequestHash(s, accounts.NewTxRequest(keyAddr, tx), signer.Hash(tx).Bytes())
 			if err != nil {
 				return nil, err
 			}
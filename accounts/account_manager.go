@@ -40,6 +40,9 @@ var (
 	ErrLocked  = errors.New("account is locked")
 	ErrNoMatch = errors.New("no key for given address or file")
 	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
+
+	ErrSignerMismatch = errors.New("signature doesn't match the signing account")
+	ErrNoPublicKey    = errors.New("public key of the account is not available")
 )
 
 // Account represents a stored key.
@@ -62,12 +65,18 @@ func (acc *Account) UnmarshalJSON(raw []byte) error {
 	return json.Unmarshal(raw, &acc.Address)
 }
 
-// Manager manages a key storage directory on disk.
+// Manager manages a key storage directory on disk. Accounts of additional
+// backends, e.g. external signers, can be made available through the manager
+// with AddBackend. Keystore and HD wallet accounts take precedence over backend
+// accounts with the same address.
 type Manager struct {
 	cache    *addrCache
 	keyStore keyStore
 	mu       sync.RWMutex
 	unlocked map[common.Address]*unlocked
+	backends []Backend
+	wallets  []*HDWallet // HD wallets of the key directory
+	policy   Policy      // checks signing requests, nil if unrestricted
 }
 
 type unlocked struct {
@@ -95,6 +104,7 @@ func NewPlaintextManager(keydir string) *Manager {
 func (am *Manager) init(keydir string) {
 	am.unlocked = make(map[common.Address]*unlocked)
 	am.cache = newAddrCache(keydir)
+	am.wallets = loadHDWallets(filepath.Join(keydir, hdWalletDir))
 	// TODO: In order for this finalizer to work, there must be no references
 	// to am. addrCache doesn't keep a reference but unlocked keys do,
 	// so the finalizer will not trigger until all timed unlocks have expired.
@@ -103,18 +113,91 @@ func (am *Manager) init(keydir string) {
 	})
 }
 
-// HasAddress reports whether a key with the given address is present.
+// AddBackend makes the accounts of the given backend available through the manager.
+func (am *Manager) AddBackend(b Backend) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.backends = append(am.backends, b)
+}
+
+// Backend returns the additional backend holding the given account, or nil if
+// the account is in the keystore, derived from an HD wallet or unknown. If the
+// account is unknown and a backend can't be reached, its error is returned
+// as the account may be held by it.
+func (am *Manager) Backend(addr common.Address) (Backend, error) {
+	if am.cache.hasAddress(addr) || am.hdWallet(addr) != nil {
+		return nil, nil
+	}
+	am.mu.RLock()
+	backends := am.backends
+	am.mu.RUnlock()
+	for _, b := range backends {
+		if b.HasAddress(addr) {
+			return b, nil
+		}
+	}
+	for _, b := range backends {
+		if s, ok := b.(BackendStatus); ok {
+			if err := s.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// HasAddress reports whether a key with the given address is present in the key
+// directory, an HD wallet or one of the additional backends.
 func (am *Manager) HasAddress(addr common.Address) bool {
-	return am.cache.hasAddress(addr)
+	if am.cache.hasAddress(addr) || am.hdWallet(addr) != nil {
+		return true
+	}
+	b, _ := am.Backend(addr)
+	return b != nil
 }
 
-// Accounts returns all key files present in the directory.
+// PublicKey returns the public key of an account. Keystore accounts must be
+// unlocked, accounts of additional backends are looked up by their backend.
+func (am *Manager) PublicKey(addr common.Address) (*ecdsa.PublicKey, error) {
+	b, err := am.Backend(addr)
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		if pb, ok := b.(PublicKeyBackend); ok {
+			return pb.PublicKey(addr)
+		}
+		return nil, ErrNoPublicKey
+	}
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	unlockedKey, found := am.unlocked[addr]
+	if !found {
+		return nil, ErrLocked
+	}
+	pub := unlockedKey.PrivateKey.PublicKey
+	return &pub, nil
+}
+
+// Accounts returns all key files present in the directory, followed by the
+// accounts of the HD wallets and of the additional backends.
 func (am *Manager) Accounts() []Account {
-	return am.cache.accounts()
+	accounts := am.cache.accounts()
+	am.mu.RLock()
+	wallets, backends := am.wallets, am.backends
+	am.mu.RUnlock()
+	for _, w := range wallets {
+		accounts = append(accounts, w.Accounts()...)
+	}
+	for _, b := range backends {
+		accounts = append(accounts, b.Accounts()...)
+	}
+	return accounts
 }
 
 // DeleteAccount deletes the key matched by account if the passphrase is correct.
-// If a contains no filename, the address must match a unique key.
+// If a contains no filename, the address must match a unique key. Accounts of
+// HD wallets can't be deleted.
 func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	// Decrypting the key isn't really necessary, but we do
 	// it anyway to check the password and zero out the key
@@ -126,6 +209,9 @@ func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	if err != nil {
 		return err
 	}
+	if am.isHDAccount(a) {
+		return ErrHDAccount
+	}
 	// The order is crucial here. The key is dropped from the
 	// cache after the file is gone so that a reload happening in
 	// between won't insert it into the cache again.
@@ -137,8 +223,19 @@ func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 }
 
 // Sign calculates a ECDSA signature for the given hash. The produced signature
-// is in the [R || S || V] format where V is 0 or 1.
+// is in the [R || S || V] format where V is 0 or 1. Keystore accounts must be
+// unlocked, accounts of additional backends are signed with by their backend.
+// The hash is checked against the signing policy as a HashRequest.
 func (am *Manager) Sign(addr common.Address, hash []byte) ([]byte, error) {
+	return am.SignRequest(newHashRequest(addr, hash), hash)
+}
+
+func (am *Manager) sign(addr common.Address, hash []byte) ([]byte, error) {
+	if b, err := am.Backend(addr); err != nil {
+		return nil, err
+	} else if b != nil {
+		return b.Sign(addr, hash)
+	}
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
@@ -151,8 +248,18 @@ func (am *Manager) Sign(addr common.Address, hash []byte) ([]byte, error) {
 
 // SignWithPassphrase signs hash if the private key matching the given address
 // can be decrypted with the given passphrase. The produced signature is in the
-// [R || S || V] format where V is 0 or 1.
+// [R || S || V] format where V is 0 or 1. The hash is checked against the
+// signing policy as a HashRequest.
 func (am *Manager) SignWithPassphrase(a Account, passphrase string, hash []byte) (signature []byte, err error) {
+	return am.SignRequestWithPassphrase(a, passphrase, newHashRequest(a.Address, hash), hash)
+}
+
+func (am *Manager) signWithPassphrase(a Account, passphrase string, hash []byte) (signature []byte, err error) {
+	if b, err := am.Backend(a.Address); err != nil {
+		return nil, err
+	} else if b != nil {
+		return b.SignWithPassphrase(a, passphrase, hash)
+	}
 	_, key, err := am.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return nil, err
@@ -225,10 +332,18 @@ func (am *Manager) Find(a Account) (Account, error) {
 }
 
 func (am *Manager) getDecryptedKey(a Account, auth string) (Account, *Key, error) {
-	a, err := am.Find(a)
+	found, err := am.Find(a)
+	if err == ErrNoMatch {
+		// Fall back to the accounts derived from HD wallets.
+		if w := am.hdWallet(a.Address); w != nil && (a.File == "" || a.File == w.file) {
+			key, err := w.key(a.Address, auth)
+			return Account{Address: a.Address, File: w.file}, key, err
+		}
+	}
 	if err != nil {
-		return a, nil, err
+		return found, nil, err
 	}
+	a = found
 	key, err := am.keyStore.GetKey(a.Address, a.File, auth)
 	return a, key, err
 }
@@ -321,12 +436,17 @@ func (am *Manager) importKey(key *Key, passphrase string) (Account, error) {
 	return a, nil
 }
 
-// Update changes the passphrase of an existing account.
+// Update changes the passphrase of an existing account. The passphrase of HD
+// wallet accounts can't be changed.
 func (am *Manager) Update(a Account, passphrase, newPassphrase string) error {
 	a, key, err := am.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
 	}
+	if am.isHDAccount(a) {
+		zeroKey(key.PrivateKey)
+		return ErrHDAccount
+	}
 	return am.keyStore.StoreKey(a.File, key, newPassphrase)
 }
 
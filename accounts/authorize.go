@@ -0,0 +1,163 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// RequestKind is the kind of data a signing request is for.
+type RequestKind string
+
+const (
+	TransactionRequest RequestKind = "transaction"
+	MessageRequest     RequestKind = "message"
+	ChequeRequest      RequestKind = "cheque"
+	ResourceRequest    RequestKind = "resource" // swarm mutable resource updates
+	ReceiptRequest     RequestKind = "receipt"  // swarm storage receipts
+
+	// HashRequest is the kind of requests to sign a bare hash, whose content is
+	// unknown to the policy.
+	HashRequest RequestKind = "hash"
+)
+
+// SignRequest describes what an account is asked to sign, so it can be checked
+// against a signing policy.
+type SignRequest struct {
+	Kind  RequestKind
+	From  common.Address  // signing account
+	To    *common.Address // recipient, nil for messages and contract creations
+	Value *big.Int        // value transferred, nil for messages
+	Data  []byte          // transaction input or message
+}
+
+// NewTxRequest creates the signing request for a transaction.
+func NewTxRequest(from common.Address, tx *types.Transaction) *SignRequest {
+	return &SignRequest{Kind: TransactionRequest, From: from, To: tx.To(), Value: tx.Value(), Data: tx.Data()}
+}
+
+// newHashRequest creates the signing request for a bare hash.
+func newHashRequest(from common.Address, hash []byte) *SignRequest {
+	return &SignRequest{Kind: HashRequest, From: from, Data: hash}
+}
+
+// Policy decides which signing requests are permitted.
+type Policy interface {
+	// Check returns a *DeniedError if the request is not permitted. For permitted
+	// requests it returns a function which must be called with the outcome of
+	// signing, so that only signed requests count against the policy's limits.
+	Check(req *SignRequest) (done func(signed bool), err error)
+}
+
+// DeniedError is returned for signing requests rejected by a policy.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return "signing request denied: " + e.Reason
+}
+
+// RequestSigner is implemented by backends and signers which check signing
+// requests against a policy before signing.
+type RequestSigner interface {
+	// SignRequest signs hash, the hash of the data described by req, if the
+	// policy permits req.
+	SignRequest(req *SignRequest, hash []byte) ([]byte, error)
+}
+
+// SignRequestHash signs hash, the hash of the data described by req, with the
+// given signer. The request is checked against the policy of the signer, if it
+// has one. Signers holding a private key directly are not subject to any policy.
+func SignRequestHash(s Signer, req *SignRequest, hash []byte) ([]byte, error) {
+	if rs, ok := s.(RequestSigner); ok {
+		return rs.SignRequest(req, hash)
+	}
+	return s.SignHash(hash)
+}
+
+// SetPolicy makes the manager check signing requests against the given policy.
+// Bare hashes passed to Sign and SignWithPassphrase are checked as requests of
+// kind HashRequest, use SignRequest and SignRequestWithPassphrase to describe
+// what is signed.
+func (am *Manager) SetPolicy(p Policy) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.policy = p
+}
+
+// signChecked calls sign if the signing policy permits req.
+func (am *Manager) signChecked(req *SignRequest, sign func() ([]byte, error)) ([]byte, error) {
+	am.mu.RLock()
+	policy := am.policy
+	am.mu.RUnlock()
+	if policy == nil {
+		return sign()
+	}
+	done, err := policy.Check(req)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign()
+	done(err == nil)
+	return sig, err
+}
+
+// SignRequest implements RequestSigner. The account req.From must be unlocked
+// or belong to an additional backend.
+func (am *Manager) SignRequest(req *SignRequest, hash []byte) ([]byte, error) {
+	return am.signChecked(req, func() ([]byte, error) { return am.sign(req.From, hash) })
+}
+
+// SignRequestWithPassphrase is like SignRequest, but decrypts the key of
+// account a with the passphrase instead of requiring it to be unlocked.
+func (am *Manager) SignRequestWithPassphrase(a Account, passphrase string, req *SignRequest, hash []byte) ([]byte, error) {
+	req.From = a.Address
+	return am.signChecked(req, func() ([]byte, error) { return am.signWithPassphrase(a, passphrase, hash) })
+}
+
+// SignRequest implements RequestSigner, deferring to the backend of the account.
+func (s *accountSigner) SignRequest(req *SignRequest, hash []byte) ([]byte, error) {
+	if rs, ok := s.backend.(RequestSigner); ok {
+		req.From = s.addr
+		return rs.SignRequest(req, hash)
+	}
+	return s.backend.Sign(s.addr, hash)
+}
+
+// WithPolicy returns a signer whose signing requests are checked against the
+// signing policy of am, e.g. to subject a key held in memory to the policy.
+func WithPolicy(s Signer, am *Manager) Signer {
+	return &policySigner{s, am}
+}
+
+type policySigner struct {
+	Signer
+	am *Manager
+}
+
+func (s *policySigner) SignHash(hash []byte) ([]byte, error) {
+	return s.SignRequest(newHashRequest(s.Address(), hash), hash)
+}
+
+func (s *policySigner) SignRequest(req *SignRequest, hash []byte) ([]byte, error) {
+	req.From = s.Address()
+	return s.am.signChecked(req, func() ([]byte, error) { return s.Signer.SignHash(hash) })
+}
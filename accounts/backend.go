@@ -0,0 +1,119 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Backend is a source of accounts that can sign on their behalf. The key
+// directory of a Manager is one backend, others (e.g. external signers) can be
+// added to a Manager with AddBackend.
+type Backend interface {
+	// Accounts returns all accounts of the backend.
+	Accounts() []Account
+
+	// HasAddress reports whether the backend holds the account with the given address.
+	HasAddress(addr common.Address) bool
+
+	// Sign calculates an ECDSA signature for the given hash in the [R || S || V]
+	// format where V is 0 or 1.
+	Sign(addr common.Address, hash []byte) ([]byte, error)
+
+	// SignWithPassphrase is like Sign, but authorizes the request with the
+	// passphrase of the account instead of requiring it to be unlocked.
+	SignWithPassphrase(a Account, passphrase string, hash []byte) ([]byte, error)
+}
+
+// BackendStatus is implemented by backends which can become unreachable, e.g.
+// external signers.
+type BackendStatus interface {
+	// Err returns the error of the last attempt to reach the backend, nil if
+	// it succeeded.
+	Err() error
+}
+
+// PublicKeyBackend is implemented by backends which can tell the public keys
+// of their accounts.
+type PublicKeyBackend interface {
+	Backend
+
+	// PublicKey returns the public key of the account with the given address.
+	PublicKey(addr common.Address) (*ecdsa.PublicKey, error)
+}
+
+// Signer signs hashes on behalf of a single account.
+type Signer interface {
+	// Address returns the address of the signing account.
+	Address() common.Address
+
+	// SignHash calculates an ECDSA signature for the given hash in the
+	// [R || S || V] format where V is 0 or 1.
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// NewKeySigner creates a signer for a private key held in memory.
+func NewKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+type keySigner struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+func (s *keySigner) Address() common.Address              { return s.addr }
+func (s *keySigner) SignHash(hash []byte) ([]byte, error) { return crypto.Sign(hash, s.key) }
+func (s *keySigner) PublicKey() *ecdsa.PublicKey          { return &s.key.PublicKey }
+
+// NewAccountSigner creates a signer for an account of a backend. With a Manager as
+// backend, keystore accounts must be unlocked for signing to succeed.
+func NewAccountSigner(backend Backend, addr common.Address) Signer {
+	return &accountSigner{backend, addr}
+}
+
+type accountSigner struct {
+	backend Backend
+	addr    common.Address
+}
+
+func (s *accountSigner) Address() common.Address { return s.addr }
+
+func (s *accountSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.backend.Sign(s.addr, hash)
+}
+
+// SignerPublicKey returns the public key of a signer's account. Signers which
+// don't hold the key themselves ask the backend of the account for it; nothing
+// is signed.
+func SignerPublicKey(s Signer) (*ecdsa.PublicKey, error) {
+	if ps, ok := s.(*policySigner); ok {
+		s = ps.Signer
+	}
+	switch s := s.(type) {
+	case *keySigner:
+		return s.PublicKey(), nil
+	case *accountSigner:
+		if pb, ok := s.backend.(PublicKeyBackend); ok {
+			return pb.PublicKey(s.addr)
+		}
+	}
+	return nil, ErrNoPublicKey
+}
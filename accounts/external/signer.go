@@ -0,0 +1,190 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend which delegates signing to an
+// external process, keeping private keys out of the node.
+//
+// The signer is reached over JSON-RPC (IPC, HTTP or websocket) and must provide
+// the following methods:
+//
+//	account_list() []address
+//	account_publicKey(address) publickey
+//	account_signHash(address, hash) signature
+//	account_signHashWithPassphrase(address, passphrase, hash) signature
+//
+// Hashes, public keys and signatures are hex encoded. Public keys are
+// uncompressed, signatures are in the [R || S || V] format where V is 0 or 1.
+package external
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// requestTimeout bounds the time the signer may take to answer a request. It is
+// generous because signers may ask a human for confirmation.
+const requestTimeout = 5 * time.Minute
+
+// listTimeout bounds the time the signer may take to list its accounts.
+const listTimeout = 5 * time.Second
+
+// listRefresh is the time after which the account list of the signer is
+// fetched again.
+const listRefresh = 10 * time.Second
+
+// Signer is an account backend for an external signer.
+type Signer struct {
+	endpoint string
+	client   *rpc.Client
+
+	mu     sync.Mutex
+	addrs  []common.Address // accounts of the last successful listing
+	listed time.Time        // time of the last listing
+	err    error            // error of the last listing
+}
+
+// Dial connects to the external signer at the given endpoint.
+func Dial(endpoint string) (*Signer, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to external signer: %v", err)
+	}
+	return NewSigner(endpoint, client), nil
+}
+
+// NewSigner creates a backend for the external signer reachable through client.
+func NewSigner(endpoint string, client *rpc.Client) *Signer {
+	return &Signer{endpoint: endpoint, client: client}
+}
+
+// Close disconnects from the signer.
+func (s *Signer) Close() {
+	s.client.Close()
+}
+
+func (s *Signer) String() string {
+	return "external signer " + s.endpoint
+}
+
+// Accounts implements accounts.Backend, returning the accounts of the signer. If
+// the signer can't be reached, the accounts of the last listing are returned.
+func (s *Signer) Accounts() []accounts.Account {
+	addrs, _ := s.list()
+	accs := make([]accounts.Account, len(addrs))
+	for i, addr := range addrs {
+		accs[i] = accounts.Account{Address: addr}
+	}
+	return accs
+}
+
+// HasAddress implements accounts.Backend.
+func (s *Signer) HasAddress(addr common.Address) bool {
+	addrs, _ := s.list()
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Err implements accounts.BackendStatus, returning the error of the last
+// attempt to list the accounts of the signer.
+func (s *Signer) Err() error {
+	_, err := s.list()
+	return err
+}
+
+// list returns the accounts of the signer, fetching them again if the last
+// listing is older than listRefresh.
+func (s *Signer) list() ([]common.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.listed) < listRefresh {
+		return s.addrs, s.err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+	var addrs []common.Address
+	s.listed = time.Now()
+	if s.err = s.client.CallContext(ctx, &addrs, "account_list"); s.err != nil {
+		glog.V(logger.Warn).Infof("%v: can't list accounts: %v", s, s.err)
+		s.err = fmt.Errorf("%v unavailable: %v", s, s.err)
+	} else {
+		s.addrs = addrs
+	}
+	return s.addrs, s.err
+}
+
+// PublicKey implements accounts.PublicKeyBackend, asking the signer for the
+// public key of the account.
+func (s *Signer) PublicKey(addr common.Address) (*ecdsa.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+	var key hexutil.Bytes
+	if err := s.client.CallContext(ctx, &key, "account_publicKey", addr); err != nil {
+		return nil, err
+	}
+	pub := crypto.ToECDSAPub(key)
+	if pub == nil || pub.X == nil {
+		return nil, fmt.Errorf("invalid public key from %v", s)
+	}
+	if crypto.PubkeyToAddress(*pub) != addr {
+		return nil, accounts.ErrSignerMismatch
+	}
+	return pub, nil
+}
+
+// Sign implements accounts.Backend, asking the signer to sign the hash.
+func (s *Signer) Sign(addr common.Address, hash []byte) ([]byte, error) {
+	return s.sign(addr, hash, "account_signHash", addr, hexutil.Bytes(hash))
+}
+
+// SignWithPassphrase implements accounts.Backend, forwarding the passphrase to the
+// signer.
+func (s *Signer) SignWithPassphrase(a accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return s.sign(a.Address, hash, "account_signHashWithPassphrase", a.Address, passphrase, hexutil.Bytes(hash))
+}
+
+// sign calls a signing method and verifies the returned signature was made by
+// the expected account.
+func (s *Signer) sign(addr common.Address, hash []byte, method string, args ...interface{}) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var sig hexutil.Bytes
+	if err := s.client.CallContext(ctx, &sig, method, args...); err != nil {
+		return nil, err
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from %v: %v", s, err)
+	}
+	if crypto.PubkeyToAddress(*pub) != addr {
+		return nil, accounts.ErrSignerMismatch
+	}
+	return sig, nil
+}
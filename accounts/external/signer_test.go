@@ -0,0 +1,188 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SignerAPI is a minimal external signer holding its keys in memory.
+type SignerAPI struct {
+	keys       map[common.Address]*ecdsa.PrivateKey
+	passphrase string
+	forge      *ecdsa.PrivateKey // if set, signs with this key instead
+	down       bool              // if set, accounts can't be listed
+	lists      int               // number of account listings
+}
+
+func (api *SignerAPI) List() ([]common.Address, error) {
+	api.lists++
+	if api.down {
+		return nil, errors.New("signer down")
+	}
+	var addrs []common.Address
+	for addr := range api.keys {
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func (api *SignerAPI) PublicKey(addr common.Address) (hexutil.Bytes, error) {
+	key, ok := api.keys[addr]
+	if !ok {
+		return nil, errors.New("unknown account")
+	}
+	return crypto.FromECDSAPub(&key.PublicKey), nil
+}
+
+func (api *SignerAPI) SignHash(addr common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	key, ok := api.keys[addr]
+	if !ok {
+		return nil, errors.New("unknown account")
+	}
+	if api.forge != nil {
+		key = api.forge
+	}
+	return crypto.Sign(hash, key)
+}
+
+func (api *SignerAPI) SignHashWithPassphrase(addr common.Address, passphrase string, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	if passphrase != api.passphrase {
+		return nil, errors.New("wrong passphrase")
+	}
+	return api.SignHash(addr, hash)
+}
+
+func newTestSigner(t *testing.T) (*Signer, *SignerAPI, common.Address) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	api := &SignerAPI{keys: map[common.Address]*ecdsa.PrivateKey{addr: key}, passphrase: "foo"}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		t.Fatal(err)
+	}
+	return NewSigner("inproc", rpc.DialInProc(server)), api, addr
+}
+
+func TestSignerBackend(t *testing.T) {
+	signer, api, addr := newTestSigner(t)
+	defer signer.Close()
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	am.AddBackend(signer)
+
+	// The signer's accounts are available through the manager.
+	if accs := am.Accounts(); len(accs) != 1 || accs[0].Address != addr {
+		t.Fatalf("wrong accounts: %v", accs)
+	}
+	if !am.HasAddress(addr) {
+		t.Fatal("manager doesn't have the signer's account")
+	}
+	hash := crypto.Keccak256([]byte("test"))
+	sig, err := am.Sign(addr, hash)
+	if err != nil {
+		t.Fatalf("can't sign: %v", err)
+	}
+	if pub, _ := crypto.SigToPub(hash, sig); pub == nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Fatal("wrong signature")
+	}
+	if _, err := am.SignWithPassphrase(accounts.Account{Address: addr}, "bar", hash); err == nil {
+		t.Fatal("signed with wrong passphrase")
+	}
+	if _, err := am.SignWithPassphrase(accounts.Account{Address: addr}, "foo", hash); err != nil {
+		t.Fatalf("can't sign with passphrase: %v", err)
+	}
+
+	// Signers for the account ask for the public key of the account.
+	pub, err := accounts.SignerPublicKey(accounts.NewAccountSigner(am, addr))
+	if err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Fatalf("wrong public key (err %v)", err)
+	}
+
+	// The account list is fetched once until it is refreshed.
+	if api.lists != 1 {
+		t.Fatalf("accounts listed %d times, want 1", api.lists)
+	}
+	signer.mu.Lock()
+	signer.listed = time.Time{}
+	signer.mu.Unlock()
+	if !am.HasAddress(addr) || api.lists != 2 {
+		t.Fatalf("accounts not refreshed, listed %d times", api.lists)
+	}
+
+	// Signatures by other keys are rejected.
+	api.forge, _ = crypto.GenerateKey()
+	if _, err := am.Sign(addr, hash); err != accounts.ErrSignerMismatch {
+		t.Fatalf("got error %v for forged signature, want %v", err, accounts.ErrSignerMismatch)
+	}
+}
+
+func TestSignerDown(t *testing.T) {
+	signer, api, addr := newTestSigner(t)
+	defer signer.Close()
+	api.down = true
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	am.AddBackend(signer)
+
+	// Requests for accounts not in the keystore fail with the signer's error
+	// instead of falling back to the keystore.
+	hash := crypto.Keccak256([]byte("test"))
+	if _, err := am.Sign(addr, hash); err == nil || err == accounts.ErrLocked {
+		t.Fatalf("got error %v, want signer error", err)
+	}
+	if _, err := am.PublicKey(addr); err == nil || err == accounts.ErrLocked {
+		t.Fatalf("got error %v, want signer error", err)
+	}
+	if am.HasAddress(addr) {
+		t.Fatal("manager has account of unreachable signer")
+	}
+
+	// Keystore accounts are still signed with.
+	acc, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Unlock(acc, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.Sign(acc.Address, hash); err != nil {
+		t.Fatalf("can't sign with keystore account: %v", err)
+	}
+	if pub, err := am.PublicKey(acc.Address); err != nil || crypto.PubkeyToAddress(*pub) != acc.Address {
+		t.Fatalf("wrong public key of keystore account (err %v)", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package hd implements hierarchical deterministic keys, which derive any
+number of accounts from a single secret.
+
+The secret is a BIP-39 mnemonic sentence, created from random entropy with
+NewEntropy and NewMnemonic. NewSeed stretches the mnemonic and an optional
+password into a seed, from which NewMaster creates the BIP-32 master key.
+Child keys are derived along a DerivationPath. Ethereum accounts are the
+children of the BIP-44 path DefaultBaseDerivationPath, the second account
+being derived with:
+
+	master, err := hd.NewMaster(hd.NewSeed(mnemonic, password))
+	key, err := master.Derive(hd.DefaultBaseDerivationPath.Child(1))
+
+The accounts package keeps HD wallets built on these keys.
+*/
+package hd
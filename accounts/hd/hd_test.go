@@ -0,0 +1,174 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+var mnemonicTests = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+		"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+	},
+}
+
+func TestMnemonic(t *testing.T) {
+	for i, test := range mnemonicTests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("test %d: NewMnemonic error: %v", i, err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch:\ngot  %q\nwant %q", i, mnemonic, test.mnemonic)
+		}
+		decoded, err := MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Fatalf("test %d: MnemonicToEntropy error: %v", i, err)
+		}
+		if !bytes.Equal(decoded, entropy) {
+			t.Errorf("test %d: entropy mismatch: got %x, want %x", i, decoded, entropy)
+		}
+		if seed := hex.EncodeToString(NewSeed(mnemonic, "TREZOR")); seed != test.seed {
+			t.Errorf("test %d: seed mismatch:\ngot  %s\nwant %s", i, seed, test.seed)
+		}
+	}
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon foobar",
+	}
+	for _, mnemonic := range tests {
+		if err := ValidateMnemonic(mnemonic); err == nil {
+			t.Errorf("no error for %q", mnemonic)
+		}
+	}
+	entropy, err := NewEntropy(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic, _ := NewMnemonic(entropy)
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		t.Errorf("random mnemonic invalid: %v", err)
+	}
+	if _, err := NewEntropy(100); err != ErrEntropyLength {
+		t.Errorf("wrong error for 100 bit entropy: %v", err)
+	}
+}
+
+// Test vector 1 from https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+func TestDerive(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path, key, chainCode string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca", ""},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4", ""},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", ""},
+	}
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		key, err := master.Derive(path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		if got := hex.EncodeToString(key.key); got != test.key {
+			t.Errorf("%s: key mismatch: got %s, want %s", test.path, got, test.key)
+		}
+		if got := hex.EncodeToString(key.chainCode); test.chainCode != "" && got != test.chainCode {
+			t.Errorf("%s: chain code mismatch: got %s, want %s", test.path, got, test.chainCode)
+		}
+	}
+}
+
+func TestDeriveEthereumAccount(t *testing.T) {
+	seed := NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.Derive(DefaultBaseDerivationPath.Child(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if addr := crypto.PubkeyToAddress(key.PrivateKey().PublicKey); addr != want {
+		t.Errorf("address mismatch: got %x, want %x", addr, want)
+	}
+}
+
+func TestDerivationPath(t *testing.T) {
+	tests := []struct {
+		input string
+		path  DerivationPath
+		str   string
+	}{
+		{"m", DerivationPath{}, "m"},
+		{"m/44'/60'/0'/0", DefaultBaseDerivationPath, "m/44'/60'/0'/0"},
+		{"m/44h/60h/0h/0/7", DerivationPath{0x8000002c, 0x8000003c, 0x80000000, 0, 7}, "m/44'/60'/0'/0/7"},
+		{" m/2147483647' ", DerivationPath{0xffffffff}, "m/2147483647'"},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.input)
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+			continue
+		}
+		if len(path) != len(test.path) || path.String() != test.str {
+			t.Errorf("%q: got %v, want %v", test.input, path, test.str)
+		}
+	}
+	for _, input := range []string{"", "44'/60'", "m/", "m/x", "m/2147483648", "m/-1"} {
+		if _, err := ParseDerivationPath(input); err == nil {
+			t.Errorf("no error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+// ErrInvalidKey is returned for the rare derivations that don't yield a valid
+// key. BIP-32 specifies to proceed with the next index.
+var ErrInvalidKey = errors.New("derived key is invalid")
+
+// ExtendedKey is a BIP-32 private key together with its chain code.
+type ExtendedKey struct {
+	key       []byte // 32 byte private key
+	chainCode []byte
+}
+
+// NewMaster returns the master key of a seed.
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(secp256k1.S256().Params().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	return &ExtendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// Child derives the child key with the given index. Indexes from
+// HardenedKeyStart derive hardened keys.
+func (k *ExtendedKey) Child(idx uint32) (*ExtendedKey, error) {
+	var data []byte
+	if idx >= HardenedKeyStart {
+		data = append([]byte{0}, k.key...)
+	} else {
+		data = k.publicKey()
+	}
+	data = append(data, byte(idx>>24), byte(idx>>16), byte(idx>>8), byte(idx))
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := secp256k1.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	child := il.Add(il, new(big.Int).SetBytes(k.key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, ErrInvalidKey
+	}
+	key := make([]byte, 32)
+	b := child.Bytes()
+	copy(key[32-len(b):], b)
+	return &ExtendedKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// Derive derives the key at path relative to k.
+func (k *ExtendedKey) Derive(path DerivationPath) (*ExtendedKey, error) {
+	var err error
+	for _, idx := range path {
+		if k, err = k.Child(idx); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// PrivateKey returns the key as an ECDSA private key.
+func (k *ExtendedKey) PrivateKey() *ecdsa.PrivateKey {
+	return crypto.ToECDSA(k.key)
+}
+
+// publicKey returns the compressed public key.
+func (k *ExtendedKey) publicKey() []byte {
+	x, y := secp256k1.S256().ScalarBaseMult(k.key)
+	pub := make([]byte, 33)
+	pub[0] = 2 + byte(y.Bit(0))
+	b := x.Bytes()
+	copy(pub[33-len(b):], b)
+	return pub
+}
@@ -0,0 +1,127 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	ErrEntropyLength   = errors.New("entropy length must be a multiple of 32 bits between 128 and 256")
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrChecksum        = errors.New("mnemonic checksum mismatch")
+)
+
+var wordIndex = make(map[string]int, len(englishWords))
+
+func init() {
+	for i, w := range englishWords {
+		wordIndex[w] = i
+	}
+}
+
+// NewEntropy returns bits of random entropy for a mnemonic.
+func NewEntropy(bits int) ([]byte, error) {
+	if err := validateEntropyBits(bits); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+func validateEntropyBits(bits int) error {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return ErrEntropyLength
+	}
+	return nil
+}
+
+// NewMnemonic encodes entropy as an English mnemonic sentence.
+func NewMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if err := validateEntropyBits(bits); err != nil {
+		return "", err
+	}
+	// Append the checksum, one bit for every 32 bits of entropy.
+	cs := bits / 32
+	hash := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(cs))
+	data.Or(data, big.NewInt(int64(hash[0]>>uint(8-cs))))
+
+	words := make([]string, (bits+cs)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		idx := new(big.Int).And(data, mask)
+		words[i] = englishWords[idx.Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic sentence, verifying its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, w := range words {
+		idx, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(idx)))
+	}
+	cs := len(words) * 11 / 33
+	checksum := byte(new(big.Int).And(data, big.NewInt(int64(1)<<uint(cs)-1)).Int64())
+	data.Rsh(data, uint(cs))
+
+	entropy := make([]byte, len(words)*11*32/33/8)
+	b := data.Bytes()
+	copy(entropy[len(entropy)-len(b):], b)
+	hash := sha256.Sum256(entropy)
+	if hash[0]>>uint(8-cs) != checksum {
+		return nil, ErrChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks that mnemonic is a valid English mnemonic sentence.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// NewSeed derives the BIP-39 seed of a mnemonic sentence and an optional
+// password. The password is used as is, without unicode normalization.
+func NewSeed(mnemonic, password string) []byte {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
+}
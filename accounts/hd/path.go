@@ -0,0 +1,94 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the index of the first hardened child key.
+const HardenedKeyStart = 0x80000000
+
+// DefaultBaseDerivationPath is the BIP-44 path of Ethereum accounts. Accounts
+// are derived at its children m/44'/60'/0'/0/0, m/44'/60'/0'/0/1 and so on.
+var DefaultBaseDerivationPath = DerivationPath{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart, 0}
+
+// DerivationPath is the list of child indexes leading from the master key to a
+// derived key.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path like m/44'/60'/0'/0. Hardened indexes are
+// marked with a trailing ' or h.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, errors.New("derivation path must start with m")
+	}
+	var result DerivationPath
+	for _, part := range parts[1:] {
+		offset := uint64(0)
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			offset = HardenedKeyStart
+			part = part[:len(part)-1]
+		}
+		idx, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || idx >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid index %q in derivation path", part)
+		}
+		result = append(result, uint32(idx+offset))
+	}
+	return result, nil
+}
+
+// String implements fmt.Stringer.
+func (p DerivationPath) String() string {
+	result := "m"
+	for _, idx := range p {
+		result += "/"
+		if idx >= HardenedKeyStart {
+			result += fmt.Sprintf("%d'", idx-HardenedKeyStart)
+		} else {
+			result += fmt.Sprintf("%d", idx)
+		}
+	}
+	return result
+}
+
+// Child returns the path of the child with the given index.
+func (p DerivationPath) Child(idx uint32) DerivationPath {
+	child := make(DerivationPath, len(p), len(p)+1)
+	copy(child, p)
+	return append(child, idx)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p DerivationPath) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *DerivationPath) UnmarshalText(text []byte) error {
+	path, err := ParseDerivationPath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
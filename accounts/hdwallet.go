@@ -0,0 +1,349 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/hd"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/pborman/uuid"
+)
+
+// hdWalletDir is the subdirectory of the key directory holding HD wallets.
+const hdWalletDir = "hd"
+
+var (
+	ErrHDAccount      = errors.New("account is derived from an HD wallet")
+	ErrHDWalletExists = errors.New("HD wallet already exists")
+)
+
+// HDWallet is a hierarchical deterministic wallet. Its seed is stored encrypted
+// in the key directory, its accounts are derived from the seed at consecutive
+// child indexes of a base derivation path.
+type HDWallet struct {
+	file     string
+	id       string
+	basePath hd.DerivationPath
+	crypto   cryptoJSON
+
+	mu       sync.RWMutex
+	accounts []hdAccount
+}
+
+type hdAccount struct {
+	Address common.Address
+	Index   uint32
+}
+
+type hdWalletJSON struct {
+	Id       string            `json:"id"`
+	Version  int               `json:"version"`
+	BasePath hd.DerivationPath `json:"basepath"`
+	Crypto   cryptoJSON        `json:"crypto"`
+	Accounts []hdAccountJSON   `json:"accounts"`
+}
+
+type hdAccountJSON struct {
+	Address string `json:"address"`
+	Index   uint32 `json:"index"`
+}
+
+// ID returns the unique identifier of the wallet.
+func (w *HDWallet) ID() string {
+	return w.id
+}
+
+// File returns the path of the wallet file.
+func (w *HDWallet) File() string {
+	return w.file
+}
+
+// BasePath returns the derivation path of which accounts are children.
+func (w *HDWallet) BasePath() hd.DerivationPath {
+	return w.basePath
+}
+
+// Accounts returns the derived accounts in derivation order. Their File is
+// the wallet file.
+func (w *HDWallet) Accounts() []Account {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	accounts := make([]Account, len(w.accounts))
+	for i, acc := range w.accounts {
+		accounts[i] = Account{Address: acc.Address, File: w.file}
+	}
+	return accounts
+}
+
+// Path returns the derivation path of the account with the given address.
+func (w *HDWallet) Path(addr common.Address) (hd.DerivationPath, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, acc := range w.accounts {
+		if acc.Address == addr {
+			return w.basePath.Child(acc.Index), true
+		}
+	}
+	return nil, false
+}
+
+func (w *HDWallet) hasAddress(addr common.Address) bool {
+	_, ok := w.Path(addr)
+	return ok
+}
+
+// master decrypts the seed and returns the master key.
+func (w *HDWallet) master(auth string) (*hd.ExtendedKey, error) {
+	seed, err := decryptData(w.crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+	return hd.NewMaster(seed)
+}
+
+// key derives the key of the account with the given address.
+func (w *HDWallet) key(addr common.Address, auth string) (*Key, error) {
+	path, ok := w.Path(addr)
+	if !ok {
+		return nil, ErrNoMatch
+	}
+	master, err := w.master(auth)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyFromECDSA(ext.PrivateKey()), nil
+}
+
+// derive derives the account at the next unused index and stores it in the
+// wallet file.
+func (w *HDWallet) derive(auth string) (Account, error) {
+	master, err := w.master(auth)
+	if err != nil {
+		return Account{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next := uint32(0)
+	if n := len(w.accounts); n > 0 {
+		next = w.accounts[n-1].Index + 1
+	}
+	base, err := master.Derive(w.basePath)
+	if err != nil {
+		return Account{}, err
+	}
+	for ; next < hd.HardenedKeyStart; next++ {
+		ext, err := base.Child(next)
+		if err == hd.ErrInvalidKey {
+			continue // BIP-32: skip indexes without a valid key
+		}
+		if err != nil {
+			return Account{}, err
+		}
+		key := ext.PrivateKey()
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		zeroKey(key)
+
+		w.accounts = append(w.accounts, hdAccount{Address: addr, Index: next})
+		if err := w.save(); err != nil {
+			w.accounts = w.accounts[:len(w.accounts)-1]
+			return Account{}, err
+		}
+		return Account{Address: addr, File: w.file}, nil
+	}
+	return Account{}, errors.New("no more accounts in derivation path")
+}
+
+// save writes the wallet file. The caller must hold w.mu.
+func (w *HDWallet) save() error {
+	enc := hdWalletJSON{
+		Id:       w.id,
+		Version:  version,
+		BasePath: w.basePath,
+		Crypto:   w.crypto,
+		Accounts: make([]hdAccountJSON, len(w.accounts)),
+	}
+	for i, acc := range w.accounts {
+		enc.Accounts[i] = hdAccountJSON{Address: hex.EncodeToString(acc.Address[:]), Index: acc.Index}
+	}
+	content, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(w.file, content)
+}
+
+func loadHDWallet(file string) (*HDWallet, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var dec hdWalletJSON
+	if err := json.Unmarshal(content, &dec); err != nil {
+		return nil, err
+	}
+	if dec.Version != version {
+		return nil, fmt.Errorf("Version not supported: %v", dec.Version)
+	}
+	w := &HDWallet{file: file, id: dec.Id, basePath: dec.BasePath, crypto: dec.Crypto}
+	for _, acc := range dec.Accounts {
+		addr, err := hex.DecodeString(acc.Address)
+		if err != nil || len(addr) != common.AddressLength {
+			return nil, fmt.Errorf("invalid account address %q", acc.Address)
+		}
+		w.accounts = append(w.accounts, hdAccount{Address: common.BytesToAddress(addr), Index: acc.Index})
+	}
+	return w, nil
+}
+
+// loadHDWallets loads all wallets in the given directory.
+func loadHDWallets(dir string) []*HDWallet {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(logger.Warn).Infof("can't read HD wallet directory: %v", err)
+		}
+		return nil
+	}
+	var wallets []*HDWallet
+	for _, fi := range files {
+		if skipKeyFile(fi) {
+			continue
+		}
+		file := filepath.Join(dir, fi.Name())
+		w, err := loadHDWallet(file)
+		if err != nil {
+			glog.V(logger.Warn).Infof("can't load HD wallet %s: %v", file, err)
+			continue
+		}
+		wallets = append(wallets, w)
+	}
+	sort.Sort(hdWalletsByFile(wallets))
+	return wallets
+}
+
+type hdWalletsByFile []*HDWallet
+
+func (s hdWalletsByFile) Len() int           { return len(s) }
+func (s hdWalletsByFile) Less(i, j int) bool { return s[i].file < s[j].file }
+func (s hdWalletsByFile) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// NewHDWallet creates an HD wallet from a BIP-39 mnemonic and derives its first
+// account. The seed is encrypted with the passphrase, which is also needed to
+// derive further accounts and to sign. A nil base path selects
+// hd.DefaultBaseDerivationPath.
+func (am *Manager) NewHDWallet(mnemonic string, base hd.DerivationPath, passphrase string) (*HDWallet, error) {
+	if err := hd.ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = hd.DefaultBaseDerivationPath
+	}
+	var N, P int
+	if store, ok := am.keyStore.(*keyStorePassphrase); ok {
+		N, P = store.scryptN, store.scryptP
+	} else {
+		N, P = StandardScryptN, StandardScryptP
+	}
+	cryptoStruct, err := encryptData(hd.NewSeed(mnemonic, ""), passphrase, N, P)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.NewRandom().String()
+	w := &HDWallet{
+		file:     filepath.Join(am.cache.keydir, hdWalletDir, "UTC--"+toISO8601(time.Now().UTC())+"--"+id),
+		id:       id,
+		basePath: base,
+		crypto:   cryptoStruct,
+	}
+	// Deriving the first account saves the wallet file.
+	first, err := w.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if am.HasAddress(first.Address) {
+		os.Remove(w.file)
+		return nil, ErrHDWalletExists
+	}
+	am.mu.Lock()
+	am.wallets = append(am.wallets, w)
+	am.mu.Unlock()
+	return w, nil
+}
+
+// HDWallets returns all HD wallets of the key directory.
+func (am *Manager) HDWallets() []*HDWallet {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return append([]*HDWallet(nil), am.wallets...)
+}
+
+// HDWallet returns the HD wallet with the given ID.
+func (am *Manager) HDWallet(id string) (*HDWallet, error) {
+	for _, w := range am.HDWallets() {
+		if w.id == id {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown HD wallet %s", id)
+}
+
+// DeriveHDAccount derives the next account of the HD wallet with the given ID.
+func (am *Manager) DeriveHDAccount(id, passphrase string) (Account, error) {
+	w, err := am.HDWallet(id)
+	if err != nil {
+		return Account{}, err
+	}
+	return w.derive(passphrase)
+}
+
+// hdWallet returns the HD wallet holding the given account, or nil if the
+// account isn't derived from an HD wallet.
+func (am *Manager) hdWallet(addr common.Address) *HDWallet {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	for _, w := range am.wallets {
+		if w.hasAddress(addr) {
+			return w
+		}
+	}
+	return nil
+}
+
+// isHDAccount reports whether a, as resolved by getDecryptedKey, is derived from
+// an HD wallet.
+func (am *Manager) isHDAccount(a Account) bool {
+	w := am.hdWallet(a.Address)
+	return w != nil && w.file == a.File
+}
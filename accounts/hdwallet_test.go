@@ -0,0 +1,104 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/hd"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestHDWallet(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	w, err := am.NewHDWallet(testMnemonic, nil, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if accs := w.Accounts(); len(accs) != 1 || accs[0].Address != want {
+		t.Fatalf("wrong first account: %v", accs)
+	}
+	if _, err := am.DeriveHDAccount(w.ID(), "bar"); err != ErrDecrypt {
+		t.Errorf("wrong error for bad passphrase: %v", err)
+	}
+	second, err := am.DeriveHDAccount(w.ID(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := w.Path(second.Address); path.String() != "m/44'/60'/0'/0/1" {
+		t.Errorf("wrong path of second account: %v", path)
+	}
+	if !am.HasAddress(second.Address) {
+		t.Error("manager doesn't have derived account")
+	}
+	if accs := am.Accounts(); len(accs) != 2 {
+		t.Errorf("wrong number of manager accounts: %d", len(accs))
+	}
+
+	// Wallets are reloaded from the key directory.
+	am2 := NewManager(dir, veryLightScryptN, veryLightScryptP)
+	wallets := am2.HDWallets()
+	if len(wallets) != 1 || wallets[0].ID() != w.ID() || len(wallets[0].Accounts()) != 2 {
+		t.Fatalf("wrong wallets after reload: %v", wallets)
+	}
+	if _, err := am2.NewHDWallet(testMnemonic, nil, "foo"); err != ErrHDWalletExists {
+		t.Errorf("wrong error for duplicate wallet: %v", err)
+	}
+	if err := am2.DeleteAccount(second, "foo"); err != ErrHDAccount {
+		t.Errorf("wrong error for deleting HD account: %v", err)
+	}
+}
+
+func TestHDWalletSign(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	w, err := am.NewHDWallet(testMnemonic, hd.DerivationPath{hd.HardenedKeyStart + 44}, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := w.Accounts()[0]
+	hash := make([]byte, 32)
+
+	sig, err := am.SignWithPassphrase(a, "foo", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(*pub) != a.Address {
+		t.Error("signature from wrong key")
+	}
+	if _, err := am.Sign(a.Address, hash); err != ErrLocked {
+		t.Errorf("wrong error for locked account: %v", err)
+	}
+	if err := am.Unlock(a, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.Sign(a.Address, hash); err != nil {
+		t.Errorf("sign with unlocked account failed: %v", err)
+	}
+}
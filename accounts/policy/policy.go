@@ -0,0 +1,495 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package policy implements a rules engine deciding which signing requests
+// accounts may sign.
+//
+// A policy is loaded from a JSON file like the following, which lets the
+// account 0x8605... pay up to 1 ether per day to a single contract by calling
+// its deposit method, and issue cheques of up to 0.1 ether each:
+//
+//	{
+//	  "rules": [
+//	    {
+//	      "accounts": ["0x8605cdbbdb6d264aa742e77020dcbc58fcdce182"],
+//	      "kinds": ["transaction"],
+//	      "to": ["0x1d8d8f2bc5c3e6a8f1b3a06f4b9f4c5c3f6e2b1a"],
+//	      "methods": ["0xd0e30db0"],
+//	      "period": "24h",
+//	      "periodLimit": "0xde0b6b3a7640000"
+//	    },
+//	    {"kinds": ["cheque"], "maxValue": "0x16345785d8a0000"}
+//	  ],
+//	  "script": "policy.js",
+//	  "auditLog": "signing.log",
+//	  "state": "policy.state"
+//	}
+//
+// A request is approved if it satisfies all constraints of at least one rule.
+// If a script is given, it must define a function approve(request) which is
+// called for requests approved by the rules and returns true to approve the
+// request, or false or a string giving the reason to deny it. Requests are
+// passed to the script as objects with the fields kind, from, to, value (a
+// decimal string), data and method (hex strings).
+//
+// Every decision is written to the audit log as a JSON object on its own line.
+//
+// Only requests which were signed count against period limits. The values
+// spent are kept in the state file, which defaults to the policy file with the
+// extension .state, so limits hold across restarts. Spendings are kept by the
+// index of their rule, reordering the rules of a policy resets them.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// Rule permits the signing requests satisfying all of its constraints. Empty
+// constraints are not checked.
+type Rule struct {
+	Accounts []common.Address       `json:"accounts"` // signing accounts
+	Kinds    []accounts.RequestKind `json:"kinds"`    // kinds of requests
+	To       []common.Address       `json:"to"`       // recipients
+	Methods  []hexutil.Bytes        `json:"methods"`  // 4 byte method selectors of transactions
+
+	MaxValue *hexutil.Big `json:"maxValue"` // maximum value of a single request
+
+	// PeriodLimit is the maximum value of all requests approved by the rule for an
+	// account within Period.
+	Period      Duration     `json:"period"`
+	PeriodLimit *hexutil.Big `json:"periodLimit"`
+}
+
+// Duration is a time.Duration encoded as a string like "24h" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config is the content of a policy file.
+type Config struct {
+	Rules    []Rule `json:"rules"`
+	Script   string `json:"script"`   // path of the approval script
+	AuditLog string `json:"auditLog"` // path of the audit log
+	State    string `json:"state"`    // path of the state file
+}
+
+// Policy checks signing requests against rules and an optional script. It
+// implements accounts.Policy.
+type Policy struct {
+	rules  []Rule
+	script *script // nil if none
+
+	mu        sync.Mutex
+	spent     map[spendKey][]*spending // values approved by rules with period limits
+	now       func() time.Time
+	statePath string // empty if spendings aren't persisted
+
+	auditMu sync.Mutex
+	audit   *json.Encoder // nil if disabled
+}
+
+type spendKey struct {
+	rule    int
+	account common.Address
+}
+
+type spending struct {
+	time    time.Time
+	value   *big.Int
+	pending bool // approved, but not signed yet
+}
+
+// New creates a policy from rules and the source code of an approval script. An
+// empty source means no script. A policy without rules and script approves all
+// requests.
+func New(rules []Rule, src string) (*Policy, error) {
+	for i, rule := range rules {
+		if rule.PeriodLimit != nil && rule.Period <= 0 {
+			return nil, fmt.Errorf("rule %d: period limit without period", i)
+		}
+		for _, m := range rule.Methods {
+			if len(m) != 4 {
+				return nil, fmt.Errorf("rule %d: invalid method selector %v", i, m)
+			}
+		}
+	}
+	p := &Policy{rules: rules, spent: make(map[spendKey][]*spending), now: time.Now}
+	if src != "" {
+		s, err := newScript(src)
+		if err != nil {
+			return nil, err
+		}
+		p.script = s
+	}
+	return p, nil
+}
+
+// Load reads a policy file. The paths of the script and the audit log are
+// relative to the directory of the file.
+func Load(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid signing policy %s: %v", path, err)
+	}
+	resolve := func(file string) string {
+		if filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(filepath.Dir(path), file)
+	}
+	var src []byte
+	if cfg.Script != "" {
+		if src, err = ioutil.ReadFile(resolve(cfg.Script)); err != nil {
+			return nil, err
+		}
+	}
+	p, err := New(cfg.Rules, string(src))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing policy %s: %v", path, err)
+	}
+	if cfg.AuditLog != "" {
+		f, err := os.OpenFile(resolve(cfg.AuditLog), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+		p.SetAuditLog(f)
+	}
+	state := cfg.State
+	if state == "" {
+		state = strings.TrimSuffix(path, filepath.Ext(path)) + ".state"
+	}
+	if err := p.SetStateFile(resolve(state)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetAuditLog makes the policy record its decisions to w.
+func (p *Policy) SetAuditLog(w io.Writer) {
+	p.auditMu.Lock()
+	defer p.auditMu.Unlock()
+	p.audit = json.NewEncoder(w)
+}
+
+// Check implements accounts.Policy.
+func (p *Policy) Check(req *accounts.SignRequest) (func(signed bool), error) {
+	done, err := p.check(req)
+	p.record(req, err)
+	if err != nil {
+		glog.V(logger.Info).Infof("%v", err)
+	}
+	return done, err
+}
+
+func (p *Policy) check(req *accounts.SignRequest) (func(signed bool), error) {
+	done := func(bool) {}
+	if len(p.rules) > 0 {
+		var err error
+		if done, err = p.checkRules(req); err != nil {
+			return nil, err
+		}
+	}
+	if p.script != nil {
+		if reason := p.script.approve(req); reason != "" {
+			done(false)
+			return nil, &accounts.DeniedError{Reason: reason}
+		}
+	}
+	return done, nil
+}
+
+// checkRules approves the request if it satisfies one of the rules. Its value is
+// reserved against the rule's period limit until the returned function reports
+// whether the request was signed.
+func (p *Policy) checkRules(req *accounts.SignRequest) (func(signed bool), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	reason := "no rule permits the request"
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.applies(req) {
+			continue
+		}
+		if msg := rule.violation(req); msg != "" {
+			reason = fmt.Sprintf("rule %d: %s", i, msg)
+			continue
+		}
+		if rule.PeriodLimit != nil {
+			key := spendKey{i, req.From}
+			spent, total := p.spentSince(key, now.Add(-time.Duration(rule.Period)))
+			total.Add(total, value(req))
+			if total.Cmp(rule.PeriodLimit.ToInt()) > 0 {
+				reason = fmt.Sprintf("rule %d: value limit of %v per %v exceeded", i, rule.PeriodLimit.ToInt(), time.Duration(rule.Period))
+				continue
+			}
+			s := &spending{time: now, value: value(req), pending: true}
+			p.spent[key] = append(spent, s)
+			return func(signed bool) { p.settle(key, s, signed) }, nil
+		}
+		return func(bool) {}, nil
+	}
+	return nil, &accounts.DeniedError{Reason: reason}
+}
+
+// settle keeps a reserved spending if the request was signed and drops it
+// otherwise.
+func (p *Policy) settle(key spendKey, s *spending, signed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if signed {
+		s.pending = false
+		if err := p.saveState(); err != nil {
+			glog.V(logger.Warn).Infof("can't write signing policy state: %v", err)
+		}
+		return
+	}
+	spent := p.spent[key]
+	for i := range spent {
+		if spent[i] == s {
+			p.spent[key] = append(spent[:i:i], spent[i+1:]...)
+			break
+		}
+	}
+}
+
+// spentSince drops spendings older than since and returns the remaining ones
+// along with their total value.
+func (p *Policy) spentSince(key spendKey, since time.Time) ([]*spending, *big.Int) {
+	spent := p.spent[key]
+	for len(spent) > 0 && spent[0].time.Before(since) {
+		spent = spent[1:]
+	}
+	total := new(big.Int)
+	for _, s := range spent {
+		total.Add(total, s.value)
+	}
+	return spent, total
+}
+
+// stateEntry is a spending in the state file.
+type stateEntry struct {
+	Rule    int            `json:"rule"`
+	Account common.Address `json:"account"`
+	Time    time.Time      `json:"time"`
+	Value   *hexutil.Big   `json:"value"`
+}
+
+// SetStateFile makes the policy keep the values spent within period limits in
+// the file at path, loading those recorded by previous runs.
+func (p *Policy) SetStateFile(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.statePath = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var entries []stateEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("invalid signing policy state %s: %v", path, err)
+	}
+	spent := make(map[spendKey][]*spending)
+	for _, e := range entries {
+		// drop spendings of rules which no longer exist or have no limit
+		if e.Rule < 0 || e.Rule >= len(p.rules) || p.rules[e.Rule].PeriodLimit == nil || e.Value == nil {
+			continue
+		}
+		key := spendKey{e.Rule, e.Account}
+		spent[key] = append(spent[key], &spending{time: e.Time, value: e.Value.ToInt()})
+	}
+	for _, list := range spent {
+		sort.Sort(byTime(list))
+	}
+	p.spent = spent
+	return nil
+}
+
+// saveState writes the signed spendings to the state file, if there is one.
+func (p *Policy) saveState() error {
+	if p.statePath == "" {
+		return nil
+	}
+	entries := []stateEntry{}
+	for key, spent := range p.spent {
+		for _, s := range spent {
+			if !s.pending {
+				entries = append(entries, stateEntry{key.rule, key.account, s.time, (*hexutil.Big)(s.value)})
+			}
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	// replace the file atomically so a crash can't leave it truncated
+	tmp := p.statePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.statePath)
+}
+
+type byTime []*spending
+
+func (s byTime) Len() int           { return len(s) }
+func (s byTime) Less(i, j int) bool { return s[i].time.Before(s[j].time) }
+func (s byTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// applies reports whether the rule is about the request's account and kind.
+func (rule *Rule) applies(req *accounts.SignRequest) bool {
+	if len(rule.Accounts) > 0 && !containsAddress(rule.Accounts, req.From) {
+		return false
+	}
+	if len(rule.Kinds) > 0 {
+		for _, kind := range rule.Kinds {
+			if kind == req.Kind {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// violation returns why the request doesn't satisfy the rule's constraints, or
+// the empty string if it does.
+func (rule *Rule) violation(req *accounts.SignRequest) string {
+	if len(rule.To) > 0 && (req.To == nil || !containsAddress(rule.To, *req.To)) {
+		return "recipient not permitted"
+	}
+	if len(rule.Methods) > 0 {
+		sel := selector(req)
+		permitted := false
+		for _, m := range rule.Methods {
+			if sel != nil && string(m) == string(sel) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return "method not permitted"
+		}
+	}
+	if rule.MaxValue != nil && value(req).Cmp(rule.MaxValue.ToInt()) > 0 {
+		return fmt.Sprintf("value exceeds limit of %v", rule.MaxValue.ToInt())
+	}
+	return ""
+}
+
+// auditEntry is a single line of the audit log.
+type auditEntry struct {
+	Time     time.Time            `json:"time"`
+	Kind     accounts.RequestKind `json:"kind"`
+	From     common.Address       `json:"from"`
+	To       *common.Address      `json:"to,omitempty"`
+	Value    *hexutil.Big         `json:"value,omitempty"`
+	Method   hexutil.Bytes        `json:"method,omitempty"`
+	Approved bool                 `json:"approved"`
+	Reason   string               `json:"reason,omitempty"`
+}
+
+func (p *Policy) record(req *accounts.SignRequest, err error) {
+	p.auditMu.Lock()
+	defer p.auditMu.Unlock()
+	if p.audit == nil {
+		return
+	}
+	entry := &auditEntry{
+		Time:     time.Now(),
+		Kind:     req.Kind,
+		From:     req.From,
+		To:       req.To,
+		Method:   selector(req),
+		Approved: err == nil,
+	}
+	if req.Value != nil {
+		entry.Value = (*hexutil.Big)(req.Value)
+	}
+	if err != nil {
+		entry.Reason = err.Error()
+		if denied, ok := err.(*accounts.DeniedError); ok {
+			entry.Reason = denied.Reason
+		}
+	}
+	if err := p.audit.Encode(entry); err != nil {
+		glog.V(logger.Warn).Infof("can't write signing audit log: %v", err)
+	}
+}
+
+// selector returns the method selector of a transaction, or nil if it has none.
+func selector(req *accounts.SignRequest) []byte {
+	if req.Kind != accounts.TransactionRequest || len(req.Data) < 4 {
+		return nil
+	}
+	return req.Data[:4]
+}
+
+func value(req *accounts.SignRequest) *big.Int {
+	if req.Value == nil {
+		return new(big.Int)
+	}
+	return req.Value
+}
+
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
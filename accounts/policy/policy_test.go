@@ -0,0 +1,341 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	owner    = common.HexToAddress("0x8605cdbbdb6d264aa742e77020dcbc58fcdce182")
+	other    = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	contract = common.HexToAddress("0x1d8d8f2bc5c3e6a8f1b3a06f4b9f4c5c3f6e2b1a")
+	deposit  = hexutil.Bytes{0xd0, 0xe3, 0x0d, 0xb0}
+)
+
+func txRequest(from, to common.Address, value int64, data []byte) *accounts.SignRequest {
+	return &accounts.SignRequest{Kind: accounts.TransactionRequest, From: from, To: &to, Value: big.NewInt(value), Data: data}
+}
+
+// checkApproved checks the request against the policy, approved requests are
+// reported as signed.
+func checkApproved(t *testing.T, p *Policy, req *accounts.SignRequest, want bool) {
+	done, err := p.Check(req)
+	if want && err != nil {
+		t.Errorf("request %+v denied: %v", req, err)
+	}
+	if err == nil {
+		done(true)
+	}
+	if !want {
+		if err == nil {
+			t.Errorf("request %+v approved", req)
+		} else if _, ok := err.(*accounts.DeniedError); !ok {
+			t.Errorf("request %+v: got error %T, want *accounts.DeniedError", req, err)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	p, err := New([]Rule{
+		{
+			Accounts: []common.Address{owner},
+			Kinds:    []accounts.RequestKind{accounts.TransactionRequest},
+			To:       []common.Address{contract},
+			Methods:  []hexutil.Bytes{deposit},
+			MaxValue: (*hexutil.Big)(big.NewInt(100)),
+		},
+		{Kinds: []accounts.RequestKind{accounts.MessageRequest}},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := append(deposit, 1, 2, 3)
+	checkApproved(t, p, txRequest(owner, contract, 100, call), true)
+	checkApproved(t, p, txRequest(owner, contract, 101, call), false)
+	checkApproved(t, p, txRequest(owner, other, 1, call), false)
+	checkApproved(t, p, txRequest(owner, contract, 1, []byte{1, 2, 3, 4}), false)
+	checkApproved(t, p, txRequest(owner, contract, 1, nil), false)
+	checkApproved(t, p, txRequest(other, contract, 1, call), false)
+	checkApproved(t, p, &accounts.SignRequest{Kind: accounts.ChequeRequest, From: owner, To: &contract}, false)
+	checkApproved(t, p, &accounts.SignRequest{Kind: accounts.MessageRequest, From: other, Data: []byte("hello")}, true)
+
+	// Denials explain which constraint was violated.
+	_, err = p.Check(txRequest(owner, other, 1, call))
+	if err == nil || !strings.Contains(err.Error(), "recipient not permitted") {
+		t.Errorf("wrong denial: %v", err)
+	}
+}
+
+func TestPeriodLimit(t *testing.T) {
+	p, err := New([]Rule{{
+		Kinds:       []accounts.RequestKind{accounts.ChequeRequest},
+		Period:      Duration(time.Hour),
+		PeriodLimit: (*hexutil.Big)(big.NewInt(10)),
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000000, 0)
+	p.now = func() time.Time { return now }
+	cheque := func(from common.Address, amount int64) *accounts.SignRequest {
+		return &accounts.SignRequest{Kind: accounts.ChequeRequest, From: from, To: &contract, Value: big.NewInt(amount)}
+	}
+
+	checkApproved(t, p, cheque(owner, 6), true)
+	now = now.Add(30 * time.Minute)
+	checkApproved(t, p, cheque(owner, 5), false)
+	checkApproved(t, p, cheque(owner, 4), true)
+	checkApproved(t, p, cheque(owner, 1), false)
+	// Limits are per account.
+	checkApproved(t, p, cheque(other, 10), true)
+	// Spendings expire after the period.
+	now = now.Add(31 * time.Minute)
+	checkApproved(t, p, cheque(owner, 6), true)
+	checkApproved(t, p, cheque(owner, 1), false)
+}
+
+func TestPeriodLimitUnsigned(t *testing.T) {
+	limit := Rule{Period: Duration(time.Hour), PeriodLimit: (*hexutil.Big)(big.NewInt(10))}
+	p, err := New([]Rule{limit}, "function approve(req) { return req.value != '3'; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Requests denied by the script don't count against the limit.
+	for i := 0; i < 5; i++ {
+		checkApproved(t, p, txRequest(owner, contract, 3, nil), false)
+	}
+	// Neither do requests which failed to be signed, but they are reserved
+	// until signing is done.
+	done, err := p.Check(txRequest(owner, contract, 6, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 5, nil), false)
+	done(false)
+	checkApproved(t, p, txRequest(owner, contract, 10, nil), true)
+	checkApproved(t, p, txRequest(owner, contract, 1, nil), false)
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policy.json")
+	cfg := `{"rules": [{"kinds": ["message"]}, {"period": "1h", "periodLimit": "0xa"}]}`
+	ioutil.WriteFile(path, []byte(cfg), 0600)
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 4, nil), true)
+	checkApproved(t, p, txRequest(owner, contract, 3, nil), true)
+	if _, err := os.Stat(filepath.Join(dir, "policy.state")); err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+
+	// Spendings are restored after a restart.
+	p, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 4, nil), false)
+	checkApproved(t, p, txRequest(owner, contract, 3, nil), true)
+
+	// Spendings of rules without limits are dropped.
+	ioutil.WriteFile(path, []byte(`{"rules": [{"kinds": ["message"]}, {"kinds": ["cheque"]}, {"period": "1h", "periodLimit": "0xa"}]}`), 0600)
+	if p, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 10, nil), true)
+}
+
+func TestInvalidRules(t *testing.T) {
+	if _, err := New([]Rule{{PeriodLimit: (*hexutil.Big)(big.NewInt(1))}}, ""); err == nil {
+		t.Error("accepted period limit without period")
+	}
+	if _, err := New([]Rule{{Methods: []hexutil.Bytes{{1, 2}}}}, ""); err == nil {
+		t.Error("accepted short method selector")
+	}
+	if _, err := New(nil, "var x = 1;"); err == nil {
+		t.Error("accepted script without approve function")
+	}
+}
+
+func TestScript(t *testing.T) {
+	src := `
+function approve(req) {
+	if (req.kind != "transaction") return true;
+	if (req.to != "` + contract.Hex() + `") return "unknown recipient";
+	if (req.method != "0xd0e30db0") return false;
+	return parseInt(req.value) <= 1000;
+}`
+	p, err := New(nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := append(deposit, 1)
+	checkApproved(t, p, txRequest(owner, contract, 1000, call), true)
+	checkApproved(t, p, txRequest(owner, contract, 1001, call), false)
+	checkApproved(t, p, txRequest(owner, contract, 1, nil), false)
+	checkApproved(t, p, &accounts.SignRequest{Kind: accounts.MessageRequest, From: owner}, true)
+	if _, err := p.Check(txRequest(owner, other, 1, call)); err == nil || err.(*accounts.DeniedError).Reason != "unknown recipient" {
+		t.Errorf("wrong denial: %v", err)
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	p, err := New(nil, "function approve(req) { if (req.kind == 'message') return true; var i = 0; for (;;) { i++; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 1, nil), false)
+	// The VM is usable after an interrupted call.
+	checkApproved(t, p, &accounts.SignRequest{Kind: accounts.MessageRequest, From: owner}, true)
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := `{
+	"rules": [{"to": ["` + contract.Hex() + `"], "maxValue": "0x10"}],
+	"script": "policy.js",
+	"auditLog": "audit.log"
+}`
+	ioutil.WriteFile(filepath.Join(dir, "policy.json"), []byte(cfg), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "policy.js"), []byte("function approve(req) { return req.value != '13'; }"), 0600)
+
+	p, err := Load(filepath.Join(dir, "policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApproved(t, p, txRequest(owner, contract, 16, deposit), true)
+	checkApproved(t, p, txRequest(owner, contract, 17, nil), false)
+	checkApproved(t, p, txRequest(owner, contract, 13, nil), false)
+
+	log, err := ioutil.ReadFile(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []auditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	for scanner.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid audit log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit log entries, want 3", len(entries))
+	}
+	if e := entries[0]; !e.Approved || e.From != owner || *e.To != contract || e.Value.ToInt().Int64() != 16 || !bytes.Equal(e.Method, deposit) {
+		t.Errorf("wrong entry for approved request: %+v", e)
+	}
+	if e := entries[1]; e.Approved || !strings.Contains(e.Reason, "value exceeds limit") {
+		t.Errorf("wrong entry for request denied by rule: %+v", e)
+	}
+	if e := entries[2]; e.Approved || e.Reason != "denied by approval script" {
+		t.Errorf("wrong entry for request denied by script: %+v", e)
+	}
+}
+
+func TestManagerPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	acc, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Unlock(acc, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	signer := accounts.NewAccountSigner(am, acc.Address)
+	hash := crypto.Keccak256([]byte("tx"))
+	req := txRequest(acc.Address, other, 1, nil)
+
+	if _, err := accounts.SignRequestHash(signer, req, hash); err != nil {
+		t.Fatalf("request denied without policy: %v", err)
+	}
+	p, _ := New([]Rule{{
+		To:          []common.Address{contract},
+		Period:      Duration(time.Hour),
+		PeriodLimit: (*hexutil.Big)(big.NewInt(10)),
+	}}, "")
+	am.SetPolicy(p)
+	if _, err := accounts.SignRequestHash(signer, req, hash); err == nil {
+		t.Fatal("request approved despite policy")
+	}
+	// Bare hashes are subject to the policy as well.
+	if _, err := am.Sign(acc.Address, hash); err == nil {
+		t.Fatal("hash signed despite policy")
+	}
+	if _, err := am.SignWithPassphrase(acc, "foo", hash); err == nil {
+		t.Fatal("hash signed with passphrase despite policy")
+	}
+
+	// Failed signing doesn't count against the period limit.
+	req = txRequest(acc.Address, contract, 10, nil)
+	if _, err := am.SignRequestWithPassphrase(acc, "bar", req, hash); err != accounts.ErrDecrypt {
+		t.Fatalf("got error %v, want %v", err, accounts.ErrDecrypt)
+	}
+	if _, err := am.SignRequest(req, hash); err != nil {
+		t.Fatalf("request denied: %v", err)
+	}
+	if _, err := am.SignRequest(txRequest(acc.Address, contract, 1, nil), hash); err == nil {
+		t.Fatal("request approved beyond period limit")
+	}
+
+	// Keys held in memory are subject to the policy through WithPolicy.
+	key, _ := crypto.GenerateKey()
+	keySigner := accounts.WithPolicy(accounts.NewKeySigner(key), am)
+	if _, err := keySigner.SignHash(hash); err == nil {
+		t.Fatal("hash signed despite policy")
+	}
+	req = txRequest(keySigner.Address(), other, 1, nil)
+	if _, err := accounts.SignRequestHash(keySigner, req, hash); err == nil {
+		t.Fatal("request approved despite policy")
+	}
+	req.To = &contract
+	if _, err := accounts.SignRequestHash(keySigner, req, hash); err != nil {
+		t.Fatalf("request denied: %v", err)
+	}
+}
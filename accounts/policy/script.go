@@ -0,0 +1,128 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/robertkrimen/otto"
+)
+
+// scriptTimeout bounds the time the approval script may run for a request.
+const scriptTimeout = time.Second
+
+var errScriptTimeout = errors.New("approval script timed out")
+
+// script is an approval script running in a JavaScript VM.
+type script struct {
+	mu   sync.Mutex
+	vm   *otto.Otto
+	call uint64 // number of the running call, stale interrupts are ignored
+}
+
+func newScript(src string) (*script, error) {
+	vm := otto.New()
+	vm.Interrupt = make(chan func(), 1)
+	if _, err := vm.Run(src); err != nil {
+		return nil, fmt.Errorf("invalid approval script: %v", err)
+	}
+	approve, err := vm.Get("approve")
+	if err != nil {
+		return nil, err
+	}
+	if !approve.IsFunction() {
+		return nil, errors.New("approval script must define a function approve(request)")
+	}
+	return &script{vm: vm}, nil
+}
+
+// approve calls the script's approve function, returning the reason for denying
+// the request or the empty string if it is approved.
+func (s *script) approve(req *accounts.SignRequest) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.run(req)
+	if err != nil {
+		return fmt.Sprintf("approval script failed: %v", err)
+	}
+	switch {
+	case result.IsBoolean():
+		if ok, _ := result.ToBoolean(); ok {
+			return ""
+		}
+	case result.IsString():
+		if reason := result.String(); reason != "" {
+			return reason
+		}
+	}
+	return "denied by approval script"
+}
+
+func (s *script) run(req *accounts.SignRequest) (result otto.Value, err error) {
+	// Drop interrupts of earlier calls which completed just in time.
+	select {
+	case <-s.vm.Interrupt:
+	default:
+	}
+	s.call++
+	call := s.call
+	timer := time.AfterFunc(scriptTimeout, func() {
+		select {
+		case s.vm.Interrupt <- func() {
+			if s.call == call {
+				panic(errScriptTimeout)
+			}
+		}:
+		default:
+		}
+	})
+	defer timer.Stop()
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught != errScriptTimeout {
+				panic(caught)
+			}
+			err = errScriptTimeout
+		}
+	}()
+
+	obj, err := s.vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	obj.Set("kind", string(req.Kind))
+	obj.Set("from", req.From.Hex())
+	if req.To != nil {
+		obj.Set("to", req.To.Hex())
+	} else {
+		obj.Set("to", nil)
+	}
+	obj.Set("value", value(req).String())
+	obj.Set("data", hexutil.Encode(req.Data))
+	if sel := selector(req); sel != nil {
+		obj.Set("method", hexutil.Encode(sel))
+	} else {
+		obj.Set("method", nil)
+	}
+	return s.vm.Call("approve", nil, obj)
+}
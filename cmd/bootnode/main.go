@@ -96,7 +96,7 @@ func main() {
 			utils.Fatalf("%v", err)
 		}
 	} else {
-		if _, err := discover.ListenUDP(nodeKey, *listenAddr, natm, "", restrictList); err != nil {
+		if _, err := discover.ListenUDP(nodeKey, *listenAddr, natm, "", "", nil, restrictList); err != nil {
 			utils.Fatalf("%v", err)
 		}
 	}
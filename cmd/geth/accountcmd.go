@@ -19,8 +19,10 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/hd"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/console"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -172,6 +174,69 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Action:    hdWalletList,
+				Name:      "hdlist",
+				Usage:     "Print HD wallets and their accounts",
+				ArgsUsage: " ",
+				Description: `
+    geth account hdlist
+
+Prints the ID of every HD wallet in the keystore, followed by the addresses and
+derivation paths of its accounts.
+`,
+			},
+			{
+				Action:    hdWalletCreate,
+				Name:      "hdnew",
+				Usage:     "Create a new HD wallet",
+				ArgsUsage: " ",
+				Description: `
+    geth account hdnew
+
+Creates a hierarchical deterministic (BIP-32) wallet from a new random BIP-39
+mnemonic and derives its first account. Prints the mnemonic, the wallet ID and
+the address.
+
+The wallet seed is saved in encrypted format, you are prompted for a passphrase.
+The passphrase is needed to derive accounts and to unlock them.
+
+The mnemonic restores all accounts of the wallet with 'geth account hdimport'.
+Write it down and keep it safe; it is not stored and can't be shown again.
+`,
+			},
+			{
+				Action:    hdWalletImport,
+				Name:      "hdimport",
+				Usage:     "Import a BIP-39 mnemonic into a new HD wallet",
+				ArgsUsage: "<mnemonicFile> [<basePath>]",
+				Description: `
+    geth account hdimport <mnemonicfile> [<basepath>]
+
+Creates an HD wallet from the mnemonic in <mnemonicfile> and derives its first
+account. Prints the wallet ID and the address.
+
+Accounts are derived as the children of <basepath>, which defaults to
+m/44'/60'/0'/0.
+
+For non-interactive use the passphrase can be specified with the --password flag:
+
+    geth --password <passwordfile> account hdimport <mnemonicfile>
+`,
+			},
+			{
+				Action:    hdWalletDerive,
+				Name:      "hdderive",
+				Usage:     "Derive new accounts of an HD wallet",
+				ArgsUsage: "<walletID> [<count>]",
+				Description: `
+    geth account hdderive <walletid> [<count>]
+
+Derives the next <count> accounts (default 1) of the HD wallet and prints their
+addresses. Deriving the same number of accounts from the same mnemonic always
+yields the same accounts.
 `,
 			},
 		},
@@ -336,3 +401,102 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+func hdWalletList(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	for i, w := range stack.AccountManager().HDWallets() {
+		fmt.Printf("Wallet #%d: %s %s\n", i, w.ID(), w.File())
+		printHDAccounts(w, w.Accounts())
+	}
+	return nil
+}
+
+func printHDAccounts(w *accounts.HDWallet, accts []accounts.Account) {
+	for _, acct := range accts {
+		path, _ := w.Path(acct.Address)
+		fmt.Printf("  Address: {%x} %s\n", acct.Address, path)
+	}
+}
+
+// hdWalletCreate creates an HD wallet from a new random mnemonic.
+func hdWalletCreate(ctx *cli.Context) error {
+	entropy, err := hd.NewEntropy(256)
+	if err != nil {
+		utils.Fatalf("Failed to generate mnemonic: %v", err)
+	}
+	mnemonic, err := hd.NewMnemonic(entropy)
+	if err != nil {
+		utils.Fatalf("Failed to generate mnemonic: %v", err)
+	}
+	w := createHDWallet(ctx, mnemonic, nil)
+	fmt.Printf("Mnemonic: %s\n", mnemonic)
+	fmt.Println("Write down the mnemonic and keep it safe. It restores all accounts of the wallet.")
+	fmt.Printf("Wallet: %s\n", w.ID())
+	printHDAccounts(w, w.Accounts())
+	return nil
+}
+
+func hdWalletImport(ctx *cli.Context) error {
+	file := ctx.Args().First()
+	if len(file) == 0 {
+		utils.Fatalf("mnemonic file must be given as argument")
+	}
+	mnemonic, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Fatalf("Could not read mnemonic file: %v", err)
+	}
+	var base hd.DerivationPath
+	if len(ctx.Args()) > 1 {
+		if base, err = hd.ParseDerivationPath(ctx.Args()[1]); err != nil {
+			utils.Fatalf("Invalid base path: %v", err)
+		}
+	}
+	w := createHDWallet(ctx, string(mnemonic), base)
+	fmt.Printf("Wallet: %s\n", w.ID())
+	printHDAccounts(w, w.Accounts())
+	return nil
+}
+
+func createHDWallet(ctx *cli.Context, mnemonic string, base hd.DerivationPath) *accounts.HDWallet {
+	if err := hd.ValidateMnemonic(mnemonic); err != nil {
+		utils.Fatalf("Invalid mnemonic: %v", err)
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	password := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+	w, err := stack.AccountManager().NewHDWallet(mnemonic, base, password)
+	if err != nil {
+		utils.Fatalf("Failed to create wallet: %v", err)
+	}
+	return w
+}
+
+func hdWalletDerive(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("wallet ID must be given as argument")
+	}
+	count := 1
+	if len(ctx.Args()) > 1 {
+		n, err := strconv.Atoi(ctx.Args()[1])
+		if err != nil || n < 1 {
+			utils.Fatalf("Invalid account count %q", ctx.Args()[1])
+		}
+		count = n
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	accman := stack.AccountManager()
+	w, err := accman.HDWallet(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	password := getPassPhrase("Unlocking wallet "+w.ID(), false, 0, utils.MakePasswordList(ctx))
+	var derived []accounts.Account
+	for i := 0; i < count; i++ {
+		acct, err := accman.DeriveHDAccount(w.ID(), password)
+		if err != nil {
+			utils.Fatalf("Could not derive account: %v", err)
+		}
+		derived = append(derived, acct)
+	}
+	printHDAccounts(w, derived)
+	return nil
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
 	"runtime"
@@ -78,6 +79,46 @@ Repeat passphrase: {{.InputLine "foobar"}}
 	geth.expectRegexp(`Address: \{[0-9a-f]{40}\}\n`)
 }
 
+func TestAccountHDWallet(t *testing.T) {
+	datadir := tmpdir(t)
+	mnemonic := filepath.Join(datadir, "mnemonic")
+	ioutil.WriteFile(mnemonic, []byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\n"), 0600)
+
+	geth := runGeth(t, "--datadir", datadir, "--lightkdf", "account", "hdimport", mnemonic)
+	geth.expect(`
+Your new wallet is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+`)
+	geth.expectRegexp(`Wallet: [0-9a-f-]{36}
+  Address: \{9858effd232b4033e47d90003d41ec34ecaeda94\} m/44'/60'/0'/0/0
+`)
+	geth.expectExit()
+
+	files, err := ioutil.ReadDir(filepath.Join(datadir, "keystore", "hd"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("wallet file not found: %v", err)
+	}
+	var wallet struct{ Id string }
+	content, _ := ioutil.ReadFile(filepath.Join(datadir, "keystore", "hd", files[0].Name()))
+	if err := json.Unmarshal(content, &wallet); err != nil {
+		t.Fatal(err)
+	}
+	id := wallet.Id
+
+	geth = runGeth(t, "--datadir", datadir, "--lightkdf", "account", "hdderive", id, "2")
+	defer geth.expectExit()
+	geth.expect(`
+Unlocking wallet ` + id + `
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+`)
+	geth.expectRegexp(`  Address: \{[0-9a-f]{40}\} m/44'/60'/0'/0/1
+  Address: \{[0-9a-f]{40}\} m/44'/60'/0'/0/2
+`)
+}
+
 func TestAccountNewBadRepeat(t *testing.T) {
 	geth := runGeth(t, "--lightkdf", "account", "new")
 	defer geth.expectExit()
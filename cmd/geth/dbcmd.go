@@ -0,0 +1,65 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Manage the storage of the chain databases",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+Manage the storage engines ("backends") of the chain databases in the data
+directory. The backend is chosen with --dbbackend.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    convertDB,
+				Name:      "convert",
+				Usage:     "Convert the chain databases to another backend",
+				ArgsUsage: " ",
+				Description: `
+    geth --dbbackend boltdb db convert
+
+Copies the full and light chain databases, the node database and the registry
+of banned and trusted nodes of the data directory into databases of the backend
+given by --dbbackend, replacing the originals. The node must not be running
+during the conversion.
+`,
+			},
+		},
+	}
+)
+
+// chainDbNames are the names of the databases converted by the convert command.
+var chainDbNames = []string{"chaindata", "lightchaindata", "nodes", "registry"}
+
+func convertDB(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+
+	dirs := make([]string, len(chainDbNames))
+	for i, name := range chainDbNames {
+		dirs[i] = stack.ResolvePath(name)
+	}
+	utils.ConvertDatabases(dirs, ctx.GlobalString(utils.DBBackendFlag.Name), ctx.GlobalInt(utils.CacheFlag.Name))
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -54,6 +55,18 @@ Regular users do not need to execute it.
 		Category:  "MISCELLANEOUS COMMANDS",
 		Description: `
 The output of this command is supposed to be machine-readable.
+`,
+	}
+	dumpConfigCommand = cli.Command{
+		Action:    dumpConfig,
+		Name:      "dumpconfig",
+		Usage:     "Show configuration values",
+		ArgsUsage: " ",
+		Category:  "MISCELLANEOUS COMMANDS",
+		Description: `
+The dumpconfig command prints the effective configuration, assembled from the
+--config file and command line flags, in TOML format. The output can be used
+as a starting point for a configuration file.
 `,
 	}
 	licenseCommand = cli.Command{
@@ -95,6 +108,16 @@ func makedag(ctx *cli.Context) error {
 	return nil
 }
 
+func dumpConfig(ctx *cli.Context) error {
+	nodeConf := utils.MakeNodeConfig(ctx, clientIdentifier, gitCommit)
+	stack, err := node.New(nodeConf)
+	if err != nil {
+		utils.Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	ethConf := utils.MakeEthConfig(ctx, stack, makeExtraData())
+	return utils.DumpConfig(os.Stdout, map[string]interface{}{"Node": nodeConf, "Eth": ethConf})
+}
+
 func version(ctx *cli.Context) error {
 	fmt.Println(strings.Title(clientIdentifier))
 	fmt.Println("Version:", params.Version)
@@ -1,20 +1,17 @@
 package main
 
 import (
-	"crypto/ecdsa"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"runtime"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/contracts/chequebook"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/internal/debug"
@@ -76,6 +73,14 @@ var (
 		Name:  "sync",
 		Usage: "Swarm Syncing enabled (default true)",
 	}
+	SwarmBandwidthFlag = cli.Uint64Flag{
+		Name:  "bzzbandwidth",
+		Usage: "Swarm outgoing bandwidth limit for all peers in bytes per second (default 0=unlimited)",
+	}
+	SwarmPeerBandwidthFlag = cli.Uint64Flag{
+		Name:  "bzzpeerbandwidth",
+		Usage: "Swarm outgoing bandwidth limit per peer in bytes per second (default 0=unlimited)",
+	}
 	EthAPIFlag = cli.StringFlag{
 		Name:  "ethapi",
 		Usage: "URL of the Ethereum API provider",
@@ -122,6 +127,10 @@ var (
 		Usage: "The url + port to communicate to the visualization server (ex/default 'http://localhost:8585')",
 		Value: "http://localhost:8585",
 	}
+	TranscoderFlag = cli.BoolFlag{
+		Name:  "transcoder",
+		Usage: "Advertise this node as a transcoder on discovery v5",
+	}
 	LivepeerNetworkIdFlag = cli.IntFlag{
 		Name:  "lpnetworkid",
 		Usage: "Network identifier (integer, default 326=livepeer toy net)",
@@ -149,6 +158,35 @@ func init() {
 			ArgsUsage:   " <streamID>",
 			Description: "This command will use ffplay to play the given stream ID from the Livepeer network",
 		},
+		{
+			Action:    dumpConfig,
+			Name:      "dumpconfig",
+			Usage:     "Show configuration values",
+			ArgsUsage: " ",
+			Description: `
+Prints the effective node and swarm configuration, assembled from the --config
+file and command line flags, in TOML format.
+`,
+		},
+		{
+			Name:  "db",
+			Usage: "Manage the storage of the swarm databases",
+			Subcommands: []cli.Command{
+				{
+					Action:    convertDB,
+					Name:      "convert",
+					Usage:     "Convert the swarm databases to another backend",
+					ArgsUsage: " ",
+					Description: `
+    livepeer --dbbackend boltdb db convert
+
+Copies the chunk, request, reputation and name databases of all swarm accounts
+into databases of the backend given by --dbbackend, replacing the originals.
+The node must not be running during the conversion.
+`,
+				},
+			},
+		},
 		{
 			Action:    version,
 			Name:      "version",
@@ -161,10 +199,13 @@ The output of this command is supposed to be machine-readable.
 	}
 
 	app.Flags = []cli.Flag{
+		utils.ConfigFileFlag,
 		utils.IdentityFlag,
 		utils.DataDirFlag,
 		utils.BootnodesFlag,
 		utils.KeyStoreDirFlag,
+		utils.ExternalSignerFlag,
+		utils.SigningPolicyFlag,
 		utils.ListenPortFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -176,6 +217,7 @@ The output of this command is supposed to be machine-readable.
 		utils.IPCDisabledFlag,
 		utils.IPCApiFlag,
 		utils.IPCPathFlag,
+		utils.DBBackendFlag,
 		utils.LPNetFlag,
 		// bzzd-specific flags
 		CorsStringFlag,
@@ -183,6 +225,8 @@ The output of this command is supposed to be machine-readable.
 		SwarmConfigPathFlag,
 		SwarmSwapEnabledFlag,
 		SwarmSyncEnabledFlag,
+		SwarmBandwidthFlag,
+		SwarmPeerBandwidthFlag,
 		SwarmPortFlag,
 		SwarmAccountFlag,
 		SwarmNetworkIdFlag,
@@ -198,6 +242,7 @@ The output of this command is supposed to be machine-readable.
 		VizEnabledFlag,
 		VizHostFlag,
 		LivepeerNetworkIdFlag,
+		TranscoderFlag,
 	}
 	app.Flags = append(app.Flags, debug.Flags...)
 	app.Before = func(ctx *cli.Context) error {
@@ -243,13 +288,12 @@ func livepeer(ctx *cli.Context) error {
 	if ctx.GlobalIsSet(utils.BootnodesFlag.Name) {
 		bootnodes := strings.Split(ctx.GlobalString(utils.BootnodesFlag.Name), ",")
 		injectBootnodes(stack.Server(), bootnodes)
-	} else {
-		if networkId == lpn.NetworkId {
-			injectBootnodes(stack.Server(), testbetBootNodes)
-		}
+	} else if networkId == lpn.NetworkId && stack.Server().DiscV5 == nil {
+		// with discovery v5 peers are found through the network topic
+		injectBootnodes(stack.Server(), testbetBootNodes)
 	}
 
-	// Start consuming visualization events and reporting your peers every 20 seconds.
+	// Start consuming visualization events and reporting your peers when they change.
 	// See comments below near the consumeVizEvents() method
 	vizClient.NodeID = fmt.Sprintf("%s", stack.Server().Self().ID)
 
@@ -285,12 +329,19 @@ func stream(ctx *cli.Context) error {
 	return nil
 }
 
-// Call peer reporting event at some fixed interval like 20 seconds for the visualization server
+// Report the peers to the visualization server whenever a peer is added or dropped
 func startPeerReporting(node *node.Node, doneChan chan bool, vizClient *streamingVizClient.Client) {
-	tickChan := time.NewTicker(20 * time.Second).C
+	sub := node.Server().SubscribeEvents()
+	defer sub.Unsubscribe()
 	for {
 		select {
-		case <-tickChan:
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			if t := ev.Data.(p2p.PeerEvent).Type; t != p2p.PeerEventTypeAdd && t != p2p.PeerEventTypeDrop {
+				continue
+			}
 			peers := node.Server().PeersInfo()
 			peerIDs := make([]string, 0, len(peers))
 			for _, p := range peers {
@@ -303,23 +354,86 @@ func startPeerReporting(node *node.Node, doneChan chan bool, vizClient *streamin
 	}
 }
 
-func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizClient.Client) {
-	prvkey := getAccount(ctx, stack)
-
+// dumpConfig prints the configuration without unlocking or creating the swarm
+// account, the swarm node's config.json is only read if the account is given
+// as an address.
+func dumpConfig(ctx *cli.Context) error {
+	nodeConf := utils.MakeNodeConfig(ctx, clientIdentifier, gitCommit)
+	var address common.Address
+	if keyid := ctx.GlobalString(SwarmAccountFlag.Name); common.IsHexAddress(keyid) {
+		address = common.HexToAddress(keyid)
+	}
 	chbookaddr := common.HexToAddress(ctx.GlobalString(ChequebookAddrFlag.Name))
-	bzzdir := ctx.GlobalString(SwarmConfigPathFlag.Name)
-	if bzzdir == "" {
-		bzzdir = stack.InstanceDir()
+	bzzconfig, err := bzzapi.ReadConfig(bzzDir(ctx, nodeConf.InstanceDir()), address, chbookaddr, ctx.GlobalUint64(LivepeerNetworkIdFlag.Name), ctx.GlobalString(RTMPFlag.Name))
+	if err != nil {
+		utils.Fatalf("unable to configure swarm: %v", err)
 	}
+	applyBzzFlags(ctx, bzzconfig)
+	return utils.DumpConfig(os.Stdout, map[string]interface{}{"Node": nodeConf, "Swarm": bzzconfig})
+}
 
-	bzzconfig, err := bzzapi.NewConfig(bzzdir, chbookaddr, prvkey, ctx.GlobalUint64(LivepeerNetworkIdFlag.Name), ctx.GlobalString(RTMPFlag.Name))
+// bzzDir returns the directory of the swarm configuration, by default the
+// node's instance directory.
+func bzzDir(ctx *cli.Context, instanceDir string) string {
+	if bzzdir := ctx.GlobalString(SwarmConfigPathFlag.Name); bzzdir != "" {
+		return bzzdir
+	}
+	return instanceDir
+}
+
+// makeBzzConfig loads the swarm configuration of the account selected by the
+// command line flags, applying the configuration file and flags on top of it.
+func makeBzzConfig(ctx *cli.Context, stack *node.Node) *bzzapi.Config {
+	signer := getAccount(ctx, stack)
+	chbookaddr := common.HexToAddress(ctx.GlobalString(ChequebookAddrFlag.Name))
+	bzzconfig, err := bzzapi.NewConfig(bzzDir(ctx, stack.InstanceDir()), chbookaddr, signer, ctx.GlobalUint64(LivepeerNetworkIdFlag.Name), ctx.GlobalString(RTMPFlag.Name))
 	if err != nil {
 		utils.Fatalf("unable to configure swarm: %v", err)
 	}
+	applyBzzFlags(ctx, bzzconfig)
+	return bzzconfig
+}
+
+// applyBzzFlags applies the configuration file and explicitly set flags to the
+// swarm configuration, flags take precedence over the file.
+func applyBzzFlags(ctx *cli.Context, bzzconfig *bzzapi.Config) {
+	utils.LoadConfigSection(ctx, "Swarm", bzzconfig)
+	if ctx.GlobalIsSet(LivepeerNetworkIdFlag.Name) {
+		bzzconfig.NetworkId = ctx.GlobalUint64(LivepeerNetworkIdFlag.Name)
+	}
+	if ctx.GlobalIsSet(RTMPFlag.Name) {
+		bzzconfig.RTMPPort = ctx.GlobalString(RTMPFlag.Name)
+	}
 	bzzport := ctx.GlobalString(SwarmPortFlag.Name)
 	if len(bzzport) > 0 {
 		bzzconfig.Port = bzzport
 	}
+	if ctx.GlobalIsSet(SwarmBandwidthFlag.Name) {
+		bzzconfig.MaxBandwidth = ctx.GlobalUint64(SwarmBandwidthFlag.Name)
+	}
+	if ctx.GlobalIsSet(SwarmPeerBandwidthFlag.Name) {
+		bzzconfig.MaxPeerBandwidth = ctx.GlobalUint64(SwarmPeerBandwidthFlag.Name)
+	}
+	if ctx.GlobalIsSet(TranscoderFlag.Name) {
+		bzzconfig.Transcoder = ctx.GlobalBool(TranscoderFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.DBBackendFlag.Name) {
+		bzzconfig.SetDbBackend(ctx.GlobalString(utils.DBBackendFlag.Name))
+	}
+}
+
+func convertDB(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	dirs, err := bzzapi.DatabasePaths(bzzDir(ctx, stack.InstanceDir()))
+	if err != nil {
+		utils.Fatalf("Can't find swarm databases: %v", err)
+	}
+	utils.ConvertDatabases(dirs, ctx.GlobalString(utils.DBBackendFlag.Name), 0)
+	return nil
+}
+
+func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizClient.Client) {
+	bzzconfig := makeBzzConfig(ctx, stack)
 	swapEnabled := ctx.GlobalBool(SwarmSwapEnabledFlag.Name)
 	syncEnabled := ctx.GlobalBoolT(SwarmSyncEnabledFlag.Name)
 
@@ -327,22 +441,24 @@ func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizCli
 	cors := ctx.GlobalString(CorsStringFlag.Name)
 
 	boot := func(ctx *node.ServiceContext) (node.Service, error) {
-		var client *ethclient.Client
+		// without an ethereum API, names are resolved by the local resolver only
+		var backend chequebook.Backend
 		if len(ethapi) > 0 {
-			client, err = ethclient.Dial(ethapi)
+			client, err := ethclient.Dial(ethapi)
 			if err != nil {
 				utils.Fatalf("Can't connect: %v", err)
 			}
+			backend = client
 		}
 
-		return swarm.NewSwarm(ctx, client, bzzconfig, swapEnabled, syncEnabled, cors, viz)
+		return swarm.NewSwarm(ctx, backend, bzzconfig, swapEnabled, syncEnabled, cors, viz)
 	}
 	if err := stack.Register(boot); err != nil {
 		utils.Fatalf("Failed to register the Swarm service: %v", err)
 	}
 }
 
-func getAccount(ctx *cli.Context, stack *node.Node) *ecdsa.PrivateKey {
+func getAccount(ctx *cli.Context, stack *node.Node) accounts.Signer {
 	keyid := ctx.GlobalString(SwarmAccountFlag.Name)
 	if keyid == "" {
 		//utils.Fatalf("Option %q is required", SwarmAccountFlag.Name)
@@ -353,10 +469,10 @@ func getAccount(ctx *cli.Context, stack *node.Node) *ecdsa.PrivateKey {
 	// Try to load the arg as a hex key file.
 	if key, err := crypto.LoadECDSA(keyid); err == nil {
 		glog.V(logger.Info).Infof("swarm account key loaded: %#x", crypto.PubkeyToAddress(key.PublicKey))
-		return key
+		return accounts.NewKeySigner(key)
 	}
-	// Otherwise try getting it from the keystore.
-	return decryptStoreAccount(stack.AccountManager(), keyid)
+	// Otherwise try getting it from the keystore, an HD wallet or an external signer.
+	return utils.AccountSigner(stack.AccountManager(), keyid, promptPassphrase)
 }
 
 func findOrGenerateFirstAccount(accman *accounts.Manager, ctx *cli.Context) (keyid string) {
@@ -374,34 +490,6 @@ func findOrGenerateFirstAccount(accman *accounts.Manager, ctx *cli.Context) (key
 	return acc.Address.Hex()
 }
 
-func decryptStoreAccount(accman *accounts.Manager, account string) *ecdsa.PrivateKey {
-	var a accounts.Account
-	var err error
-	if common.IsHexAddress(account) {
-		a, err = accman.Find(accounts.Account{Address: common.HexToAddress(account)})
-	} else if ix, ixerr := strconv.Atoi(account); ixerr == nil {
-		a, err = accman.AccountByIndex(ix)
-	} else {
-		utils.Fatalf("Can't find swarm account key %s", account)
-	}
-	if err != nil {
-		utils.Fatalf("Can't find swarm account key: %v", err)
-	}
-	keyjson, err := ioutil.ReadFile(a.File)
-	if err != nil {
-		utils.Fatalf("Can't load swarm account key: %v", err)
-	}
-	for i := 1; i <= 3; i++ {
-		passphrase := promptPassphrase(fmt.Sprintf("Unlocking swarm account %s [%d/3]", a.Address.Hex(), i))
-		key, err := accounts.DecryptKey(keyjson, passphrase)
-		if err == nil {
-			return key.PrivateKey
-		}
-	}
-	utils.Fatalf("Can't decrypt swarm account key")
-	return nil
-}
-
 func promptPassphrase(prompt string) string {
 	if prompt != "" {
 		fmt.Println(prompt)
@@ -0,0 +1,259 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/swarm/storage"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	manifestType         = "application/bzz-manifest+json"
+	maxParallelDownloads = 5
+)
+
+var bzzPrefix = regexp.MustCompile("^bzz[ir]?:/+")
+
+func download(ctx *cli.Context) {
+	args := ctx.Args()
+	var (
+		bzzapi = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	)
+	if len(args) != 2 {
+		log.Fatal("need bzz uri and target directory as arguments")
+	}
+
+	var (
+		uri    = strings.Trim(bzzPrefix.ReplaceAllString(args[0], ""), "/")
+		dir    = expandPath(args[1])
+		client = &client{api: bzzapi}
+	)
+	parts := strings.SplitN(uri, "/", 2)
+	host, prefix := parts[0], ""
+	if len(parts) == 2 && parts[1] != "" {
+		prefix = parts[1] + "/"
+	}
+	if host == "" {
+		log.Fatal("need a content hash or name in the bzz uri")
+	}
+
+	var entries []manifestEntry
+	err := client.walkManifest(host, "", prefix, func(entry manifestEntry) {
+		entries = append(entries, entry)
+	})
+	if err != nil {
+		log.Fatalln("manifest retrieval failed:", err)
+	}
+	if err := client.downloadEntries(entries, dir); err != nil {
+		log.Fatalln("download failed:", err)
+	}
+}
+
+// walkManifest retrieves the manifest at hash and calls cb for every file
+// entry whose path starts with prefix; the entry path is made relative to it.
+// Nested manifests are retrieved as needed.
+func (c *client) walkManifest(hash, base, prefix string, cb func(manifestEntry)) error {
+	m, err := c.downloadManifest(hash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range m.Entries {
+		path := base + entry.Path
+		if entry.ContentType == manifestType {
+			if strings.HasPrefix(path, prefix) || strings.HasPrefix(prefix, path) {
+				if err := c.walkManifest(entry.Hash, path, prefix, cb); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		entry.Path = path[len(prefix):]
+		// the default entry and directory-like paths have no file to write to
+		if entry.Path == "" || strings.HasSuffix(entry.Path, "/") {
+			continue
+		}
+		cb(entry)
+	}
+	return nil
+}
+
+func (c *client) downloadManifest(hash string) (manifest, error) {
+	var m manifest
+	resp, err := http.Get(c.api + "/bzzr:/" + hash)
+	if err != nil {
+		return m, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return m, fmt.Errorf("bad status retrieving manifest %s: %s", hash, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&m)
+	return m, err
+}
+
+// downloadEntries writes the content of entries below dir, using up to
+// maxParallelDownloads concurrent requests
+func (c *client) downloadEntries(entries []manifestEntry, dir string) error {
+	var (
+		wg    sync.WaitGroup
+		errC  = make(chan error, len(entries))
+		slots = make(chan struct{}, maxParallelDownloads)
+	)
+	// check all paths before writing anything
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		path := filepath.Join(dir, filepath.FromSlash(entry.Path))
+		if rel, err := relativePath(dir, path); err != nil {
+			return err
+		} else if rel == "." {
+			return fmt.Errorf("path %s is the directory %s", entry.Path, dir)
+		}
+		paths[i] = path
+	}
+	for i, entry := range entries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(hash, path string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := c.downloadFile(hash, path); err != nil {
+				errC <- fmt.Errorf("%s: %v", path, err)
+			}
+		}(entry.Hash, paths[i])
+	}
+	wg.Wait()
+	close(errC)
+	return <-errC
+}
+
+// downloadFile retrieves the content with the given hash into path and
+// verifies it. A partially downloaded file at path is resumed with a range
+// request; if the result does not match the hash it is downloaded again.
+func (c *client) downloadFile(hash, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	var offset int64
+	if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+		if ok, err := verifyFile(hash, path); err != nil {
+			return err
+		} else if ok {
+			log.Printf("file %s already downloaded", path)
+			return nil
+		}
+		offset = fi.Size()
+	}
+	if err := c.downloadRange(hash, path, offset); err != nil {
+		return err
+	}
+	ok, err := verifyFile(hash, path)
+	if err != nil || ok {
+		return err
+	}
+	if offset > 0 {
+		log.Printf("resumed file %s is corrupt, downloading again", path)
+		if err := c.downloadRange(hash, path, 0); err != nil {
+			return err
+		}
+		if ok, err = verifyFile(hash, path); err != nil || ok {
+			return err
+		}
+	}
+	return fmt.Errorf("content does not match hash %s", hash)
+}
+
+// downloadRange writes the content with the given hash from offset onwards
+// to path, truncating the file there
+func (c *client) downloadRange(hash, path string, offset int64) error {
+	req, err := http.NewRequest("GET", c.api+"/bzzr:/"+hash, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+		log.Printf("resuming file %s at %d bytes", path, offset)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// local file is at least as long as the content, restart
+		return c.downloadRange(hash, path, 0)
+	default:
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		f.Close()
+		return err
+	}
+	if offset == 0 {
+		log.Printf("downloading file %s", path)
+	}
+	_, err = io.Copy(f, resp.Body)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// verifyFile checks whether the swarm hash of the file at path is hash
+func verifyFile(hash, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	chunker := storage.NewTreeChunker(storage.NewChunkerParams())
+	key, err := chunker.Split(f, stat.Size(), nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(key.String(), hash), nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// testBzz serves content and manifests by hash on the bzzr scheme
+type testBzz struct {
+	content map[string][]byte
+}
+
+func (self *testBzz) putContent(t *testing.T, data []byte) string {
+	chunker := storage.NewTreeChunker(storage.NewChunkerParams())
+	key, err := chunker.Split(bytes.NewReader(data), int64(len(data)), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self.content[key.String()] = data
+	return key.String()
+}
+
+func (self *testBzz) putManifest(t *testing.T, entries ...manifestEntry) string {
+	data, err := json.Marshal(manifest{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return self.putContent(t, data)
+}
+
+func (self *testBzz) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, ok := self.content[strings.TrimPrefix(r.URL.Path, "/bzzr:/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func newTestBzz(t *testing.T) (*testBzz, *httptest.Server) {
+	bzz := &testBzz{content: make(map[string][]byte)}
+	return bzz, httptest.NewServer(bzz)
+}
+
+func TestDownloadNestedManifest(t *testing.T) {
+	bzz, srv := newTestBzz(t)
+	defer srv.Close()
+
+	files := map[string]string{
+		"dir/a.txt":     "a",
+		"dir/sub/b.txt": "b",
+		"other.txt":     "other",
+	}
+	hashes := make(map[string]string)
+	for path, data := range files {
+		hashes[path] = bzz.putContent(t, []byte(data))
+	}
+	sub := bzz.putManifest(t,
+		manifestEntry{Hash: hashes["dir/sub/b.txt"], Path: "b.txt"},
+	)
+	nested := bzz.putManifest(t,
+		manifestEntry{Hash: hashes["dir/a.txt"], Path: "a.txt"},
+		manifestEntry{Hash: sub, Path: "sub/", ContentType: manifestType},
+	)
+	root := bzz.putManifest(t,
+		manifestEntry{Hash: nested, Path: "dir/", ContentType: manifestType},
+		manifestEntry{Hash: hashes["other.txt"], Path: "other.txt"},
+		manifestEntry{Hash: hashes["other.txt"]},
+	)
+
+	c := &client{api: srv.URL}
+	var paths []string
+	var entries []manifestEntry
+	err := c.walkManifest(root, "", "dir/", func(entry manifestEntry) {
+		paths = append(paths, entry.Path)
+		entries = append(entries, entry)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	if exp := []string{"a.txt", "sub/b.txt"}; strings.Join(paths, ",") != strings.Join(exp, ",") {
+		t.Fatalf("expected entries %v, got %v", exp, paths)
+	}
+
+	dir, err := ioutil.TempDir("", "swarm-download-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := c.downloadEntries(entries, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := files["dir/"+path]; string(data) != exp {
+			t.Errorf("%s: expected %q, got %q", path, exp, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); !os.IsNotExist(err) {
+		t.Errorf("entry outside the prefix was downloaded")
+	}
+}
+
+func TestDownloadEntriesPaths(t *testing.T) {
+	bzz, srv := newTestBzz(t)
+	defer srv.Close()
+	hash := bzz.putContent(t, []byte("content"))
+	c := &client{api: srv.URL}
+
+	dir, err := ioutil.TempDir("", "swarm-download-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// no download starts if any path is outside the directory
+	entries := []manifestEntry{
+		{Hash: hash, Path: "a.txt"},
+		{Hash: hash, Path: "../escape.txt"},
+	}
+	if err := c.downloadEntries(entries, dir); err == nil {
+		t.Fatal("expected error for path outside the directory")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("expected no files written, got %d", len(files))
+	}
+
+	// a relative target directory is accepted
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err := c.downloadEntries(entries[:1], "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
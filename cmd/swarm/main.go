@@ -17,19 +17,16 @@
 package main
 
 import (
-	"crypto/ecdsa"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"runtime"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/contracts/chequebook"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/internal/debug"
@@ -94,6 +91,14 @@ var (
 		Name:  "sync",
 		Usage: "Swarm Syncing enabled (default true)",
 	}
+	SwarmBandwidthFlag = cli.Uint64Flag{
+		Name:  "bzzbandwidth",
+		Usage: "Swarm outgoing bandwidth limit for all peers in bytes per second (default 0=unlimited)",
+	}
+	SwarmPeerBandwidthFlag = cli.Uint64Flag{
+		Name:  "bzzpeerbandwidth",
+		Usage: "Swarm outgoing bandwidth limit per peer in bytes per second (default 0=unlimited)",
+	}
 	EthAPIFlag = cli.StringFlag{
 		Name:  "ethapi",
 		Usage: "URL of the Ethereum API provider",
@@ -150,6 +155,35 @@ func init() {
 	app.HideVersion = true // we have a command to print the version
 	app.Copyright = "Copyright 2013-2016 The go-ethereum Authors"
 	app.Commands = []cli.Command{
+		{
+			Action:    dumpConfig,
+			Name:      "dumpconfig",
+			Usage:     "Show configuration values",
+			ArgsUsage: " ",
+			Description: `
+Prints the effective node and swarm configuration, assembled from the --config
+file and command line flags, in TOML format.
+`,
+		},
+		{
+			Name:  "db",
+			Usage: "Manage the storage of the swarm databases",
+			Subcommands: []cli.Command{
+				{
+					Action:    convertDB,
+					Name:      "convert",
+					Usage:     "Convert the swarm databases to another backend",
+					ArgsUsage: " ",
+					Description: `
+    swarm --dbbackend boltdb db convert
+
+Copies the chunk, request, reputation and name databases of all swarm accounts
+into databases of the backend given by --dbbackend, replacing the originals.
+The node must not be running during the conversion.
+`,
+				},
+			},
+		},
 		{
 			Action:    version,
 			Name:      "version",
@@ -166,6 +200,17 @@ The output of this command is supposed to be machine-readable.
 			ArgsUsage: " <file>",
 			Description: `
 "upload a file or directory to swarm using the HTTP API and prints the root hash",
+`,
+		},
+		{
+			Action:    download,
+			Name:      "down",
+			Usage:     "download a manifest tree from swarm to a directory using the HTTP API",
+			ArgsUsage: " <bzz-uri> <dir>",
+			Description: `
+Mirrors the files below the given bzz uri onto the local directory, retrieving
+files in parallel and verifying each against its swarm hash. Partially
+downloaded files are resumed.
 `,
 		},
 		{
@@ -180,10 +225,13 @@ Prints the swarm hash of file or directory.
 	}
 
 	app.Flags = []cli.Flag{
+		utils.ConfigFileFlag,
 		utils.IdentityFlag,
 		utils.DataDirFlag,
 		utils.BootnodesFlag,
 		utils.KeyStoreDirFlag,
+		utils.ExternalSignerFlag,
+		utils.SigningPolicyFlag,
 		utils.ListenPortFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -195,17 +243,20 @@ Prints the swarm hash of file or directory.
 		utils.IPCDisabledFlag,
 		utils.IPCApiFlag,
 		utils.IPCPathFlag,
+		utils.DBBackendFlag,
 		// bzzd-specific flags
 		CorsStringFlag,
 		EthAPIFlag,
 		SwarmConfigPathFlag,
 		SwarmSwapEnabledFlag,
 		SwarmSyncEnabledFlag,
+		SwarmBandwidthFlag,
+		SwarmPeerBandwidthFlag,
 		SwarmPortFlag,
 		SwarmAccountFlag,
 		SwarmNetworkIdFlag,
 		ChequebookAddrFlag,
-		// upload flags
+		// upload and download flags
 		SwarmApiFlag,
 		SwarmRecursiveUploadFlag,
 		SwarmWantManifestFlag,
@@ -266,7 +317,7 @@ func bzzd(ctx *cli.Context) error {
 		}
 	}
 
-	// Start consuming visualization events and reporting your peers every 20 seconds.
+	// Start consuming visualization events and reporting your peers when they change.
 	// See comments below near the consumeVizEvents() method
 	vizClient.NodeID = fmt.Sprintf("%s", stack.Server().Self().ID)
 
@@ -285,12 +336,19 @@ func bzzd(ctx *cli.Context) error {
 	return nil
 }
 
-// Call peer reporting event at some fixed interval like 20 seconds for the visualization server
+// Report the peers to the visualization server whenever a peer is added or dropped
 func startPeerReporting(node *node.Node, doneChan chan bool, vizClient *streamingVizClient.Client) {
-	tickChan := time.NewTicker(20 * time.Second).C
+	sub := node.Server().SubscribeEvents()
+	defer sub.Unsubscribe()
 	for {
 		select {
-		case <-tickChan:
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			if t := ev.Data.(p2p.PeerEvent).Type; t != p2p.PeerEventTypeAdd && t != p2p.PeerEventTypeDrop {
+				continue
+			}
 			peers := node.Server().PeersInfo()
 			peerIDs := make([]string, 0, len(peers))
 			for _, p := range peers {
@@ -303,23 +361,83 @@ func startPeerReporting(node *node.Node, doneChan chan bool, vizClient *streamin
 	}
 }
 
-func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizClient.Client) {
-	prvkey := getAccount(ctx, stack)
-
+// dumpConfig prints the configuration without unlocking or creating the swarm
+// account, the swarm node's config.json is only read if the account is given
+// as an address.
+func dumpConfig(ctx *cli.Context) error {
+	nodeConf := utils.MakeNodeConfig(ctx, clientIdentifier, gitCommit)
+	var address common.Address
+	if keyid := ctx.GlobalString(SwarmAccountFlag.Name); common.IsHexAddress(keyid) {
+		address = common.HexToAddress(keyid)
+	}
 	chbookaddr := common.HexToAddress(ctx.GlobalString(ChequebookAddrFlag.Name))
-	bzzdir := ctx.GlobalString(SwarmConfigPathFlag.Name)
-	if bzzdir == "" {
-		bzzdir = stack.InstanceDir()
+	bzzconfig, err := bzzapi.ReadConfig(bzzDir(ctx, nodeConf.InstanceDir()), address, chbookaddr, ctx.GlobalUint64(SwarmNetworkIdFlag.Name), ctx.String("rtmp"))
+	if err != nil {
+		utils.Fatalf("unable to configure swarm: %v", err)
+	}
+	applyBzzFlags(ctx, bzzconfig)
+	return utils.DumpConfig(os.Stdout, map[string]interface{}{"Node": nodeConf, "Swarm": bzzconfig})
+}
+
+// bzzDir returns the directory of the swarm configuration, by default the
+// node's instance directory.
+func bzzDir(ctx *cli.Context, instanceDir string) string {
+	if bzzdir := ctx.GlobalString(SwarmConfigPathFlag.Name); bzzdir != "" {
+		return bzzdir
 	}
+	return instanceDir
+}
 
-	bzzconfig, err := bzzapi.NewConfig(bzzdir, chbookaddr, prvkey, ctx.GlobalUint64(SwarmNetworkIdFlag.Name), ctx.String("rtmp"))
+// makeBzzConfig loads the swarm configuration of the account selected by the
+// command line flags, applying the configuration file and flags on top of it.
+func makeBzzConfig(ctx *cli.Context, stack *node.Node) *bzzapi.Config {
+	signer := getAccount(ctx, stack)
+	chbookaddr := common.HexToAddress(ctx.GlobalString(ChequebookAddrFlag.Name))
+	bzzconfig, err := bzzapi.NewConfig(bzzDir(ctx, stack.InstanceDir()), chbookaddr, signer, ctx.GlobalUint64(SwarmNetworkIdFlag.Name), ctx.String("rtmp"))
 	if err != nil {
 		utils.Fatalf("unable to configure swarm: %v", err)
 	}
+	applyBzzFlags(ctx, bzzconfig)
+	return bzzconfig
+}
+
+// applyBzzFlags applies the configuration file and explicitly set flags to the
+// swarm configuration, flags take precedence over the file.
+func applyBzzFlags(ctx *cli.Context, bzzconfig *bzzapi.Config) {
+	utils.LoadConfigSection(ctx, "Swarm", bzzconfig)
+	if ctx.GlobalIsSet(SwarmNetworkIdFlag.Name) {
+		bzzconfig.NetworkId = ctx.GlobalUint64(SwarmNetworkIdFlag.Name)
+	}
+	if ctx.GlobalIsSet(RTMPFlag.Name) {
+		bzzconfig.RTMPPort = ctx.GlobalString(RTMPFlag.Name)
+	}
 	bzzport := ctx.GlobalString(SwarmPortFlag.Name)
 	if len(bzzport) > 0 {
 		bzzconfig.Port = bzzport
 	}
+	if ctx.GlobalIsSet(SwarmBandwidthFlag.Name) {
+		bzzconfig.MaxBandwidth = ctx.GlobalUint64(SwarmBandwidthFlag.Name)
+	}
+	if ctx.GlobalIsSet(SwarmPeerBandwidthFlag.Name) {
+		bzzconfig.MaxPeerBandwidth = ctx.GlobalUint64(SwarmPeerBandwidthFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.DBBackendFlag.Name) {
+		bzzconfig.SetDbBackend(ctx.GlobalString(utils.DBBackendFlag.Name))
+	}
+}
+
+func convertDB(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	dirs, err := bzzapi.DatabasePaths(bzzDir(ctx, stack.InstanceDir()))
+	if err != nil {
+		utils.Fatalf("Can't find swarm databases: %v", err)
+	}
+	utils.ConvertDatabases(dirs, ctx.GlobalString(utils.DBBackendFlag.Name), 0)
+	return nil
+}
+
+func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizClient.Client) {
+	bzzconfig := makeBzzConfig(ctx, stack)
 	swapEnabled := ctx.GlobalBool(SwarmSwapEnabledFlag.Name)
 	syncEnabled := ctx.GlobalBoolT(SwarmSyncEnabledFlag.Name)
 
@@ -327,22 +445,24 @@ func registerBzzService(ctx *cli.Context, stack *node.Node, viz *streamingVizCli
 	cors := ctx.GlobalString(CorsStringFlag.Name)
 
 	boot := func(ctx *node.ServiceContext) (node.Service, error) {
-		var client *ethclient.Client
+		// without an ethereum API, names are resolved by the local resolver only
+		var backend chequebook.Backend
 		if len(ethapi) > 0 {
-			client, err = ethclient.Dial(ethapi)
+			client, err := ethclient.Dial(ethapi)
 			if err != nil {
 				utils.Fatalf("Can't connect: %v", err)
 			}
+			backend = client
 		}
 
-		return swarm.NewSwarm(ctx, client, bzzconfig, swapEnabled, syncEnabled, cors, viz)
+		return swarm.NewSwarm(ctx, backend, bzzconfig, swapEnabled, syncEnabled, cors, viz)
 	}
 	if err := stack.Register(boot); err != nil {
 		utils.Fatalf("Failed to register the Swarm service: %v", err)
 	}
 }
 
-func getAccount(ctx *cli.Context, stack *node.Node) *ecdsa.PrivateKey {
+func getAccount(ctx *cli.Context, stack *node.Node) accounts.Signer {
 	keyid := ctx.GlobalString(SwarmAccountFlag.Name)
 	if keyid == "" {
 		utils.Fatalf("Option %q is required", SwarmAccountFlag.Name)
@@ -350,38 +470,10 @@ func getAccount(ctx *cli.Context, stack *node.Node) *ecdsa.PrivateKey {
 	// Try to load the arg as a hex key file.
 	if key, err := crypto.LoadECDSA(keyid); err == nil {
 		glog.V(logger.Info).Infof("swarm account key loaded: %#x", crypto.PubkeyToAddress(key.PublicKey))
-		return key
+		return accounts.NewKeySigner(key)
 	}
-	// Otherwise try getting it from the keystore.
-	return decryptStoreAccount(stack.AccountManager(), keyid)
-}
-
-func decryptStoreAccount(accman *accounts.Manager, account string) *ecdsa.PrivateKey {
-	var a accounts.Account
-	var err error
-	if common.IsHexAddress(account) {
-		a, err = accman.Find(accounts.Account{Address: common.HexToAddress(account)})
-	} else if ix, ixerr := strconv.Atoi(account); ixerr == nil {
-		a, err = accman.AccountByIndex(ix)
-	} else {
-		utils.Fatalf("Can't find swarm account key %s", account)
-	}
-	if err != nil {
-		utils.Fatalf("Can't find swarm account key: %v", err)
-	}
-	keyjson, err := ioutil.ReadFile(a.File)
-	if err != nil {
-		utils.Fatalf("Can't load swarm account key: %v", err)
-	}
-	for i := 1; i <= 3; i++ {
-		passphrase := promptPassphrase(fmt.Sprintf("Unlocking swarm account %s [%d/3]", a.Address.Hex(), i))
-		key, err := accounts.DecryptKey(keyjson, passphrase)
-		if err == nil {
-			return key.PrivateKey
-		}
-	}
-	utils.Fatalf("Can't decrypt swarm account key")
-	return nil
+	// Otherwise try getting it from the keystore, an HD wallet or an external signer.
+	return utils.AccountSigner(stack.AccountManager(), keyid, promptPassphrase)
 }
 
 func promptPassphrase(prompt string) string {
@@ -18,6 +18,7 @@
 package main
 
 import (
+	"archive/tar"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"log"
 	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"os/user"
 	"path"
@@ -61,6 +63,15 @@ func upload(ctx *cli.Context) {
 		if !recursive {
 			log.Fatal("argument is a directory and recursive upload is disabled")
 		}
+		if wantManifest {
+			// upload the directory as a tar stream, the server builds the manifest
+			hash, err := client.uploadDirectoryTar(file, defaultPath)
+			if err != nil {
+				log.Fatalln("upload failed:", err)
+			}
+			fmt.Println(hash)
+			return
+		}
 		mroot, err = client.uploadDirectory(file, defaultPath)
 	} else {
 		entry, err = client.uploadFile(file, fi)
@@ -162,6 +173,70 @@ func (c *client) uploadDirectory(dir string, defaultPath string) (manifest, erro
 	return dirm, err
 }
 
+// uploadDirectoryTar sends all files below dir in a single tar archive and
+// returns the hash of the manifest built by the server
+func (c *client) uploadDirectoryTar(dir string, defaultPath string) (string, error) {
+	var defaultEntry string
+	if len(defaultPath) > 0 {
+		var err error
+		if defaultEntry, err = relativePath(dir, defaultPath); err != nil {
+			return "", err
+		}
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			name, err := relativePath(dir, path)
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(fi, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = name
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			fd, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer fd.Close()
+			log.Printf("uploading file %s (%d bytes)", path, fi.Size())
+			_, err = io.Copy(tw, fd)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	req, err := http.NewRequest("POST", c.api+"/bzz:/?defaultpath="+url.QueryEscape(defaultEntry), pr)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("content-type", "application/x-tar")
+	return c.do(req)
+}
+
+// relativePath returns the slash separated path of file relative to dir,
+// it fails if file is not below dir
+func relativePath(dir, file string) (string, error) {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s outside directory %s", file, dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
 func (c *client) uploadFileContent(file string, fi os.FileInfo) (string, error) {
 	fd, err := os.Open(file)
 	if err != nil {
@@ -189,6 +264,10 @@ func (c *client) postRaw(mimetype string, size int64, body io.ReadCloser) (strin
 	}
 	req.Header.Set("content-type", mimetype)
 	req.ContentLength = size
+	return c.do(req)
+}
+
+func (c *client) do(req *http.Request) (string, error) {
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
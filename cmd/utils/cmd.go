@@ -21,15 +21,20 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -240,3 +245,110 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	glog.Infoln("Exported blockchain to ", fn)
 	return nil
 }
+
+// ConvertDatabases converts the databases in the given directories to backend,
+// skipping directories without a database. The databases must not be in use.
+func ConvertDatabases(dirs []string, backend string, cache int) {
+	converted := 0
+	for _, dir := range dirs {
+		from := ethdb.DetectBackend(dir)
+		switch {
+		case from == "":
+			continue
+		case from == backend:
+			fmt.Printf("%s already uses backend %s\n", dir, backend)
+			continue
+		}
+		fmt.Printf("Converting %s from %s to %s...\n", dir, from, backend)
+		start := time.Now()
+		if err := ConvertDatabase(dir, from, backend, cache); err != nil {
+			Fatalf("Conversion of %s failed: %v", dir, err)
+		}
+		fmt.Printf("Converted %s in %v\n", dir, time.Since(start))
+		converted++
+	}
+	if converted == 0 {
+		fmt.Println("No databases to convert")
+	}
+}
+
+// ConvertDatabase copies the database in dir to a database of the target backend,
+// which replaces the original once the copy is complete.
+func ConvertDatabase(dir, from, to string, cache int) error {
+	tmpdir := dir + ".convert"
+	if err := os.RemoveAll(tmpdir); err != nil {
+		return err
+	}
+	src, err := ethdb.Open(from, dir, cache, MakeDatabaseHandles())
+	if err != nil {
+		return err
+	}
+	dst, err := ethdb.Open(to, tmpdir, cache, MakeDatabaseHandles())
+	if err != nil {
+		src.Close()
+		return err
+	}
+	err = ethdb.Copy(dst, src)
+	src.Close()
+	dst.Close()
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return err
+	}
+	// Swap in the converted database, keeping the original until it's in place.
+	olddir := dir + ".old"
+	if err := os.Rename(dir, olddir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpdir, dir); err != nil {
+		os.Rename(olddir, dir)
+		return err
+	}
+	return os.RemoveAll(olddir)
+}
+
+// AccountSigner returns a signer for the swarm account given by address or
+// index. Keystore accounts are decrypted with a passphrase read by prompt,
+// other accounts are signed for by the account manager.
+func AccountSigner(accman *accounts.Manager, account string, prompt func(string) string) accounts.Signer {
+	var a accounts.Account
+	var err error
+	if common.IsHexAddress(account) {
+		addr := common.HexToAddress(account)
+		if a, err = accman.Find(accounts.Account{Address: addr}); err == accounts.ErrNoMatch && accman.HasAddress(addr) {
+			a, err = accounts.Account{Address: addr}, nil
+		}
+	} else if ix, ixerr := strconv.Atoi(account); ixerr == nil {
+		a, err = accman.AccountByIndex(ix)
+	} else {
+		Fatalf("Can't find swarm account key %s", account)
+	}
+	if err != nil {
+		Fatalf("Can't find swarm account key: %v", err)
+	}
+	if a.File == "" {
+		backend, err := accman.Backend(a.Address)
+		switch {
+		case err != nil:
+			Fatalf("Can't reach the signer of swarm account %s: %v", a.Address.Hex(), err)
+		case backend != nil:
+			glog.V(logger.Info).Infof("swarm account %s held by %v", a.Address.Hex(), backend)
+		default:
+			glog.V(logger.Info).Infof("swarm account %s derived from an HD wallet", a.Address.Hex())
+		}
+		return accounts.NewAccountSigner(accman, a.Address)
+	}
+	keyjson, err := ioutil.ReadFile(a.File)
+	if err != nil {
+		Fatalf("Can't load swarm account key: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		passphrase := prompt(fmt.Sprintf("Unlocking swarm account %s [%d/3]", a.Address.Hex(), i))
+		key, err := accounts.DecryptKey(keyjson, passphrase)
+		if err == nil {
+			return accounts.WithPolicy(accounts.NewKeySigner(key.PrivateKey), accman)
+		}
+	}
+	Fatalf("Can't decrypt swarm account key")
+	return nil
+}
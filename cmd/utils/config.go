@@ -0,0 +1,229 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// ConfigFileFlag points to a configuration file. Values in the file take
+// precedence over flag defaults, explicitly set flags take precedence over the file.
+var ConfigFileFlag = cli.StringFlag{
+	Name:  "config",
+	Usage: "TOML or JSON configuration file",
+}
+
+// configSections lists the top-level tables of a configuration file in the order
+// they are dumped.
+var configSections = []string{"Node", "Eth", "Swarm"}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// LoadConfigSection decodes the given section of the --config file into cfg,
+// returning whether the section was present. Fields whose flags were explicitly
+// set on the command line should be reapplied afterwards with OverrideFlags.
+func LoadConfigSection(ctx *cli.Context, section string, cfg interface{}) bool {
+	path := ctx.GlobalString(ConfigFileFlag.Name)
+	if path == "" {
+		return false
+	}
+	sections, err := readConfigFile(path)
+	if err != nil {
+		Fatalf("Failed to read config file: %v", err)
+	}
+	ok, err := loadConfigSection(sections, section, cfg)
+	if err != nil {
+		Fatalf("%s: %v", path, err)
+	}
+	return ok
+}
+
+// readConfigFile reads a configuration file, splitting it into its sections.
+// Files ending in .json are parsed as JSON, everything else as TOML.
+func readConfigFile(path string) (map[string]json.RawMessage, error) {
+	doc, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".json") {
+		if doc, err = tomlToJSON(doc); err != nil {
+			return nil, err
+		}
+	}
+	return parseConfigSections(doc)
+}
+
+func parseConfigSections(doc []byte) (map[string]json.RawMessage, error) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &sections); err != nil {
+		return nil, err
+	}
+	for name := range sections {
+		known := false
+		for _, s := range configSections {
+			known = known || s == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown section %q, want one of %v", name, configSections)
+		}
+	}
+	return sections, nil
+}
+
+func loadConfigSection(sections map[string]json.RawMessage, section string, cfg interface{}) (bool, error) {
+	data, ok := sections[section]
+	if !ok {
+		return false, nil
+	}
+	if err := checkFields(data, reflect.TypeOf(cfg), section); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return false, fmt.Errorf("section %s: %v", section, err)
+	}
+	return true, nil
+}
+
+// checkFields reports keys of a JSON object which do not correspond to any field
+// of typ, so typos in configuration files don't go unnoticed.
+func checkFields(data json.RawMessage, typ reflect.Type, path string) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return nil
+	}
+	// custom JSON encodings are only checked if they keep the exported fields,
+	// like node.Config does
+	if reflect.PtrTo(typ).Implements(jsonUnmarshalerType) && !hasExportedFields(typ) {
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	fields := make(map[string]reflect.Type)
+	collectFields(typ, fields)
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ftype, ok := fields[strings.ToLower(key)]
+		if !ok {
+			return fmt.Errorf("%s: unknown field %q", path, key)
+		}
+		if err := checkFields(obj[key], ftype, path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasExportedFields(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFields gathers the JSON names (lower cased) of all fields of typ,
+// including those promoted from embedded structs.
+func collectFields(typ reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, fields)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+}
+
+// OverrideFlags copies the fields listed in flagFields from flagCfg into cfg if
+// any of the flags they are derived from was explicitly set. Both arguments must
+// be pointers to the same struct type.
+func OverrideFlags(ctx *cli.Context, cfg, flagCfg interface{}, flagFields map[string][]string) {
+	dst, src := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(flagCfg).Elem()
+	for field, names := range flagFields {
+		for _, name := range names {
+			if ctx.GlobalIsSet(name) {
+				dst.FieldByName(field).Set(src.FieldByName(field))
+				break
+			}
+		}
+	}
+}
+
+// DumpConfig writes the given configuration sections to w in TOML format.
+func DumpConfig(w io.Writer, sections map[string]interface{}) error {
+	var buf bytes.Buffer
+	for _, name := range configSections {
+		cfg, ok := sections[name]
+		if !ok {
+			continue
+		}
+		enc, err := json.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("section %s: %v", name, err)
+		}
+		doc, err := jsonToTOML(name, enc)
+		if err != nil {
+			return err
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.Write(doc)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
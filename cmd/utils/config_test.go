@@ -0,0 +1,134 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+)
+
+func TestTOMLToJSON(t *testing.T) {
+	tests := []struct {
+		toml, json string
+	}{
+		{"", `{}`},
+		{"a = 1\nb = -2.5e3", `{"a":1,"b":-2500}`},
+		{"s = \"x\\ty\\u00e9\"\nl = 'C:\\dir'", `{"l":"C:\\dir","s":"x\tyé"}`},
+		{"a = [1, 2,\n  3,] # comment\nb = true", `{"a":[1,2,3],"b":true}`},
+		{"[Node]\nMaxPeers = 25\n[Node.Sub]\nX = \"y\"", `{"Node":{"MaxPeers":25,"Sub":{"X":"y"}}}`},
+		{"a = { c = 1, d = [] }", `{"a":{"c":1,"d":[]}}`},
+		{"[[t]]\nx = 1\n[[t]]\nx = 2", `{"t":[{"x":1},{"x":2}]}`},
+		{"\"quoted key\" = 1", `{"quoted key":1}`},
+	}
+	for i, test := range tests {
+		out, err := tomlToJSON([]byte(test.toml))
+		if err != nil {
+			t.Errorf("test %d: error: %v", i, err)
+			continue
+		}
+		if string(out) != test.json {
+			t.Errorf("test %d: output mismatch\ngot  %s\nwant %s", i, out, test.json)
+		}
+	}
+}
+
+func TestTOMLToJSONErrors(t *testing.T) {
+	tests := []string{
+		"a = ",
+		"a = 1\na = 2",
+		"[a]\n[a]",
+		"a = \"unterminated",
+		"a = [1, 2",
+		"a = 1 b = 2",
+		"[a\nb = 1",
+		"a = nope",
+	}
+	for _, doc := range tests {
+		if out, err := tomlToJSON([]byte(doc)); err == nil {
+			t.Errorf("%q: expected error, got %s", doc, out)
+		}
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	restrict, _ := netutil.ParseNetlist("10.0.0.0/8,192.168.0.0/16")
+	want := &node.Config{
+		DataDir:        "/tmp/data dir",
+		MaxPeers:       12,
+		NoDiscovery:    true,
+		HTTPModules:    []string{"eth", "admin"},
+		NetRestrict:    restrict,
+		NAT:            nat.ExtIP(net.ParseIP("77.12.33.4")),
+		BootstrapNodes: []*discover.Node{discover.MustParseNode("enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303")},
+	}
+	var buf bytes.Buffer
+	if err := DumpConfig(&buf, map[string]interface{}{"Node": want}); err != nil {
+		t.Fatalf("dump error: %v", err)
+	}
+	doc, err := tomlToJSON(buf.Bytes())
+	if err != nil {
+		t.Fatalf("can't parse dumped config: %v\n%s", err, buf.Bytes())
+	}
+	sections, err := parseConfigSections(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(node.Config)
+	if ok, err := loadConfigSection(sections, "Node", got); !ok || err != nil {
+		t.Fatalf("load error: %v (found %t)", err, ok)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("config mismatch\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestConfigUnknownFields(t *testing.T) {
+	type inner struct{ A int }
+	type config struct {
+		*inner
+		Renamed int `json:"other"`
+		Ignored int `json:"-"`
+		Nested  *inner
+		List    *netutil.Netlist
+	}
+	tests := []struct {
+		doc, err string
+	}{
+		{`{"a": 1, "OTHER": 2, "nested": {"A": 3}, "list": ["10.0.0.0/8"]}`, ""},
+		{`{"Renamed": 1}`, `Test: unknown field "Renamed"`},
+		{`{"Ignored": 1}`, `Test: unknown field "Ignored"`},
+		{`{"Nested": {"B": 1}}`, `Test.Nested: unknown field "B"`},
+	}
+	for _, test := range tests {
+		cfg := &config{inner: new(inner)}
+		_, err := loadConfigSection(map[string]json.RawMessage{"Test": json.RawMessage(test.doc)}, "Test", cfg)
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: got error %v, want %q", test.doc, err, test.err)
+		}
+	}
+	if _, err := parseConfigSections([]byte(`{"Nodes": {}}`)); err == nil {
+		t.Error("expected error for unknown section")
+	}
+}
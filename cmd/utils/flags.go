@@ -176,6 +176,11 @@ var (
 		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
 		Value: 128,
 	}
+	DBBackendFlag = cli.StringFlag{
+		Name:  "dbbackend",
+		Usage: "Database storage engine (" + strings.Join(ethdb.Backends, ", ") + ")",
+		Value: ethdb.DefaultBackend,
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
@@ -225,6 +230,16 @@ var (
 		Usage: "Password file to use for non-inteactive password input",
 		Value: "",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer endpoint (IPC path or URL) holding additional accounts",
+		Value: "",
+	}
+	SigningPolicyFlag = cli.StringFlag{
+		Name:  "signpolicy",
+		Usage: "Signing policy file restricting the transactions, messages and cheques accounts sign",
+		Value: "",
+	}
 
 	VMForceJitFlag = cli.BoolFlag{
 		Name:  "forcejit",
@@ -657,8 +672,50 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
-// MakeNode configures a node with no services from command line flags.
+// nodeConfigFlags maps node.Config fields to the flags they are derived from.
+var nodeConfigFlags = map[string][]string{
+	"DataDir":           {DataDirFlag.Name, TestNetFlag.Name, LPNetFlag.Name, DevModeFlag.Name},
+	"DBBackend":         {DBBackendFlag.Name},
+	"KeyStoreDir":       {KeyStoreDirFlag.Name},
+	"UseLightweightKDF": {LightKDFFlag.Name},
+	"ExternalSigner":    {ExternalSignerFlag.Name},
+	"SigningPolicy":     {SigningPolicyFlag.Name},
+	"UserIdent":         {IdentityFlag.Name},
+	"NoDiscovery":       {NoDiscoverFlag.Name, LightModeFlag.Name},
+	"DiscoveryV5":       {DiscoveryV5Flag.Name, LightModeFlag.Name, LightServFlag.Name},
+	"DiscoveryV5Addr":   {ListenPortFlag.Name},
+	"BootstrapNodes":    {BootnodesFlag.Name, TestNetFlag.Name},
+	"BootstrapNodesV5":  {BootnodesFlag.Name},
+	"ListenAddr":        {ListenPortFlag.Name, DevModeFlag.Name},
+	"NAT":               {NATFlag.Name},
+	"MaxPeers":          {MaxPeersFlag.Name, DevModeFlag.Name},
+	"MaxPendingPeers":   {MaxPendingPeersFlag.Name},
+	"IPCPath":           {IPCPathFlag.Name, IPCDisabledFlag.Name},
+	"HTTPHost":          {RPCEnabledFlag.Name, RPCListenAddrFlag.Name},
+	"HTTPPort":          {RPCPortFlag.Name},
+	"HTTPCors":          {RPCCORSDomainFlag.Name},
+	"HTTPModules":       {RPCApiFlag.Name},
+	"WSHost":            {WSEnabledFlag.Name, WSListenAddrFlag.Name},
+	"WSPort":            {WSPortFlag.Name},
+	"WSOrigins":         {WSAllowedOriginsFlag.Name},
+	"WSModules":         {WSApiFlag.Name},
+	"NetRestrict":       {NetrestrictFlag.Name},
+}
+
+// MakeNode configures a node with no services from command line flags and the
+// configuration file.
 func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
+	stack, err := node.New(MakeNodeConfig(ctx, name, gitCommit))
+	if err != nil {
+		Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	return stack
+}
+
+// MakeNodeConfig assembles the node configuration. Flag defaults are overridden
+// by the "Node" section of the configuration file, which in turn is overridden by
+// explicitly set flags.
+func MakeNodeConfig(ctx *cli.Context, name, gitCommit string) *node.Config {
 	vsn := params.Version
 	if gitCommit != "" {
 		vsn += "-" + gitCommit[:8]
@@ -666,8 +723,11 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 
 	config := &node.Config{
 		DataDir:           MakeDataDir(ctx),
+		DBBackend:         ctx.GlobalString(DBBackendFlag.Name),
 		KeyStoreDir:       ctx.GlobalString(KeyStoreDirFlag.Name),
 		UseLightweightKDF: ctx.GlobalBool(LightKDFFlag.Name),
+		ExternalSigner:    ctx.GlobalString(ExternalSignerFlag.Name),
+		SigningPolicy:     ctx.GlobalString(SigningPolicyFlag.Name),
 		PrivateKey:        MakeNodeKey(ctx),
 		Name:              name,
 		Version:           vsn,
@@ -707,16 +767,44 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		config.NetRestrict = list
 	}
 
-	stack, err := node.New(config)
-	if err != nil {
-		Fatalf("Failed to create the protocol stack: %v", err)
+	flagConfig := *config
+	if LoadConfigSection(ctx, "Node", config) {
+		OverrideFlags(ctx, config, &flagConfig, nodeConfigFlags)
 	}
-	return stack
+	return config
 }
 
-// RegisterEthService configures eth.Ethereum from command line flags and adds it to the
-// given node.
-func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
+// ethConfigFlags maps eth.Config fields to the flags they are derived from.
+var ethConfigFlags = map[string][]string{
+	"Etherbase":               {EtherbaseFlag.Name},
+	"FastSync":                {FastSyncFlag.Name},
+	"LightMode":               {LightModeFlag.Name},
+	"LightServ":               {LightServFlag.Name},
+	"LightPeers":              {LightPeersFlag.Name},
+	"MaxPeers":                {MaxPeersFlag.Name},
+	"DatabaseCache":           {CacheFlag.Name},
+	"NetworkId":               {NetworkIdFlag.Name, OlympicFlag.Name, TestNetFlag.Name},
+	"Genesis":                 {OlympicFlag.Name, TestNetFlag.Name, DevModeFlag.Name},
+	"MinerThreads":            {MinerThreadsFlag.Name},
+	"ExtraData":               {ExtraDataFlag.Name},
+	"NatSpec":                 {NatspecEnabledFlag.Name},
+	"DocRoot":                 {DocRootFlag.Name},
+	"GasPrice":                {GasPriceFlag.Name, DevModeFlag.Name},
+	"GpoMinGasPrice":          {GpoMinGasPriceFlag.Name},
+	"GpoMaxGasPrice":          {GpoMaxGasPriceFlag.Name},
+	"GpoFullBlockRatio":       {GpoFullBlockRatioFlag.Name},
+	"GpobaseStepDown":         {GpobaseStepDownFlag.Name},
+	"GpobaseStepUp":           {GpobaseStepUpFlag.Name},
+	"GpobaseCorrectionFactor": {GpobaseCorrectionFactorFlag.Name},
+	"SolcPath":                {SolcPathFlag.Name},
+	"AutoDAG":                 {AutoDAGFlag.Name, MiningEnabledFlag.Name},
+	"PowTest":                 {DevModeFlag.Name},
+}
+
+// MakeEthConfig assembles the Ethereum protocol configuration from command line
+// flags and the "Eth" section of the configuration file. The chain configuration
+// is left unset, it is read from the chain database on registration.
+func MakeEthConfig(ctx *cli.Context, stack *node.Node, extra []byte) *eth.Config {
 	// Avoid conflicting network flags
 	networks, netFlags := 0, []cli.BoolFlag{DevModeFlag, TestNetFlag, OlympicFlag}
 	for _, flag := range netFlags {
@@ -730,7 +818,6 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 
 	ethConf := &eth.Config{
 		Etherbase:               MakeEtherbase(stack.AccountManager(), ctx),
-		ChainConfig:             MakeChainConfig(ctx, stack),
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
 		LightMode:               ctx.GlobalBool(LightModeFlag.Name),
 		LightServ:               ctx.GlobalInt(LightServFlag.Name),
@@ -775,6 +862,19 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		}
 		ethConf.PowTest = true
 	}
+	flagConfig := *ethConf
+	if LoadConfigSection(ctx, "Eth", ethConf) {
+		OverrideFlags(ctx, ethConf, &flagConfig, ethConfigFlags)
+	}
+	return ethConf
+}
+
+// RegisterEthService configures eth.Ethereum from command line flags and the
+// configuration file, and adds it to the given node.
+func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
+	ethConf := MakeEthConfig(ctx, stack, extra)
+	ethConf.ChainConfig = MakeChainConfig(ctx, stack)
+
 	// Override any global options pertaining to the Ethereum protocol
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
 		state.MaxTrieCacheGen = uint16(gen)
@@ -0,0 +1,98 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/naoina/toml"
+)
+
+// This file converts between JSON and TOML. Converting through JSON lets
+// config structs use their JSON encoding in files.
+
+// tomlToJSON converts a TOML document into a JSON object.
+func tomlToJSON(doc []byte) ([]byte, error) {
+	var root map[string]interface{}
+	if err := toml.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+	return json.Marshal(root)
+}
+
+// jsonToTOML converts a JSON object into a TOML table with the given name.
+// Null values are left out, as TOML has no null.
+func jsonToTOML(name string, doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var table map[string]interface{}
+	if err := dec.Decode(&table); err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, fmt.Errorf("TOML table %s must be an object", name)
+	}
+	if err := tomlValues(table); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return toml.Marshal(map[string]interface{}{name: table})
+}
+
+// tomlValues prepares the values of a decoded JSON object for encoding.
+func tomlValues(table map[string]interface{}) error {
+	for key, value := range table {
+		if value == nil {
+			delete(table, key)
+			continue
+		}
+		v, err := tomlValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		table[key] = v
+	}
+	return nil
+}
+
+func tomlValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, tomlValues(v)
+	case []interface{}:
+		for i, elem := range v {
+			if elem == nil {
+				return nil, fmt.Errorf("can't encode null array element in TOML")
+			}
+			var err error
+			if v[i], err = tomlValue(elem); err != nil {
+				return nil, err
+			}
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u, nil
+		}
+		return v.Float64()
+	}
+	return value, nil
+}
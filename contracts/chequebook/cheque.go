@@ -35,6 +35,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/contracts/chequebook/contract"
@@ -89,7 +90,7 @@ var ContractParams = &Params{contract.ChequebookBin, contract.ChequebookABI}
 // It is the outgoing payment handler for peer to peer micropayments.
 type Chequebook struct {
 	path     string                      // path to chequebook file
-	prvKey   *ecdsa.PrivateKey           // private key to sign cheque with
+	signer   accounts.Signer             // signs cheques and transactions
 	lock     sync.Mutex                  //
 	backend  Backend                     // blockchain API
 	quit     chan bool                   // when closed causes autodeposit to stop
@@ -108,11 +109,12 @@ type Chequebook struct {
 }
 
 func (self *Chequebook) String() string {
-	return fmt.Sprintf("contract: %s, owner: %s, balance: %v, signer: %x", self.contractAddr.Hex(), self.owner.Hex(), self.balance, self.prvKey.PublicKey)
+	return fmt.Sprintf("contract: %s, owner: %s, balance: %v, signer: %s", self.contractAddr.Hex(), self.owner.Hex(), self.balance, self.signer.Address().Hex())
 }
 
-// NewChequebook creates a new Chequebook.
-func NewChequebook(path string, contractAddr common.Address, prvKey *ecdsa.PrivateKey, backend Backend) (self *Chequebook, err error) {
+// NewChequebook creates a new Chequebook. Cheques and transactions are signed by
+// signer, which must be the owner of the contract.
+func NewChequebook(path string, contractAddr common.Address, signer accounts.Signer, backend Backend) (self *Chequebook, err error) {
 	balance := new(big.Int)
 	sent := make(map[common.Address]*big.Int)
 
@@ -120,14 +122,14 @@ func NewChequebook(path string, contractAddr common.Address, prvKey *ecdsa.Priva
 	if err != nil {
 		return nil, err
 	}
-	transactOpts := bind.NewKeyedTransactor(prvKey)
+	transactOpts := bind.NewSignerTransactor(signer)
 	session := &contract.ChequebookSession{
 		Contract:     chbook,
 		TransactOpts: *transactOpts,
 	}
 
 	self = &Chequebook{
-		prvKey:       prvKey,
+		signer:       signer,
 		balance:      balance,
 		contractAddr: contractAddr,
 		sent:         sent,
@@ -155,14 +157,14 @@ func (self *Chequebook) setBalanceFromBlockChain() {
 }
 
 // LoadChequebook loads a chequebook from disk (file path).
-func LoadChequebook(path string, prvKey *ecdsa.PrivateKey, backend Backend, checkBalance bool) (self *Chequebook, err error) {
+func LoadChequebook(path string, signer accounts.Signer, backend Backend, checkBalance bool) (self *Chequebook, err error) {
 	var data []byte
 	data, err = ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
 
-	self, _ = NewChequebook(path, common.Address{}, prvKey, backend)
+	self, _ = NewChequebook(path, common.Address{}, signer, backend)
 
 	err = json.Unmarshal(data, self)
 	if err != nil {
@@ -263,7 +265,8 @@ func (self *Chequebook) Issue(beneficiary common.Address, amount *big.Int) (ch *
 		sum := new(big.Int).Set(sent)
 		sum.Add(sum, amount)
 
-		sig, err = crypto.Sign(sigHash(self.contractAddr, beneficiary, sum), self.prvKey)
+		req := &accounts.SignRequest{Kind: accounts.ChequeRequest, From: self.owner, To: &beneficiary, Value: amount}
+		sig, err = accounts.SignRequestHash(self.signer, req, sigHash(self.contractAddr, beneficiary, sum))
 		if err == nil {
 			ch = &Cheque{
 				Contract:    self.contractAddr,
@@ -335,7 +338,7 @@ func (self *Chequebook) Deposit(amount *big.Int) (string, error) {
 // The caller must hold self.lock.
 func (self *Chequebook) deposit(amount *big.Int) (string, error) {
 	// since the amount is variable here, we do not use sessions
-	depositTransactor := bind.NewKeyedTransactor(self.prvKey)
+	depositTransactor := bind.NewSignerTransactor(self.signer)
 	depositTransactor.Value = amount
 	chbookRaw := &contract.ChequebookRaw{Contract: self.contract}
 	tx, err := chbookRaw.Transfer(depositTransactor)
@@ -444,8 +447,8 @@ type Inbox struct {
 }
 
 // NewInbox creates an Inbox. An Inboxes is not persisted, the cumulative sum is updated
-// from blockchain when first cheque is received.
-func NewInbox(prvKey *ecdsa.PrivateKey, contractAddr, beneficiary common.Address, signer *ecdsa.PublicKey, abigen bind.ContractBackend) (self *Inbox, err error) {
+// from blockchain when first cheque is received. Cashing transactions are signed by sender.
+func NewInbox(sender accounts.Signer, contractAddr, beneficiary common.Address, signer *ecdsa.PublicKey, abigen bind.ContractBackend) (self *Inbox, err error) {
 	if signer == nil {
 		return nil, fmt.Errorf("signer is null")
 	}
@@ -453,18 +456,17 @@ func NewInbox(prvKey *ecdsa.PrivateKey, contractAddr, beneficiary common.Address
 	if err != nil {
 		return nil, err
 	}
-	transactOpts := bind.NewKeyedTransactor(prvKey)
+	transactOpts := bind.NewSignerTransactor(sender)
 	transactOpts.GasLimit = gasToCash
 	session := &contract.ChequebookSession{
 		Contract:     chbook,
 		TransactOpts: *transactOpts,
 	}
-	sender := transactOpts.From
 
 	self = &Inbox{
 		contract:    contractAddr,
 		beneficiary: beneficiary,
-		sender:      sender,
+		sender:      transactOpts.From,
 		signer:      signer,
 		session:     session,
 		cashed:      new(big.Int).Set(common.Big0),
@@ -24,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
 	"github.com/ethereum/go-ethereum/common"
@@ -67,7 +68,7 @@ func TestIssueAndReceive(t *testing.T) {
 	if err != nil {
 		t.Fatalf("deploy contract: expected no error, got %v", err)
 	}
-	chbook, err := NewChequebook(path, addr0, key0, backend)
+	chbook, err := NewChequebook(path, addr0, accounts.NewKeySigner(key0), backend)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -92,7 +93,7 @@ func TestIssueAndReceive(t *testing.T) {
 		t.Errorf("expected: %v, got %v", "0", chbook.Balance())
 	}
 
-	chbox, err := NewInbox(key1, addr0, addr1, &key0.PublicKey, backend)
+	chbox, err := NewInbox(accounts.NewKeySigner(key1), addr0, addr1, &key0.PublicKey, backend)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -111,7 +112,7 @@ func TestIssueAndReceive(t *testing.T) {
 func TestCheckbookFile(t *testing.T) {
 	path := filepath.Join(os.TempDir(), "chequebook-test.json")
 	backend := newTestBackend()
-	chbook, err := NewChequebook(path, addr0, key0, backend)
+	chbook, err := NewChequebook(path, addr0, accounts.NewKeySigner(key0), backend)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -120,7 +121,7 @@ func TestCheckbookFile(t *testing.T) {
 
 	chbook.Save()
 
-	chbook, err = LoadChequebook(path, key0, backend, false)
+	chbook, err = LoadChequebook(path, accounts.NewKeySigner(key0), backend, false)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -149,14 +150,14 @@ func TestVerifyErrors(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	chbook0, err := NewChequebook(path0, contr0, key0, backend)
+	chbook0, err := NewChequebook(path0, contr0, accounts.NewKeySigner(key0), backend)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
 
 	path1 := filepath.Join(os.TempDir(), "chequebook-test-1.json")
 	contr1, err := deploy(key1, common.Big2, backend)
-	chbook1, err := NewChequebook(path1, contr1, key1, backend)
+	chbook1, err := NewChequebook(path1, contr1, accounts.NewKeySigner(key1), backend)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -171,7 +172,7 @@ func TestVerifyErrors(t *testing.T) {
 	}
 
 	time.Sleep(5)
-	chbox, err := NewInbox(key1, contr0, addr1, &key0.PublicKey, backend)
+	chbox, err := NewInbox(accounts.NewKeySigner(key1), contr0, addr1, &key0.PublicKey, backend)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -224,7 +225,7 @@ func TestDeposit(t *testing.T) {
 	path0 := filepath.Join(os.TempDir(), "chequebook-test-0.json")
 	backend := newTestBackend()
 	contr0, err := deploy(key0, new(big.Int), backend)
-	chbook, err := NewChequebook(path0, contr0, key0, backend)
+	chbook, err := NewChequebook(path0, contr0, accounts.NewKeySigner(key0), backend)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -362,7 +363,7 @@ func TestCash(t *testing.T) {
 	path := filepath.Join(os.TempDir(), "chequebook-test.json")
 	backend := newTestBackend()
 	contr0, err := deploy(key0, common.Big2, backend)
-	chbook, err := NewChequebook(path, contr0, key0, backend)
+	chbook, err := NewChequebook(path, contr0, accounts.NewKeySigner(key0), backend)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -374,7 +375,7 @@ func TestCash(t *testing.T) {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	backend.Commit()
-	chbox, err := NewInbox(key1, contr0, addr1, &key0.PublicKey, backend)
+	chbox, err := NewInbox(accounts.NewKeySigner(key1), contr0, addr1, &key0.PublicKey, backend)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -63,7 +63,7 @@ var (
 )
 
 type Config struct {
-	ChainConfig *params.ChainConfig // chain configuration
+	ChainConfig *params.ChainConfig `json:"-"` // chain configuration, read from the database
 
 	NetworkId  int    // Network ID to use for selecting peers to connect to
 	Genesis    string // Genesis JSON to seed the chain database with
@@ -100,8 +100,8 @@ type Config struct {
 	EnableJit bool
 	ForceJit  bool
 
-	TestGenesisBlock *types.Block   // Genesis block to seed the chain database with (testing only!)
-	TestGenesisState ethdb.Database // Genesis state to seed the database with (testing only!)
+	TestGenesisBlock *types.Block   `json:"-"` // Genesis block to seed the chain database with (testing only!)
+	TestGenesisState ethdb.Database `json:"-"` // Genesis state to seed the database with (testing only!)
 }
 
 type LesServer interface {
@@ -418,6 +418,12 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	}
 }
 
+// HealthChecks implements node.HealthChecker, reporting the node as not ready
+// while the chain is syncing.
+func (s *Ethereum) HealthChecks() []node.HealthCheck {
+	return []node.HealthCheck{{Name: "eth.sync", Readiness: true, Check: s.Downloader().CheckSynced}}
+}
+
 // Start implements node.Service, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *Ethereum) Start(srvr *p2p.Server) error {
@@ -269,46 +269,42 @@ func upgradeChainDatabase(db ethdb.Database) error {
 	// At least some of the database is still the old format, upgrade (skip the head block!)
 	glog.V(logger.Info).Info("Old database detected, upgrading...")
 
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
-		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
-			// Skip anything other than a combined block
-			if !bytes.HasPrefix(it.Key(), blockPrefix) {
-				continue
-			}
-			// Skip the head block (merge last to signal upgrade completion)
-			if bytes.HasSuffix(it.Key(), head.Bytes()) {
-				continue
-			}
-			// Load the block, split and serialize (order!)
-			block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
-
-			if err := core.WriteTd(db, block.Hash(), block.NumberU64(), block.DeprecatedTd()); err != nil {
-				return err
-			}
-			if err := core.WriteBody(db, block.Hash(), block.NumberU64(), block.Body()); err != nil {
-				return err
-			}
-			if err := core.WriteHeader(db, block.Header()); err != nil {
-				return err
-			}
-			if err := db.Delete(it.Key()); err != nil {
-				return err
-			}
+	blockPrefix := []byte("block-hash-")
+	it := db.NewIteratorWithPrefix(blockPrefix)
+	defer it.Release()
+	for it.Next() {
+		// Skip the head block (merge last to signal upgrade completion)
+		if bytes.HasSuffix(it.Key(), head.Bytes()) {
+			continue
 		}
-		// Lastly, upgrade the head block, disabling the upgrade mechanism
-		current := core.GetBlockByHashOld(db, head)
+		// Load the block, split and serialize (order!)
+		block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
 
-		if err := core.WriteTd(db, current.Hash(), current.NumberU64(), current.DeprecatedTd()); err != nil {
+		if err := core.WriteTd(db, block.Hash(), block.NumberU64(), block.DeprecatedTd()); err != nil {
+			return err
+		}
+		if err := core.WriteBody(db, block.Hash(), block.NumberU64(), block.Body()); err != nil {
 			return err
 		}
-		if err := core.WriteBody(db, current.Hash(), current.NumberU64(), current.Body()); err != nil {
+		if err := core.WriteHeader(db, block.Header()); err != nil {
 			return err
 		}
-		if err := core.WriteHeader(db, current.Header()); err != nil {
+		if err := db.Delete(it.Key()); err != nil {
 			return err
 		}
 	}
+	// Lastly, upgrade the head block, disabling the upgrade mechanism
+	current := core.GetBlockByHashOld(db, head)
+
+	if err := core.WriteTd(db, current.Hash(), current.NumberU64(), current.DeprecatedTd()); err != nil {
+		return err
+	}
+	if err := core.WriteBody(db, current.Hash(), current.NumberU64(), current.Body()); err != nil {
+		return err
+	}
+	if err := core.WriteHeader(db, current.Header()); err != nil {
+		return err
+	}
 	return nil
 }
 
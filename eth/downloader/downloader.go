@@ -238,6 +238,16 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	}
 }
 
+// CheckSynced returns an error while the local chain is behind the highest
+// block known from peers.
+func (d *Downloader) CheckSynced() error {
+	progress := d.Progress()
+	if progress.CurrentBlock < progress.HighestBlock {
+		return fmt.Errorf("syncing, at block %d of %d", progress.CurrentBlock, progress.HighestBlock)
+	}
+	return nil
+}
+
 // Synchronising returns whether the downloader is currently retrieving blocks.
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
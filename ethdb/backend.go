@@ -0,0 +1,96 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Names of the database backends.
+const (
+	LevelDB = "leveldb"
+	BoltDB  = "boltdb"
+
+	DefaultBackend = LevelDB
+)
+
+// Backends lists the names of all database backends.
+var Backends = []string{LevelDB, BoltDB}
+
+// IdealBatchSize is the value size at which batches should be written when
+// writing large amounts of data.
+const IdealBatchSize = 100 * 1024
+
+// Open opens the database in the directory file with the given backend, creating
+// it if there is none. An empty backend name selects the default backend. cache
+// and handles only apply to LevelDB. Opening a database with a different backend
+// than it was created with fails.
+func Open(backend, file string, cache, handles int) (Database, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	if existing := DetectBackend(file); existing != "" && existing != backend {
+		return nil, fmt.Errorf("database %s uses backend %s, not %s", file, existing, backend)
+	}
+	switch backend {
+	case LevelDB:
+		return NewLDBDatabase(file, cache, handles)
+	case BoltDB:
+		return NewBoltDatabase(file)
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", backend)
+	}
+}
+
+// DetectBackend returns the backend of the database in directory file, or the
+// empty string if there is no database.
+func DetectBackend(file string) string {
+	switch {
+	case common.FileExist(filepath.Join(file, "CURRENT")):
+		return LevelDB
+	case common.FileExist(filepath.Join(file, boltFileName)):
+		return BoltDB
+	default:
+		return ""
+	}
+}
+
+// Copy writes all entries of src to dst.
+func Copy(dst, src Database) error {
+	it := src.NewIteratorWithPrefix(nil)
+	defer it.Release()
+
+	batch := dst.NewBatch()
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, backend := range Backends {
+		file := filepath.Join(dir, backend)
+		db, err := Open(backend, file, 0, 0)
+		if err != nil {
+			t.Fatalf("can't open %s database: %v", backend, err)
+		}
+		db.Put([]byte("key"), []byte(backend))
+		db.Close()
+		if detected := DetectBackend(file); detected != backend {
+			t.Errorf("detected backend %q for %s database", detected, backend)
+		}
+		// Databases can only be reopened with the backend they were created with.
+		for _, other := range Backends {
+			if other != backend {
+				if _, err := Open(other, file, 0, 0); err == nil {
+					t.Errorf("opened %s database with backend %s", backend, other)
+				}
+			}
+		}
+		db, err = Open(backend, file, 0, 0)
+		if err != nil {
+			t.Fatalf("can't reopen %s database: %v", backend, err)
+		}
+		if v, err := db.Get([]byte("key")); err != nil || string(v) != backend {
+			t.Errorf("%s database lost entry: %q %v", backend, v, err)
+		}
+		db.Close()
+	}
+	if _, err := Open("foo", filepath.Join(dir, "foo"), 0, 0); err == nil {
+		t.Error("opened database with unknown backend")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src, _ := NewMemDatabase()
+	for i := 0; i < 1000; i++ {
+		src.Put([]byte(fmt.Sprintf("key%d", i)), make([]byte, 1024))
+	}
+	dst, _ := NewMemDatabase()
+	dst.Put([]byte("other"), []byte("value"))
+	if err := Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(dst.Keys()); n != 1001 {
+		t.Errorf("destination has %d entries, want 1001", n)
+	}
+	for _, key := range src.Keys() {
+		if _, err := dst.Get(key); err != nil {
+			t.Errorf("key %q not copied", key)
+		}
+	}
+}
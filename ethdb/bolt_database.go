@@ -0,0 +1,247 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+const (
+	boltFileName = "data.bolt" // name of the database file within the database directory
+
+	// boltIteratorChunk is the number of entries an iterator reads per transaction.
+	// Iterators don't hold transactions open, as long read transactions block the
+	// database file from growing.
+	boltIteratorChunk = 256
+)
+
+var (
+	boltBucket = []byte("ethdb")
+
+	errNotFound = errors.New("not found")
+)
+
+// BoltDatabase is a database backed by a BoltDB B+tree file. Unlike LevelDB it
+// doesn't compact in the background, so writes never stall, at the cost of
+// syncing every write to disk.
+type BoltDatabase struct {
+	fn string   // directory for reporting
+	db *bolt.DB // BoltDB instance
+}
+
+// NewBoltDatabase opens the BoltDB database in the given directory, creating it
+// if needed.
+func NewBoltDatabase(file string) (*BoltDatabase, error) {
+	if err := os.MkdirAll(file, 0700); err != nil {
+		return nil, err
+	}
+	// Fail instead of waiting forever if another process holds the database.
+	db, err := bolt.Open(filepath.Join(file, boltFileName), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Opened BoltDB database %s", file)
+	return &BoltDatabase{fn: file, db: db}, nil
+}
+
+// Path returns the path to the database directory.
+func (db *BoltDatabase) Path() string {
+	return db.fn
+}
+
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (db *BoltDatabase) Get(key []byte) (value []byte, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get(key)
+		if v == nil {
+			return errNotFound
+		}
+		// Values are only valid during the transaction.
+		value = common.CopyBytes(v)
+		return nil
+	})
+	return value, err
+}
+
+func (db *BoltDatabase) Delete(key []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (db *BoltDatabase) Close() {
+	err := db.db.Close()
+	if glog.V(logger.Error) {
+		if err == nil {
+			glog.Infoln("closed db:", db.fn)
+		} else {
+			glog.Errorf("error closing db %s: %v", db.fn, err)
+		}
+	}
+}
+
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db.db}
+}
+
+// NewIteratorWithPrefix returns an iterator over the entries whose keys start
+// with prefix.
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.NewIteratorWithRange(prefix, prefixLimit(prefix))
+}
+
+// NewIteratorWithRange returns an iterator over the entries whose keys are in
+// the range [start, limit). The iterator reads the database in chunks, so it
+// may observe writes made during iteration.
+func (db *BoltDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	it := &boltIterator{db: db.db, next: common.CopyBytes(start), pos: -1}
+	if limit != nil {
+		it.limit = common.CopyBytes(limit)
+	}
+	return it
+}
+
+type boltIterator struct {
+	db    *bolt.DB
+	next  []byte // key to continue reading at
+	limit []byte
+	done  bool
+
+	entries []kv
+	pos     int
+	err     error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.pos++
+	if it.pos < len(it.entries) {
+		return true
+	}
+	if it.done {
+		it.entries = nil
+		return false
+	}
+	it.entries, it.pos = it.entries[:0], 0
+	it.err = it.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(it.next); k != nil; k, v = c.Next() {
+			if it.limit != nil && bytes.Compare(k, it.limit) >= 0 {
+				break
+			}
+			if len(it.entries) == boltIteratorChunk {
+				it.next = common.CopyBytes(k)
+				return nil
+			}
+			it.entries = append(it.entries, kv{k: common.CopyBytes(k), v: common.CopyBytes(v)})
+		}
+		it.done = true
+		return nil
+	})
+	return it.err == nil && len(it.entries) > 0
+}
+
+func (it *boltIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].k
+}
+
+func (it *boltIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].v
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	it.entries, it.done = nil, true
+}
+
+type boltBatch struct {
+	db     *bolt.DB
+	writes []kv
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), v: common.CopyBytes(value)})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), del: true})
+	b.size++
+	return nil
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *boltBatch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, kv := range b.writes {
+			var err error
+			if kv.del {
+				err = bucket.Delete(kv.k)
+			} else {
+				err = bucket.Put(kv.k, kv.v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -158,6 +159,18 @@ func (self *LDBDatabase) NewIterator() iterator.Iterator {
 	return self.db.NewIterator(nil, nil)
 }
 
+// NewIteratorWithPrefix returns an iterator over the entries whose keys start
+// with prefix.
+func (self *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return self.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+// NewIteratorWithRange returns an iterator over the entries whose keys are in
+// the range [start, limit).
+func (self *LDBDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return self.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
 func (self *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	self.quitLock.Lock()
@@ -293,15 +306,32 @@ func (db *LDBDatabase) NewBatch() Batch {
 }
 
 type ldbBatch struct {
-	db *leveldb.DB
-	b  *leveldb.Batch
+	db   *leveldb.DB
+	b    *leveldb.Batch
+	size int
 }
 
 func (b *ldbBatch) Put(key, value []byte) error {
 	b.b.Put(key, value)
+	b.size += len(value)
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size++
+	return nil
+}
+
+func (b *ldbBatch) ValueSize() int {
+	return b.size
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
+
+func (b *ldbBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
@@ -17,8 +17,13 @@
 package ethdb
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -32,3 +37,190 @@ func newDb() *LDBDatabase {
 
 	return db
 }
+
+func newTestLDB(t *testing.T) (*LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLDBDatabase(t *testing.T) {
+	db, remove := newTestLDB(t)
+	defer remove()
+	testDatabase(t, db)
+}
+
+func TestMemDatabase(t *testing.T) {
+	db, _ := NewMemDatabase()
+	testDatabase(t, db)
+}
+
+func TestTable(t *testing.T) {
+	db, _ := NewMemDatabase()
+	// Entries around the table's key range must not be visible through it.
+	db.Put([]byte("t"), []byte("outside"))
+	db.Put([]byte("t-"), []byte("empty key"))
+	db.Put([]byte("u"), []byte("outside"))
+	db.Delete([]byte("t-"))
+	testDatabase(t, NewTable(db, "t-"))
+
+	if v, err := db.Get([]byte("t-b")); err != nil || string(v) != "2" {
+		t.Errorf("table entry not stored with prefix: %q %v", v, err)
+	}
+	if v, _ := db.Get([]byte("u")); string(v) != "outside" {
+		t.Error("table modified entry outside of its prefix")
+	}
+}
+
+func TestPrefixLimit(t *testing.T) {
+	tests := []struct{ prefix, limit []byte }{
+		{nil, nil},
+		{[]byte{0xff, 0xff}, nil},
+		{[]byte{1, 2}, []byte{1, 3}},
+		{[]byte{1, 0xff}, []byte{2}},
+	}
+	for _, test := range tests {
+		if limit := prefixLimit(test.prefix); !bytes.Equal(limit, test.limit) {
+			t.Errorf("prefixLimit(%x) = %x, want %x", test.prefix, limit, test.limit)
+		}
+	}
+}
+
+// testDatabase checks that db behaves like an empty Database. It must be able to
+// store the keys "a" to "e" in its key space.
+func testDatabase(t *testing.T, db Database) {
+	entries := map[string]string{"a": "1", "b": "2", "ba": "3", "bb": "4", "c": "5"}
+	for k, v := range entries {
+		if err := db.Put([]byte(k), []byte(v)); err != nil {
+			t.Fatalf("put %q failed: %v", k, err)
+		}
+	}
+	for k, v := range entries {
+		if got, err := db.Get([]byte(k)); err != nil || string(got) != v {
+			t.Fatalf("get %q: got %q, %v, want %q", k, got, err, v)
+		}
+	}
+
+	checkIterator := func(name string, it Iterator, want ...string) {
+		defer it.Release()
+		var got []string
+		for it.Next() {
+			got = append(got, string(it.Key()))
+			if v := string(it.Value()); v != entries[string(it.Key())] {
+				t.Errorf("%s: key %q has value %q, want %q", name, it.Key(), v, entries[string(it.Key())])
+			}
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("%s: iterator error: %v", name, err)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got keys %q, want %q", name, got, want)
+		}
+	}
+	checkIterator("all", db.NewIteratorWithPrefix(nil), "a", "b", "ba", "bb", "c")
+	checkIterator("prefix b", db.NewIteratorWithPrefix([]byte("b")), "b", "ba", "bb")
+	checkIterator("prefix x", db.NewIteratorWithPrefix([]byte("x")))
+	checkIterator("range [b, c)", db.NewIteratorWithRange([]byte("b"), []byte("c")), "b", "ba", "bb")
+	checkIterator("range [ba, ∞)", db.NewIteratorWithRange([]byte("ba"), nil), "ba", "bb", "c")
+	checkIterator("range [-∞, b)", db.NewIteratorWithRange(nil, []byte("b")), "a")
+
+	// Batches apply puts and deletes in order, only when written.
+	batch := db.NewBatch()
+	batch.Put([]byte("d"), []byte("6"))
+	batch.Delete([]byte("a"))
+	batch.Delete([]byte("ba"))
+	batch.Put([]byte("e"), []byte("7"))
+	batch.Delete([]byte("e"))
+	if size := batch.ValueSize(); size != 5 {
+		t.Errorf("batch value size %d, want 5", size)
+	}
+	if _, err := db.Get([]byte("d")); err == nil {
+		t.Error("batch applied before write")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	entries["d"] = "6"
+	delete(entries, "a")
+	delete(entries, "ba")
+	checkIterator("after batch", db.NewIteratorWithPrefix(nil), "b", "bb", "c", "d")
+
+	// Reset batches can be reused.
+	batch.Reset()
+	if size := batch.ValueSize(); size != 0 {
+		t.Errorf("reset batch has value size %d", size)
+	}
+	batch.Put([]byte("e"), []byte("8"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	entries["e"] = "8"
+	checkIterator("after reset batch", db.NewIteratorWithPrefix(nil), "b", "bb", "c", "d", "e")
+
+	if err := db.Delete([]byte("bb")); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := db.Get([]byte("bb")); err == nil {
+		t.Error("deleted key still present")
+	}
+}
+
+func TestBoltDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewBoltDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testDatabase(t, db)
+}
+
+func TestBoltIteratorChunks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewBoltDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Iterate over several chunks, with the range ending exactly at a chunk boundary.
+	n := 2*boltIteratorChunk + 1
+	batch := db.NewBatch()
+	for i := 0; i < n; i++ {
+		batch.Put([]byte(fmt.Sprintf("%04d", i)), []byte{byte(i)})
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	for _, limit := range []int{n, boltIteratorChunk, 2 * boltIteratorChunk} {
+		it := db.NewIteratorWithRange(nil, []byte(fmt.Sprintf("%04d", limit)))
+		count := 0
+		for ; it.Next(); count++ {
+			if want := fmt.Sprintf("%04d", count); string(it.Key()) != want || it.Value()[0] != byte(count) {
+				t.Fatalf("entry %d: got key %q value %x", count, it.Key(), it.Value())
+			}
+		}
+		it.Release()
+		if count != limit {
+			t.Errorf("range limit %d: iterated over %d entries", limit, count)
+		}
+	}
+}
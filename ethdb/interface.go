@@ -16,15 +16,46 @@
 
 package ethdb
 
-type Database interface {
+// Putter wraps the database write operation supported by both batches and regular
+// databases.
+type Putter interface {
 	Put(key []byte, value []byte) error
+}
+
+type Database interface {
+	Putter
 	Get(key []byte) ([]byte, error)
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	// NewIteratorWithPrefix returns an iterator over the entries whose keys
+	// start with prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+
+	// NewIteratorWithRange returns an iterator over the entries whose keys are
+	// in the range [start, limit). A nil start or limit leaves the range
+	// unbounded on that side.
+	NewIteratorWithRange(start, limit []byte) Iterator
 }
 
+// Batch is a write-only database that commits changes to its host database
+// when Write is called. A batch cannot be used concurrently.
 type Batch interface {
-	Put(key, value []byte) error
+	Putter
+	Delete(key []byte) error
+	ValueSize() int // amount of data in the batch
 	Write() error
+	Reset() // discards all changes, so the batch can be reused
+}
+
+// Iterator iterates over the entries of a database in ascending key order. The
+// slices returned by Key and Value must not be modified and are only valid until
+// the next call to Next. An iterator must be released after use.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
 }
@@ -18,6 +18,7 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -95,11 +96,75 @@ func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
-type kv struct{ k, v []byte }
+// NewIteratorWithPrefix returns an iterator over the entries whose keys start
+// with prefix. The iterator operates on a snapshot of the database.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.NewIteratorWithRange(prefix, prefixLimit(prefix))
+}
+
+// NewIteratorWithRange returns an iterator over the entries whose keys are in
+// the range [start, limit). The iterator operates on a snapshot of the database.
+func (db *MemDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.db {
+		if key >= string(start) && (limit == nil || key < string(limit)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = db.db[key]
+	}
+	return &memIterator{keys: keys, values: values, pos: -1}
+}
+
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos >= len(it.keys) {
+		return false
+	}
+	it.pos++
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.pos])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+type kv struct {
+	k, v []byte
+	del  bool
+}
 
 type memBatch struct {
 	db     *MemDatabase
 	writes []kv
+	size   int
 	lock   sync.RWMutex
 }
 
@@ -107,10 +172,27 @@ func (b *memBatch) Put(key, value []byte) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), v: common.CopyBytes(value)})
+	b.size += len(value)
+	return nil
+}
+
+func (b *memBatch) Delete(key []byte) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), del: true})
+	b.size++
 	return nil
 }
 
+func (b *memBatch) ValueSize() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.size
+}
+
 func (b *memBatch) Write() error {
 	b.lock.RLock()
 	defer b.lock.RUnlock()
@@ -119,7 +201,19 @@ func (b *memBatch) Write() error {
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
 }
+
+func (b *memBatch) Reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.writes = b.writes[:0]
+	b.size = 0
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+type table struct {
+	db     Database
+	prefix string
+}
+
+// NewTable returns a Database object that prefixes all keys with a given
+// string. Iterators of the table return keys without the prefix. Closing the
+// table doesn't close the underlying database.
+func NewTable(db Database, prefix string) Database {
+	return &table{db: db, prefix: prefix}
+}
+
+func (dt *table) Put(key []byte, value []byte) error {
+	return dt.db.Put(dt.key(key), value)
+}
+
+func (dt *table) Get(key []byte) ([]byte, error) {
+	return dt.db.Get(dt.key(key))
+}
+
+func (dt *table) Delete(key []byte) error {
+	return dt.db.Delete(dt.key(key))
+}
+
+func (dt *table) Close() {
+	// Do nothing; don't close the underlying DB.
+}
+
+func (dt *table) NewBatch() Batch {
+	return &tableBatch{dt.db.NewBatch(), dt.prefix}
+}
+
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{dt.db.NewIteratorWithPrefix(dt.key(prefix)), len(dt.prefix)}
+}
+
+func (dt *table) NewIteratorWithRange(start, limit []byte) Iterator {
+	var l []byte
+	if limit != nil {
+		l = dt.key(limit)
+	} else {
+		l = prefixLimit([]byte(dt.prefix))
+	}
+	return &tableIterator{dt.db.NewIteratorWithRange(dt.key(start), l), len(dt.prefix)}
+}
+
+func (dt *table) key(key []byte) []byte {
+	return append([]byte(dt.prefix), key...)
+}
+
+type tableBatch struct {
+	batch  Batch
+	prefix string
+}
+
+// NewTableBatch returns a Batch object which prefixes all keys with a given string.
+func NewTableBatch(db Database, prefix string) Batch {
+	return &tableBatch{db.NewBatch(), prefix}
+}
+
+func (tb *tableBatch) Put(key, value []byte) error {
+	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
+}
+
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
+func (tb *tableBatch) ValueSize() int {
+	return tb.batch.ValueSize()
+}
+
+func (tb *tableBatch) Write() error {
+	return tb.batch.Write()
+}
+
+func (tb *tableBatch) Reset() {
+	tb.batch.Reset()
+}
+
+// tableIterator strips the table prefix from the keys of the underlying iterator.
+type tableIterator struct {
+	Iterator
+	n int // length of the prefix
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[it.n:]
+}
+
+// prefixLimit returns the smallest key greater than all keys with the given
+// prefix, or nil if there is none.
+func prefixLimit(prefix []byte) []byte {
+	limit := make([]byte, len(prefix))
+	copy(limit, prefix)
+	for i := len(limit) - 1; i >= 0; i-- {
+		if limit[i] < 0xff {
+			limit[i]++
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
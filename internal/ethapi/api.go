@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/hd"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
@@ -234,6 +235,83 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 	return acc.Address, err
 }
 
+// HDWalletInfo describes an HD wallet and its derived accounts.
+type HDWalletInfo struct {
+	ID       string          `json:"id"`
+	BasePath string          `json:"basePath"`
+	Accounts []HDAccountInfo `json:"accounts"`
+	Mnemonic string          `json:"mnemonic,omitempty"` // only set for new wallets
+}
+
+// HDAccountInfo is an account of an HD wallet.
+type HDAccountInfo struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+func newHDWalletInfo(w *accounts.HDWallet) *HDWalletInfo {
+	info := &HDWalletInfo{ID: w.ID(), BasePath: w.BasePath().String(), Accounts: []HDAccountInfo{}}
+	for _, acc := range w.Accounts() {
+		path, _ := w.Path(acc.Address)
+		info.Accounts = append(info.Accounts, HDAccountInfo{Address: acc.Address, Path: path.String()})
+	}
+	return info
+}
+
+// NewHDWallet creates an HD wallet from a new random mnemonic, encrypting its
+// seed with the passphrase. The mnemonic is returned with the wallet and can
+// restore all of its accounts.
+func (s *PrivateAccountAPI) NewHDWallet(password string) (*HDWalletInfo, error) {
+	entropy, err := hd.NewEntropy(256)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err := hd.NewMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.ImportMnemonic(mnemonic, nil, password)
+	if err != nil {
+		return nil, err
+	}
+	info.Mnemonic = mnemonic
+	return info, nil
+}
+
+// ImportMnemonic creates an HD wallet from a BIP-39 mnemonic, encrypting its
+// seed with the passphrase. Accounts are derived as children of basePath,
+// which defaults to m/44'/60'/0'/0.
+func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, basePath *string, password string) (*HDWalletInfo, error) {
+	var base hd.DerivationPath
+	if basePath != nil {
+		var err error
+		if base, err = hd.ParseDerivationPath(*basePath); err != nil {
+			return nil, err
+		}
+	}
+	w, err := s.am.NewHDWallet(mnemonic, base, password)
+	if err != nil {
+		return nil, err
+	}
+	return newHDWalletInfo(w), nil
+}
+
+// DeriveAccount derives the next account of the HD wallet with the given id.
+func (s *PrivateAccountAPI) DeriveAccount(id string, password string) (common.Address, error) {
+	acc, err := s.am.DeriveHDAccount(id, password)
+	return acc.Address, err
+}
+
+// ListHDWallets returns the HD wallets of the node and their accounts.
+func (s *PrivateAccountAPI) ListHDWallets() []*HDWalletInfo {
+	wallets := s.am.HDWallets()
+	infos := make([]*HDWalletInfo, len(wallets))
+	for i, w := range wallets {
+		infos[i] = newHDWalletInfo(w)
+	}
+	return infos
+}
+
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
@@ -264,7 +342,8 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 	}
 	tx := args.toTransaction()
 	signer := types.MakeSigner(s.b.ChainConfig(), s.b.CurrentBlock().Number())
-	signature, err := s.am.SignWithPassphrase(accounts.Account{Address: args.From}, passwd, signer.Hash(tx).Bytes())
+	req := accounts.NewTxRequest(args.From, tx)
+	signature, err := s.am.SignRequestWithPassphrase(accounts.Account{Address: args.From}, passwd, req, signer.Hash(tx).Bytes())
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -294,7 +373,8 @@ func signHash(data []byte) []byte {
 //
 // https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_sign
 func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr common.Address, passwd string) (hexutil.Bytes, error) {
-	signature, err := s.b.AccountManager().SignWithPassphrase(accounts.Account{Address: addr}, passwd, signHash(data))
+	req := &accounts.SignRequest{Kind: accounts.MessageRequest, From: addr, Data: data}
+	signature, err := s.b.AccountManager().SignRequestWithPassphrase(accounts.Account{Address: addr}, passwd, req, signHash(data))
 	if err != nil {
 		return nil, err
 	}
@@ -976,7 +1056,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	signer := types.MakeSigner(s.b.ChainConfig(), s.b.CurrentBlock().Number())
 
-	signature, err := s.b.AccountManager().Sign(addr, signer.Hash(tx).Bytes())
+	signature, err := s.b.AccountManager().SignRequest(accounts.NewTxRequest(addr, tx), signer.Hash(tx).Bytes())
 	if err != nil {
 		return nil, err
 	}
@@ -1058,7 +1138,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	}
 	tx := args.toTransaction()
 	signer := types.MakeSigner(s.b.ChainConfig(), s.b.CurrentBlock().Number())
-	signature, err := s.b.AccountManager().Sign(args.From, signer.Hash(tx).Bytes())
+	signature, err := s.b.AccountManager().SignRequest(accounts.NewTxRequest(args.From, tx), signer.Hash(tx).Bytes())
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1102,7 +1182,8 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_sign
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
-	signature, err := s.b.AccountManager().Sign(addr, signHash(data))
+	req := &accounts.SignRequest{Kind: accounts.MessageRequest, From: addr, Data: data}
+	signature, err := s.b.AccountManager().SignRequest(req, signHash(data))
 	if err == nil {
 		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
 	}
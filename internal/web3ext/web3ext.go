@@ -84,6 +84,12 @@ web3._extend({
 			call: 'bzz_modify',
 			params: 4,
 			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'resync',
+			call: 'bzz_resync',
+			params: 3,
+			inputFormatter: [null, null, null]
 		})
 	],
 	properties:
@@ -96,6 +102,10 @@ web3._extend({
 			name: 'info',
 			getter: 'bzz_info',
 		}),
+		new web3._extend.Property({
+			name: 'syncStatus',
+			getter: 'bzz_syncStatus'
+		}),
 	]
 });
 `
@@ -174,6 +184,27 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addTrustedPeer',
+			call: 'admin_addTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeTrustedPeer',
+			call: 'admin_removeTrustedPeer',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -229,6 +260,14 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
+		}),
+		new web3._extend.Property({
+			name: 'bans',
+			getter: 'admin_bans'
+		}),
+		new web3._extend.Property({
+			name: 'trustedPeers',
+			getter: 'admin_trustedPeers'
 		})
 	]
 });
@@ -385,6 +424,11 @@ web3._extend({
 			call: 'debug_traceTransaction',
 			params: 2,
 			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'bzzSyncState',
+			call: 'debug_bzzSyncState',
+			params: 0
 		})
 	],
 	properties: []
@@ -551,6 +595,33 @@ web3._extend({
 			name: 'ecRecover',
 			call: 'personal_ecRecover',
 			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'newHDWallet',
+			call: 'personal_newHDWallet',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importMnemonic',
+			call: 'personal_importMnemonic',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'deriveAccount',
+			call: 'personal_deriveAccount',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'listHDWallets',
+			call: 'personal_listHDWallets',
+			params: 0
+		})
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'hdWallets',
+			getter: 'personal_listHDWallets'
 		})
 	]
 })
@@ -187,6 +187,12 @@ func (s *LightEthereum) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
 
+// HealthChecks implements node.HealthChecker, reporting the node as not ready
+// while the header chain is syncing.
+func (s *LightEthereum) HealthChecks() []node.HealthCheck {
+	return []node.HealthCheck{{Name: "les.sync", Readiness: true, Check: s.Downloader().CheckSynced}}
+}
+
 // Start implements node.Service, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *LightEthereum) Start(srvr *p2p.Server) error {
@@ -25,7 +25,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rcrowley/go-metrics"
+	"golang.org/x/net/context"
 )
 
 // PrivateAdminAPI is the collection of administrative API methods exposed only
@@ -73,6 +75,153 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer bans a node ID, enode URL, IP address or CIDR network for the given
+// number of seconds, or permanently if no duration is given. Connected peers
+// matching the ban are dropped.
+func (api *PrivateAdminAPI) BanPeer(target string, seconds *uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	var expiry time.Time
+	if seconds != nil && *seconds > 0 {
+		expiry = time.Now().Add(time.Duration(*seconds) * time.Second)
+	}
+	ban, err := discover.ParseBan(target, expiry)
+	if err != nil {
+		return false, fmt.Errorf("invalid ban target: %v", err)
+	}
+	if err := server.AddBan(ban); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a node ID, enode URL, IP address or CIDR network.
+func (api *PrivateAdminAPI) UnbanPeer(target string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	ban, err := discover.ParseBan(target, time.Time{})
+	if err != nil {
+		return false, fmt.Errorf("invalid ban target: %v", err)
+	}
+	return server.RemoveBan(ban)
+}
+
+// BanInfo describes a ban in effect
+type BanInfo struct {
+	Target string     `json:"target"`
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// Bans lists the banned nodes and networks.
+func (api *PrivateAdminAPI) Bans() ([]*BanInfo, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	infos := []*BanInfo{}
+	for _, ban := range server.Bans() {
+		info := &BanInfo{Target: ban.String()}
+		if !ban.Expiry.IsZero() {
+			expiry := ban.Expiry
+			info.Expiry = &expiry
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// AddTrustedPeer marks a remote node as trusted, allowing it to connect even
+// if the node is at its peer limit.
+func (api *PrivateAdminAPI) AddTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if err := server.AddTrustedPeer(node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveTrustedPeer removes a remote node added by AddTrustedPeer from the
+// trusted nodes.
+func (api *PrivateAdminAPI) RemoveTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	return server.RemoveTrustedPeer(node)
+}
+
+// TrustedPeers lists the enode URLs of the trusted nodes.
+func (api *PrivateAdminAPI) TrustedPeers() ([]string, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	urls := []string{}
+	for _, node := range server.TrustedPeers() {
+		urls = append(urls, node.String())
+	}
+	return urls, nil
+}
+
+// PeerEvents creates an RPC subscription which receives peer events from the
+// node's p2p.Server
+func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+
+	// Create the subscription
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		sub := server.SubscribeEvents()
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				notifier.Notify(rpcSub.ID, ev.Data)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // StartRPC starts the HTTP RPC API server.
 func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
@@ -18,6 +18,10 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -25,16 +29,21 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/policy"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var (
@@ -48,18 +57,21 @@ var (
 // Config represents a small collection of configuration values to fine tune the
 // P2P network layer of a protocol stack. These values can be further extended by
 // all registered services.
+//
+// Fields which can not be loaded from a configuration file are excluded from its
+// JSON encoding.
 type Config struct {
 	// Name sets the instance name of the node. It must not contain the / character and is
 	// used in the devp2p node identifier. The instance name of geth is "geth". If no
 	// value is specified, the basename of the current executable is used.
-	Name string
+	Name string `json:"-"`
 
 	// UserIdent, if set, is used as an additional component in the devp2p node identifier.
 	UserIdent string
 
 	// Version should be set to the version number of the program. It is used
 	// in the devp2p node identifier.
-	Version string
+	Version string `json:"-"`
 
 	// DataDir is the file system folder the node should use for any data storage
 	// requirements. The configured data directory will not be directly shared with
@@ -68,6 +80,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBBackend is the storage engine of databases opened by services (see the
+	// ethdb package for the available backends). Empty means LevelDB.
+	DBBackend string
+
 	// KeyStoreDir is the file system folder that contains private keys. The directory can
 	// be specified as a relative path, in which case it is resolved relative to the
 	// current directory.
@@ -81,6 +97,16 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool
 
+	// ExternalSigner is the endpoint (IPC path or URL) of an external signer. If
+	// set, the accounts of the signer are available in addition to the keystore,
+	// their keys never enter the node.
+	ExternalSigner string
+
+	// SigningPolicy is the path of a signing policy file (see package
+	// accounts/policy). If set, requests to sign transactions, messages and
+	// cheques with any account are checked against the policy.
+	SigningPolicy string
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -91,7 +117,7 @@ type Config struct {
 	// remote peer identification as well as network traffic encryption. If no key
 	// is configured, the preset one is loaded from the data dir, generating it if
 	// needed.
-	PrivateKey *ecdsa.PrivateKey
+	PrivateKey *ecdsa.PrivateKey `json:"-"`
 
 	// NoDiscovery specifies whether the peer discovery mechanism should be started
 	// or not. Disabling is usually useful for protocol debugging (manual topology).
@@ -119,16 +145,25 @@ type Config struct {
 	ListenAddr string
 
 	// If set to a non-nil value, the given NAT port mapper is used to make the
-	// listening port available to the Internet.
+	// listening port available to the Internet. It is encoded as its nat.Parse
+	// description.
 	NAT nat.Interface
 
 	// If Dialer is set to a non-nil value, the given Dialer is used to dial outbound
 	// peer connections.
-	Dialer *net.Dialer
+	Dialer p2p.NodeDialer `json:"-"`
+
+	// If Listener is set to a non-nil value, inbound peer connections are accepted
+	// from it instead of listening on ListenAddr.
+	Listener net.Listener `json:"-"`
 
 	// If NoDial is true, the node will not dial any peers.
 	NoDial bool
 
+	// If EnableMsgEvents is set, peer events (admin_peerEvents) include an event
+	// for every message sent to or received from a peer.
+	EnableMsgEvents bool
+
 	// MaxPeers is the maximum number of peers that can be connected. If this is
 	// set to zero, then only the configured static and trusted peers can connect.
 	MaxPeers int
@@ -175,6 +210,89 @@ type Config struct {
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
 	WSModules []string
+
+	// RPCAuthTokens maps client names to static bearer tokens accepted on the HTTP
+	// and websocket RPC interfaces. If neither tokens nor a JWT secret are set, the
+	// interfaces don't require authentication.
+	RPCAuthTokens map[string]string
+
+	// RPCJWTSecret is the hex encoded shared secret of HS256 signed JSON Web Tokens
+	// accepted on the HTTP and websocket RPC interfaces. The client name is taken
+	// from the token's subject.
+	RPCJWTSecret string
+
+	// RPCAccess restricts the modules and methods HTTP and websocket clients may
+	// call. It maps client names to lists of modules ("eth") or methods
+	// ("eth_sendTransaction"), the "*" entry applies to all other clients.
+	RPCAccess map[string][]string
+
+	// RPCRateLimit is the number of requests per second each HTTP or websocket client
+	// may issue, RPCRateBurst the number of requests it may issue at once. Clients
+	// are identified by name if authenticated, by address otherwise. Zero disables
+	// rate limiting.
+	RPCRateLimit float64
+	RPCRateBurst int
+
+	// RPCTimeout is the maximum execution time of RPC method calls on the IPC, HTTP
+	// and websocket interfaces. RPCMethodTimeouts overrides it for individual
+	// modules ("debug") or methods ("debug_traceTransaction"). Zero means no timeout.
+	RPCTimeout        time.Duration
+	RPCMethodTimeouts map[string]time.Duration
+
+	// RPCBatchLimit is the maximum number of requests in an RPC batch and
+	// RPCResponseLimit the maximum size of an RPC response in bytes on the IPC, HTTP
+	// and websocket interfaces. Zero means unlimited.
+	RPCBatchLimit    int
+	RPCResponseLimit int
+
+	// RPCAccessLog is the file RPC requests of all interfaces are logged to as JSON
+	// objects, one per line. Relative paths are resolved in the instance directory.
+	// If empty, no access log is written.
+	RPCAccessLog string
+}
+
+// plainConfig has the fields of Config without its JSON methods.
+type plainConfig Config
+
+// MarshalJSON implements json.Marshaler, encoding the NAT port mapper as its
+// nat.Parse description.
+func (c Config) MarshalJSON() ([]byte, error) {
+	enc := struct {
+		*plainConfig
+		NAT string `json:",omitempty"`
+	}{plainConfig: (*plainConfig)(&c)}
+	if c.NAT != nil {
+		m, ok := c.NAT.(encoding.TextMarshaler)
+		if !ok {
+			return nil, fmt.Errorf("can't encode NAT port mapper %v", c.NAT)
+		}
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		enc.NAT = string(text)
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, parsing the NAT port mapper with
+// nat.Parse.
+func (c *Config) UnmarshalJSON(input []byte) error {
+	dec := struct {
+		*plainConfig
+		NAT *string
+	}{plainConfig: (*plainConfig)(c)}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.NAT != nil {
+		natif, err := nat.Parse(*dec.NAT)
+		if err != nil {
+			return fmt.Errorf("NAT: %v", err)
+		}
+		c.NAT = natif
+	}
+	return nil
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -202,6 +320,51 @@ func (c *Config) IPCEndpoint() string {
 	return c.IPCPath
 }
 
+// RPCAuthenticator creates the authenticator for the HTTP and websocket RPC
+// interfaces, or nil if they are unauthenticated.
+func (c *Config) RPCAuthenticator() (rpc.Authenticator, error) {
+	var auth rpc.MultiAuth
+	if len(c.RPCAuthTokens) > 0 {
+		auth = append(auth, rpc.TokenAuth(c.RPCAuthTokens))
+	}
+	if c.RPCJWTSecret != "" {
+		secret, err := hex.DecodeString(strings.TrimPrefix(c.RPCJWTSecret, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid RPC JWT secret: %v", err)
+		}
+		if len(secret) < 32 {
+			return nil, errors.New("RPC JWT secret must be at least 32 bytes")
+		}
+		auth = append(auth, &rpc.JWTAuth{Secret: secret})
+	}
+	switch len(auth) {
+	case 0:
+		return nil, nil
+	case 1:
+		return auth[0], nil
+	}
+	return auth, nil
+}
+
+// RPCLimits returns the resource limits of RPC requests.
+func (c *Config) RPCLimits() rpc.Limits {
+	return rpc.Limits{
+		Timeout:         c.RPCTimeout,
+		MethodTimeouts:  c.RPCMethodTimeouts,
+		MaxBatchSize:    c.RPCBatchLimit,
+		MaxResponseSize: c.RPCResponseLimit,
+	}
+}
+
+// RPCAccessLogPath returns the path of the RPC access log, or the empty string
+// if it is disabled.
+func (c *Config) RPCAccessLogPath() string {
+	if c.RPCAccessLog == "" {
+		return ""
+	}
+	return c.resolvePath(c.RPCAccessLog)
+}
+
 // NodeDB returns the path to the discovery node database.
 func (c *Config) NodeDB() string {
 	if c.DataDir == "" {
@@ -210,6 +373,14 @@ func (c *Config) NodeDB() string {
 	return c.resolvePath("nodes")
 }
 
+// RegistryDB returns the path to the database of banned and trusted nodes.
+func (c *Config) RegistryDB() string {
+	if c.DataDir == "" {
+		return "" // ephemeral
+	}
+	return c.resolvePath("registry")
+}
+
 // DefaultIPCEndpoint returns the IPC path used by default.
 func DefaultIPCEndpoint(clientIdentifier string) string {
 	if clientIdentifier == "" {
@@ -313,6 +484,11 @@ func (c *Config) resolvePath(path string) string {
 	return filepath.Join(c.instanceDir(), path)
 }
 
+// InstanceDir retrieves the instance directory of a node with this configuration.
+func (c *Config) InstanceDir() string {
+	return c.instanceDir()
+}
+
 func (c *Config) instanceDir() string {
 	if c.DataDir == "" {
 		return ""
@@ -432,5 +608,20 @@ func makeAccountManager(conf *Config) (am *accounts.Manager, ephemeralKeystore s
 		return nil, "", err
 	}
 
-	return accounts.NewManager(keydir, scryptN, scryptP), ephemeralKeystore, nil
+	am = accounts.NewManager(keydir, scryptN, scryptP)
+	if conf.ExternalSigner != "" {
+		signer, err := external.Dial(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", err
+		}
+		am.AddBackend(signer)
+	}
+	if conf.SigningPolicy != "" {
+		p, err := policy.Load(conf.SigningPolicy)
+		if err != nil {
+			return nil, "", err
+		}
+		am.SetPolicy(p)
+	}
+	return am, ephemeralKeystore, nil
 }
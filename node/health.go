@@ -0,0 +1,183 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// healthCheckTimeout is the time after which a check that hasn't returned is
+// reported as failed.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthChecker is implemented by services contributing checks to the /health
+// and /ready endpoints of the HTTP RPC server.
+type HealthChecker interface {
+	HealthChecks() []HealthCheck
+}
+
+// HealthCheck is a named check of a component of the node.
+type HealthCheck struct {
+	Name string // e.g. "p2p.peers"
+
+	// Readiness checks are only run by /ready. They fail while the node is up but
+	// can't serve yet, e.g. while it has no peers or is syncing. Other checks are
+	// run by both /health and /ready.
+	Readiness bool
+
+	// Check returns nil if the component is healthy.
+	Check func() error
+}
+
+// HealthStatus is the aggregated result of health checks, served as JSON.
+type HealthStatus struct {
+	Status string                       `json:"status"` // "ok" if all checks passed, "fail" otherwise
+	Checks map[string]HealthCheckStatus `json:"checks"`
+}
+
+// HealthCheckStatus is the result of a single check.
+type HealthCheckStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OK reports whether all checks passed.
+func (s *HealthStatus) OK() bool {
+	return s.Status == "ok"
+}
+
+// healthChecks gathers the checks of the node and its services. Check names
+// must be unique as results are reported by name.
+func (n *Node) healthChecks(services map[reflect.Type]Service) ([]HealthCheck, error) {
+	var checks []HealthCheck
+	if n.config.MaxPeers > 0 {
+		checks = append(checks, HealthCheck{Name: "p2p.peers", Readiness: true, Check: n.checkPeers})
+	}
+	for _, service := range services {
+		if hc, ok := service.(HealthChecker); ok {
+			checks = append(checks, hc.HealthChecks()...)
+		}
+	}
+	sort.Sort(healthChecksByName(checks))
+	for i := 1; i < len(checks); i++ {
+		if checks[i].Name == checks[i-1].Name {
+			return nil, fmt.Errorf("duplicate health check: %s", checks[i].Name)
+		}
+	}
+	return checks, nil
+}
+
+func (n *Node) checkPeers() error {
+	server := n.Server()
+	if server == nil {
+		return errors.New("p2p server not running")
+	}
+	if server.PeerCount() == 0 {
+		return errors.New("no peers")
+	}
+	return nil
+}
+
+type healthChecksByName []HealthCheck
+
+func (s healthChecksByName) Len() int           { return len(s) }
+func (s healthChecksByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s healthChecksByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Health runs the health checks of the node concurrently. If ready is false,
+// readiness checks are skipped.
+func (n *Node) Health(ready bool) *HealthStatus {
+	n.lock.RLock()
+	checks := n.health
+	n.lock.RUnlock()
+	return runHealthChecks(checks, ready)
+}
+
+func runHealthChecks(checks []HealthCheck, ready bool) *HealthStatus {
+	type result struct {
+		name string
+		err  error
+	}
+	status := &HealthStatus{Status: "ok", Checks: make(map[string]HealthCheckStatus)}
+	results := make(chan result, len(checks))
+	pending := make(map[string]bool)
+	for _, check := range checks {
+		if check.Readiness && !ready {
+			continue
+		}
+		pending[check.Name] = true
+		go func(check HealthCheck) {
+			results <- result{check.Name, check.Check()}
+		}(check)
+	}
+	report := func(name string, err error) {
+		if err == nil {
+			status.Checks[name] = HealthCheckStatus{Status: "ok"}
+			return
+		}
+		status.Status = "fail"
+		status.Checks[name] = HealthCheckStatus{Status: "fail", Error: err.Error()}
+	}
+	timeout := time.NewTimer(healthCheckTimeout)
+	defer timeout.Stop()
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			report(r.name, r.err)
+		case <-timeout.C:
+			for name := range pending {
+				report(name, errors.New("timed out"))
+			}
+			return status
+		}
+	}
+	return status
+}
+
+// healthHandler serves /health and /ready, passing other requests to next.
+// The endpoints don't require authentication so that orchestrators can probe
+// the node.
+func (n *Node) healthHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ready bool
+		switch r.URL.Path {
+		case "/health":
+		case "/ready":
+			ready = true
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status := n.Health(ready)
+		w.Header().Set("content-type", "application/json")
+		if !status.OK() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type healthService struct {
+	NoopService
+	ready error
+}
+
+func (s *healthService) HealthChecks() []HealthCheck {
+	return []HealthCheck{
+		{Name: "test.alive", Check: func() error { return nil }},
+		{Name: "test.ready", Readiness: true, Check: func() error { return s.ready }},
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	conf := testNodeConfig()
+	conf.HTTPHost = "127.0.0.1"
+	stack, err := New(conf)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	service := &healthService{ready: errors.New("syncing")}
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return service, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+	url := "http://" + stack.httpListener.Addr().String()
+
+	get := func(path string) (int, *HealthStatus) {
+		resp, err := http.Get(url + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		status := new(HealthStatus)
+		if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+			t.Fatalf("GET %s: invalid response: %v", path, err)
+		}
+		return resp.StatusCode, status
+	}
+	code, status := get("/health")
+	if code != http.StatusOK || !status.OK() || len(status.Checks) != 1 {
+		t.Errorf("/health: unexpected response %d %+v", code, status)
+	}
+	code, status = get("/ready")
+	if code != http.StatusServiceUnavailable || status.OK() {
+		t.Errorf("/ready: unexpected response %d %+v", code, status)
+	}
+	if check := status.Checks["test.ready"]; check.Status != "fail" || check.Error != "syncing" {
+		t.Errorf("/ready: unexpected check result %+v", check)
+	}
+	service.ready = nil
+	if code, status = get("/ready"); code != http.StatusOK || len(status.Checks) != 2 {
+		t.Errorf("/ready: unexpected response %d %+v", code, status)
+	}
+
+	// Other requests still reach the RPC server.
+	body := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`)
+	resp, err := http.Post(url, "application/json", body)
+	if err != nil {
+		t.Fatalf("RPC request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("RPC request: unexpected status %d", resp.StatusCode)
+	}
+}
+
+func TestHealthCheckAggregation(t *testing.T) {
+	checks := []HealthCheck{
+		{Name: "a", Check: func() error { return nil }},
+		{Name: "b", Check: func() error { return fmt.Errorf("down") }},
+		{Name: "c", Readiness: true, Check: func() error { return nil }},
+	}
+	status := runHealthChecks(checks, false)
+	if status.OK() || len(status.Checks) != 2 || status.Checks["b"].Error != "down" {
+		t.Errorf("unexpected health status %+v", status)
+	}
+	if status = runHealthChecks(checks[:1], true); !status.OK() {
+		t.Errorf("unexpected health status %+v", status)
+	}
+}
+
+// duplicateHealthService registers the same checks as healthService.
+type duplicateHealthService struct{ healthService }
+
+func TestHealthCheckDuplicate(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	constructors := []ServiceConstructor{
+		func(*ServiceContext) (Service, error) { return &healthService{}, nil },
+		func(*ServiceContext) (Service, error) { return &duplicateHealthService{}, nil },
+	}
+	for i, constructor := range constructors {
+		if err := stack.Register(constructor); err != nil {
+			t.Fatalf("service #%d: failed to register: %v", i, err)
+		}
+	}
+	if err := stack.Start(); err == nil {
+		stack.Stop()
+		t.Fatal("started with duplicate health checks")
+	} else if !strings.Contains(err.Error(), "test.alive") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -77,6 +77,11 @@ type Node struct {
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	health []HealthCheck // Checks served by the /health and /ready HTTP endpoints
+
+	rpcAuth      rpc.Authenticator // Authenticator of HTTP and websocket RPC clients (nil = open)
+	rpcAccessLog *os.File          // Access log of all RPC endpoints (nil = disabled)
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 }
@@ -111,6 +116,10 @@ func New(conf *Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	rpcAuth, err := conf.RPCAuthenticator()
+	if err != nil {
+		return nil, err
+	}
 	// Note: any interaction with Config that would create/touch files
 	// in the data directory or instance directory is delayed until Start.
 	return &Node{
@@ -121,6 +130,7 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		rpcAuth:           rpcAuth,
 		eventmux:          new(event.TypeMux),
 	}, nil
 }
@@ -154,23 +164,27 @@ func (n *Node) Start() error {
 	// Initialize the p2p server. This creates the node key and
 	// discovery databases.
 	n.serverConfig = p2p.Config{
-		PrivateKey:       n.config.NodeKey(),
-		Name:             n.config.NodeName(),
-		Discovery:        !n.config.NoDiscovery,
-		DiscoveryV5:      n.config.DiscoveryV5,
-		DiscoveryV5Addr:  n.config.DiscoveryV5Addr,
-		BootstrapNodes:   n.config.BootstrapNodes,
-		BootstrapNodesV5: n.config.BootstrapNodesV5,
-		StaticNodes:      n.config.StaticNodes(),
-		TrustedNodes:     n.config.TrusterNodes(),
-		NodeDatabase:     n.config.NodeDB(),
-		ListenAddr:       n.config.ListenAddr,
-		NetRestrict:      n.config.NetRestrict,
-		NAT:              n.config.NAT,
-		Dialer:           n.config.Dialer,
-		NoDial:           n.config.NoDial,
-		MaxPeers:         n.config.MaxPeers,
-		MaxPendingPeers:  n.config.MaxPendingPeers,
+		PrivateKey:          n.config.NodeKey(),
+		Name:                n.config.NodeName(),
+		Discovery:           !n.config.NoDiscovery,
+		DiscoveryV5:         n.config.DiscoveryV5,
+		DiscoveryV5Addr:     n.config.DiscoveryV5Addr,
+		BootstrapNodes:      n.config.BootstrapNodes,
+		BootstrapNodesV5:    n.config.BootstrapNodesV5,
+		StaticNodes:         n.config.StaticNodes(),
+		TrustedNodes:        n.config.TrusterNodes(),
+		NodeDatabase:        n.config.NodeDB(),
+		NodeDatabaseBackend: n.config.DBBackend,
+		RegistryDatabase:    n.config.RegistryDB(),
+		ListenAddr:          n.config.ListenAddr,
+		NetRestrict:         n.config.NetRestrict,
+		NAT:                 n.config.NAT,
+		Dialer:              n.config.Dialer,
+		Listener:            n.config.Listener,
+		NoDial:              n.config.NoDial,
+		MaxPeers:            n.config.MaxPeers,
+		MaxPendingPeers:     n.config.MaxPendingPeers,
+		EnableMsgEvents:     n.config.EnableMsgEvents,
 	}
 	running := &p2p.Server{Config: n.serverConfig}
 	glog.V(logger.Info).Infoln("instance:", n.serverConfig.Name)
@@ -269,23 +283,35 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	health, err := n.healthChecks(services)
+	if err != nil {
+		return err
+	}
+	if err := n.openAccessLog(); err != nil {
+		return err
+	}
+	n.health = health
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startIPC(apis); err != nil {
 		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors); err != nil {
 		n.stopIPC()
 		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins); err != nil {
 		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	// All API endpoints started successfully
@@ -293,6 +319,39 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	return nil
 }
 
+// openAccessLog opens the RPC access log file, if one is configured.
+func (n *Node) openAccessLog() error {
+	path := n.config.RPCAccessLogPath()
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	n.rpcAccessLog = file
+	glog.V(logger.Info).Infof("RPC access log: %s", path)
+	return nil
+}
+
+// closeAccessLog closes the RPC access log file.
+func (n *Node) closeAccessLog() {
+	if n.rpcAccessLog != nil {
+		n.rpcAccessLog.Close()
+		n.rpcAccessLog = nil
+	}
+}
+
+// logRPC makes an RPC server write to the access log, if it is enabled.
+func (n *Node) logRPC(handler *rpc.Server) {
+	if n.rpcAccessLog != nil {
+		handler.SetAccessLog(n.rpcAccessLog)
+	}
+}
+
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
@@ -303,6 +362,7 @@ func (n *Node) startInProc(apis []rpc.API) error {
 		}
 		glog.V(logger.Debug).Infof("InProc registered %T under '%s'", api.Service, api.Namespace)
 	}
+	n.logRPC(handler)
 	n.inprocHandler = handler
 	return nil
 }
@@ -329,6 +389,8 @@ func (n *Node) startIPC(apis []rpc.API) error {
 		}
 		glog.V(logger.Debug).Infof("IPC registered %T under '%s'", api.Service, api.Namespace)
 	}
+	handler.SetLimits(n.config.RPCLimits())
+	n.logRPC(handler)
 	// All APIs registered, start the IPC listener
 	var (
 		listener net.Listener
@@ -399,6 +461,8 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 			glog.V(logger.Debug).Infof("HTTP registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
+	n.secureRPC(handler)
+	n.logRPC(handler)
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -407,7 +471,9 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	server := rpc.NewHTTPServer(cors, handler)
+	server.Handler = n.healthHandler(server.Handler)
+	go server.Serve(listener)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 
 	// All listeners booted successfully
@@ -418,6 +484,15 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	return nil
 }
 
+// secureRPC applies the configured authentication, access control, rate
+// limiting and resource limits to an RPC server exposed over the network.
+func (n *Node) secureRPC(handler *rpc.Server) {
+	handler.SetAuthenticator(n.rpcAuth)
+	handler.SetAccessList(n.config.RPCAccess)
+	handler.SetRateLimit(n.config.RPCRateLimit, n.config.RPCRateBurst)
+	handler.SetLimits(n.config.RPCLimits())
+}
+
 // stopHTTP terminates the HTTP RPC endpoint.
 func (n *Node) stopHTTP() {
 	if n.httpListener != nil {
@@ -453,6 +528,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 			glog.V(logger.Debug).Infof("WebSocket registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
+	n.secureRPC(handler)
+	n.logRPC(handler)
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -501,6 +578,7 @@ func (n *Node) Stop() error {
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
+	n.closeAccessLog()
 	n.rpcAPIs = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
@@ -646,7 +724,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	if n.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return ethdb.Open(n.config.DBBackend, n.config.resolvePath(name), cache, handles)
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
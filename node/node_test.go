@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -103,6 +104,43 @@ func TestNodeUsedDataDir(t *testing.T) {
 	}
 }
 
+// Tests that RPC requests are written to the configured access log.
+func TestNodeRPCAccessLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stack, err := New(&Config{DataDir: dir, PrivateKey: testNodeKey, RPCAccessLog: "rpcaccess.log"})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	client, err := stack.Attach()
+	if err != nil {
+		t.Fatalf("failed to attach to node: %v", err)
+	}
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatalf("RPC call failed: %v", err)
+	}
+	client.Close()
+	if err := stack.Stop(); err != nil {
+		t.Fatalf("failed to stop node: %v", err)
+	}
+
+	log, err := ioutil.ReadFile(stack.config.RPCAccessLogPath())
+	if err != nil {
+		t.Fatalf("failed to read access log: %v", err)
+	}
+	if !strings.Contains(string(log), `"method":"rpc_modules"`) {
+		t.Fatalf("call missing from access log: %s", log)
+	}
+}
+
 // Tests whether services can be registered and duplicates caught.
 func TestServiceRegistry(t *testing.T) {
 	stack, err := New(testNodeConfig())
@@ -51,6 +51,7 @@ type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
 	netrestrict *netutil.Netlist
+	registry    *discover.Registry // banned nodes are not dialed
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
@@ -81,6 +82,25 @@ type task interface {
 	Do(*Server)
 }
 
+// NodeDialer is used to connect to nodes in the network, typically by using
+// an underlying net.Dialer but also using in-memory connections in
+// simulations.
+type NodeDialer interface {
+	Dial(*discover.Node) (net.Conn, error)
+}
+
+// TCPDialer implements the NodeDialer interface by using a net.Dialer to
+// create TCP connections to nodes in the network.
+type TCPDialer struct {
+	*net.Dialer
+}
+
+// Dial creates a TCP connection to the node.
+func (t TCPDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
+	return t.Dialer.Dial("tcp", addr.String())
+}
+
 // A dialTask is generated for each node that is dialed. Its
 // fields cannot be accessed while the task is running.
 type dialTask struct {
@@ -103,11 +123,12 @@ type waitExpireTask struct {
 	time.Duration
 }
 
-func newDialState(static []*discover.Node, ntab discoverTable, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
+func newDialState(static []*discover.Node, ntab discoverTable, maxdyn int, netrestrict *netutil.Netlist, registry *discover.Registry) *dialstate {
 	s := &dialstate{
 		maxDynDials: maxdyn,
 		ntab:        ntab,
 		netrestrict: netrestrict,
+		registry:    registry,
 		static:      make(map[discover.NodeID]*dialTask),
 		dialing:     make(map[discover.NodeID]connFlag),
 		randomNodes: make([]*discover.Node, maxdyn/2),
@@ -214,6 +235,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errBannedNode       = errors.New("banned")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -227,6 +249,9 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errSelf
 	case s.netrestrict != nil && !s.netrestrict.Contains(n.IP):
 		return errNotWhitelisted
+	case s.registry.Banned(n.ID, n.IP):
+		// static nodes are kept, they are dialed again when the ban is lifted
+		return errBannedNode
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
 	}
@@ -295,9 +320,8 @@ func (t *dialTask) resolve(srv *Server) bool {
 
 // dial performs the actual connection attempt.
 func (t *dialTask) dial(srv *Server, dest *discover.Node) bool {
-	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
-	glog.V(logger.Debug).Infof("dial tcp %v (%x)\n", addr, dest.ID[:6])
-	fd, err := srv.Dialer.Dial("tcp", addr.String())
+	glog.V(logger.Debug).Infof("dial %v:%d (%x)\n", dest.IP, dest.TCP, dest.ID[:6])
+	fd, err := srv.Dialer.Dial(dest)
 	if err != nil {
 		glog.V(logger.Detail).Infof("%v", err)
 		return false
@@ -87,7 +87,7 @@ func (t fakeTable) ReadRandomNodes(buf []*discover.Node) int { return copy(buf,
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
 	runDialTest(t, dialtest{
-		init: newDialState(nil, fakeTable{}, 5, nil),
+		init: newDialState(nil, fakeTable{}, 5, nil, nil),
 		rounds: []round{
 			// A discovery query is launched.
 			{
@@ -234,7 +234,7 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(nil, table, 10, nil),
+		init: newDialState(nil, table, 10, nil, nil),
 		rounds: []round{
 			// 5 out of 8 of the nodes returned by ReadRandomNodes are dialed.
 			{
@@ -332,7 +332,7 @@ func TestDialStateNetRestrict(t *testing.T) {
 	restrict.Add("127.0.2.0/24")
 
 	runDialTest(t, dialtest{
-		init: newDialState(nil, table, 10, restrict),
+		init: newDialState(nil, table, 10, restrict, nil),
 		rounds: []round{
 			{
 				new: []task{
@@ -355,7 +355,7 @@ func TestDialStateStaticDial(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, fakeTable{}, 0, nil, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -427,6 +427,35 @@ func TestDialStateStaticDial(t *testing.T) {
 	})
 }
 
+// This test checks that banned static nodes are not dialed until the ban
+// is lifted.
+func TestDialStateStaticBanned(t *testing.T) {
+	registry, err := discover.OpenRegistry("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+	ban := &discover.Ban{ID: uintID(2)}
+	if err := registry.Ban(ban); err != nil {
+		t.Fatal(err)
+	}
+	static := []*discover.Node{{ID: uintID(1)}, {ID: uintID(2)}}
+	s := newDialState(static, fakeTable{}, 0, nil, registry)
+
+	var now time.Time
+	want := []task{&dialTask{flags: staticDialedConn, dest: &discover.Node{ID: uintID(1)}}}
+	if tasks := s.newTasks(0, nil, now); !sametasks(tasks, want) {
+		t.Fatalf("got tasks %v, want %v", spew.Sdump(tasks), spew.Sdump(want))
+	}
+	if _, err := registry.Unban(ban); err != nil {
+		t.Fatal(err)
+	}
+	want = []task{&dialTask{flags: staticDialedConn, dest: &discover.Node{ID: uintID(2)}}}
+	if tasks := s.newTasks(1, nil, now); !sametasks(tasks, want) {
+		t.Fatalf("got tasks after unban %v, want %v", spew.Sdump(tasks), spew.Sdump(want))
+	}
+}
+
 // This test checks that past dials are not retried for some time.
 func TestDialStateCache(t *testing.T) {
 	wantStatic := []*discover.Node{
@@ -436,7 +465,7 @@ func TestDialStateCache(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, fakeTable{}, 0, nil, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -498,7 +527,7 @@ func TestDialStateCache(t *testing.T) {
 func TestDialResolve(t *testing.T) {
 	resolved := discover.NewNode(uintID(1), net.IP{127, 0, 55, 234}, 3333, 4444)
 	table := &resolveMock{answer: resolved}
-	state := newDialState(nil, table, 0, nil)
+	state := newDialState(nil, table, 0, nil, nil)
 
 	// Check that the task is generated with an incomplete ID.
 	dest := discover.NewNode(uintID(1), nil, 0, 0)
@@ -509,7 +538,7 @@ func TestDialResolve(t *testing.T) {
 	}
 
 	// Now run the task, it should resolve the ID once.
-	config := Config{Dialer: &net.Dialer{Deadline: time.Now().Add(-5 * time.Minute)}}
+	config := Config{Dialer: TCPDialer{&net.Dialer{Deadline: time.Now().Add(-5 * time.Minute)}}}
 	srv := &Server{ntab: table, Config: config}
 	tasks[0].Do(srv)
 	if !reflect.DeepEqual(table.resolveCalls, []discover.NodeID{dest.ID}) {
@@ -28,15 +28,10 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
-	"github.com/syndtr/goleveldb/leveldb/opt"
-	"github.com/syndtr/goleveldb/leveldb/storage"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
@@ -47,10 +42,10 @@ var (
 
 // nodeDB stores all nodes we know about.
 type nodeDB struct {
-	lvl    *leveldb.DB   // Interface to the database itself
-	self   NodeID        // Own node id to prevent adding it into the database
-	runner sync.Once     // Ensures we can start at most one expirer
-	quit   chan struct{} // Channel to signal the expiring thread to stop
+	store  ethdb.Database // Interface to the database itself
+	self   NodeID         // Own node id to prevent adding it into the database
+	runner sync.Once      // Ensures we can start at most one expirer
+	quit   chan struct{}  // Channel to signal the expiring thread to stop
 }
 
 // Schema layout for the node database
@@ -66,36 +61,33 @@ var (
 
 // newNodeDB creates a new node database for storing and retrieving infos about
 // known peers in the network. If no path is given, an in-memory, temporary
-// database is constructed.
-func newNodeDB(path string, version int, self NodeID) (*nodeDB, error) {
+// database is constructed. An empty backend selects the default one.
+func newNodeDB(backend, path string, version int, self NodeID) (*nodeDB, error) {
 	if path == "" {
 		return newMemoryNodeDB(self)
 	}
-	return newPersistentNodeDB(path, version, self)
+	return newPersistentNodeDB(backend, path, version, self)
 }
 
 // newMemoryNodeDB creates a new in-memory node database without a persistent
 // backend.
 func newMemoryNodeDB(self NodeID) (*nodeDB, error) {
-	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	db, err := ethdb.NewMemDatabase()
 	if err != nil {
 		return nil, err
 	}
 	return &nodeDB{
-		lvl:  db,
-		self: self,
-		quit: make(chan struct{}),
+		store: db,
+		self:  self,
+		quit:  make(chan struct{}),
 	}, nil
 }
 
-// newPersistentNodeDB creates/opens a leveldb backed persistent node database,
-// also flushing its contents in case of a version mismatch.
-func newPersistentNodeDB(path string, version int, self NodeID) (*nodeDB, error) {
-	opts := &opt.Options{OpenFilesCacheCapacity: 5}
-	db, err := leveldb.OpenFile(path, opts)
-	if _, iscorrupted := err.(*errors.ErrCorrupted); iscorrupted {
-		db, err = leveldb.RecoverFile(path, nil)
-	}
+// newPersistentNodeDB creates/opens a persistent node database with the given
+// backend, also flushing its contents in case of a version mismatch. An
+// existing database is opened with the backend it was created with.
+func newPersistentNodeDB(backend, path string, version int, self NodeID) (*nodeDB, error) {
+	db, err := openDB(backend, path)
 	if err != nil {
 		return nil, err
 	}
@@ -104,32 +96,42 @@ func newPersistentNodeDB(path string, version int, self NodeID) (*nodeDB, error)
 	currentVer := make([]byte, binary.MaxVarintLen64)
 	currentVer = currentVer[:binary.PutVarint(currentVer, int64(version))]
 
-	blob, err := db.Get(nodeDBVersionKey, nil)
-	switch err {
-	case leveldb.ErrNotFound:
+	blob, err := db.Get(nodeDBVersionKey)
+	if err != nil {
 		// Version not found (i.e. empty cache), insert it
-		if err := db.Put(nodeDBVersionKey, currentVer, nil); err != nil {
+		if err := db.Put(nodeDBVersionKey, currentVer); err != nil {
 			db.Close()
 			return nil, err
 		}
-
-	case nil:
+	} else if !bytes.Equal(blob, currentVer) {
 		// Version present, flush if different
-		if !bytes.Equal(blob, currentVer) {
-			db.Close()
-			if err = os.RemoveAll(path); err != nil {
-				return nil, err
-			}
-			return newPersistentNodeDB(path, version, self)
+		db.Close()
+		if err = os.RemoveAll(path); err != nil {
+			return nil, err
 		}
+		return newPersistentNodeDB(backend, path, version, self)
 	}
 	return &nodeDB{
-		lvl:  db,
-		self: self,
-		quit: make(chan struct{}),
+		store: db,
+		self:  self,
+		quit:  make(chan struct{}),
 	}, nil
 }
 
+// openDB opens the database at path, creating it with the given backend if
+// there is none. Existing databases keep their backend until they are
+// converted with the db convert command.
+func openDB(backend, path string) (ethdb.Database, error) {
+	if backend == "" {
+		backend = ethdb.DefaultBackend
+	}
+	if existing := ethdb.DetectBackend(path); existing != "" && existing != backend {
+		glog.V(logger.Warn).Infof("Database %s uses backend %s, not %s", path, existing, backend)
+		backend = existing
+	}
+	return ethdb.Open(backend, path, 0, 0)
+}
+
 // makeKey generates the leveldb key-blob from a node id and its particular
 // field of interest.
 func makeKey(id NodeID, field string) []byte {
@@ -156,7 +158,7 @@ func splitKey(key []byte) (id NodeID, field string) {
 // fetchInt64 retrieves an integer instance associated with a particular
 // database key.
 func (db *nodeDB) fetchInt64(key []byte) int64 {
-	blob, err := db.lvl.Get(key, nil)
+	blob, err := db.store.Get(key)
 	if err != nil {
 		return 0
 	}
@@ -173,12 +175,12 @@ func (db *nodeDB) storeInt64(key []byte, n int64) error {
 	blob := make([]byte, binary.MaxVarintLen64)
 	blob = blob[:binary.PutVarint(blob, n)]
 
-	return db.lvl.Put(key, blob, nil)
+	return db.store.Put(key, blob)
 }
 
 // node retrieves a node with a given id from the database.
 func (db *nodeDB) node(id NodeID) *Node {
-	blob, err := db.lvl.Get(makeKey(id, nodeDBDiscoverRoot), nil)
+	blob, err := db.store.Get(makeKey(id, nodeDBDiscoverRoot))
 	if err != nil {
 		glog.V(logger.Detail).Infof("failed to retrieve node %v: %v", id, err)
 		return nil
@@ -198,18 +200,20 @@ func (db *nodeDB) updateNode(node *Node) error {
 	if err != nil {
 		return err
 	}
-	return db.lvl.Put(makeKey(node.ID, nodeDBDiscoverRoot), blob, nil)
+	return db.store.Put(makeKey(node.ID, nodeDBDiscoverRoot), blob)
 }
 
 // deleteNode deletes all information/keys associated with a node.
 func (db *nodeDB) deleteNode(id NodeID) error {
-	deleter := db.lvl.NewIterator(util.BytesPrefix(makeKey(id, "")), nil)
+	deleter := db.store.NewIteratorWithPrefix(makeKey(id, ""))
+	defer deleter.Release()
+
 	for deleter.Next() {
-		if err := db.lvl.Delete(deleter.Key(), nil); err != nil {
+		if err := db.store.Delete(deleter.Key()); err != nil {
 			return err
 		}
 	}
-	return nil
+	return deleter.Error()
 }
 
 // ensureExpirer is a small helper method ensuring that the data expiration
@@ -248,7 +252,7 @@ func (db *nodeDB) expireNodes() error {
 	threshold := time.Now().Add(-nodeDBNodeExpiration)
 
 	// Find discovered nodes that are older than the allowance
-	it := db.lvl.NewIterator(nil, nil)
+	it := db.store.NewIteratorWithPrefix(nil)
 	defer it.Release()
 
 	for it.Next() {
@@ -306,10 +310,8 @@ func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
 	var (
 		now   = time.Now()
 		nodes = make([]*Node, 0, n)
-		it    = db.lvl.NewIterator(nil, nil)
 		id    NodeID
 	)
-	defer it.Release()
 
 seek:
 	for seeks := 0; len(nodes) < n && seeks < n*5; seeks++ {
@@ -319,9 +321,9 @@ seek:
 		ctr := id[0]
 		rand.Read(id[:])
 		id[0] = ctr + id[0]%16
-		it.Seek(makeKey(id, nodeDBDiscoverRoot))
-
+		it := db.store.NewIteratorWithRange(makeKey(id, nodeDBDiscoverRoot), nil)
 		n := nextNode(it)
+		it.Release()
 		if n == nil {
 			id[0] = 0
 			continue seek // iterator exhausted
@@ -344,8 +346,8 @@ seek:
 
 // reads the next node record from the iterator, skipping over other
 // database entries.
-func nextNode(it iterator.Iterator) *Node {
-	for end := false; !end; end = !it.Next() {
+func nextNode(it ethdb.Iterator) *Node {
+	for it.Next() {
 		id, field := splitKey(it.Key())
 		if field != nodeDBDiscoverRoot {
 			continue
@@ -365,5 +367,5 @@ func nextNode(it iterator.Iterator) *Node {
 // close flushes and closes the database files.
 func (db *nodeDB) close() {
 	close(db.quit)
-	db.lvl.Close()
+	db.store.Close()
 }
@@ -0,0 +1,300 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+var errBanned = errors.New("banned")
+
+// Schema layout of the registry database
+var (
+	registryBanPrefix     = []byte("ban:")
+	registryTrustedPrefix = []byte("trusted:")
+)
+
+// Ban excludes a node ID or an IP network from discovery and peer
+// connections until it expires.
+type Ban struct {
+	ID     NodeID     // banned node, zero if a network is banned
+	Net    *net.IPNet // banned network, nil if a node is banned
+	Expiry time.Time  // zero for permanent bans
+}
+
+// ParseBan creates a ban from a node ID, an enode URL, an IP address or a
+// CIDR network.
+func ParseBan(target string, expiry time.Time) (*Ban, error) {
+	b := &Ban{Expiry: expiry}
+	switch {
+	case strings.HasPrefix(target, "enode://"):
+		n, err := ParseNode(target)
+		if err != nil {
+			return nil, err
+		}
+		b.ID = n.ID
+	case strings.Contains(target, "/"):
+		_, ipnet, err := net.ParseCIDR(target)
+		if err != nil {
+			return nil, err
+		}
+		b.Net = ipnet
+	case net.ParseIP(target) != nil:
+		ip := net.ParseIP(target)
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		b.Net = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	default:
+		id, err := HexID(target)
+		if err != nil {
+			return nil, err
+		}
+		b.ID = id
+	}
+	return b, nil
+}
+
+// String returns the banned node ID or network.
+func (b *Ban) String() string {
+	if b.Net != nil {
+		return b.Net.String()
+	}
+	return b.ID.String()
+}
+
+// Matches tells if the ban applies to the node with the given ID and IP.
+// The IP may be nil if it is not known.
+func (b *Ban) Matches(id NodeID, ip net.IP) bool {
+	if b.Net != nil {
+		return ip != nil && b.Net.Contains(ip)
+	}
+	return b.ID == id
+}
+
+func (b *Ban) expired(now time.Time) bool {
+	return !b.Expiry.IsZero() && !now.Before(b.Expiry)
+}
+
+// Registry holds the banned and the dynamically trusted nodes. If it is
+// backed by a database, its entries persist across restarts. Unlike the
+// node database, the registry database is never flushed.
+type Registry struct {
+	mu      sync.RWMutex
+	bans    map[string]*Ban
+	trusted map[NodeID]*Node
+
+	db    ethdb.Database
+	onBan func(*Ban) // called when a ban is added
+}
+
+// OpenRegistry opens a registry backed by the database at the given path,
+// creating it with the given database backend if there is none. If no path
+// is given, the registry is kept in memory.
+func OpenRegistry(backend, path string) (*Registry, error) {
+	var (
+		db  ethdb.Database
+		err error
+	)
+	if path == "" {
+		db, err = ethdb.NewMemDatabase()
+	} else {
+		db, err = openDB(backend, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{
+		bans:    make(map[string]*Ban),
+		trusted: make(map[NodeID]*Node),
+		db:      db,
+	}
+	r.load()
+	return r, nil
+}
+
+// load reads the registry entries from the database, dropping expired bans
+func (r *Registry) load() {
+	now := time.Now()
+	it := r.db.NewIteratorWithPrefix(registryBanPrefix)
+	for it.Next() {
+		expiry, read := binary.Varint(it.Value())
+		if read <= 0 {
+			continue
+		}
+		b, err := ParseBan(string(it.Key()[len(registryBanPrefix):]), time.Time{})
+		if err != nil {
+			glog.V(logger.Warn).Infof("Invalid ban in registry: %v", err)
+			continue
+		}
+		if expiry != 0 {
+			b.Expiry = time.Unix(expiry, 0)
+		}
+		if b.expired(now) {
+			r.db.Delete(it.Key())
+			continue
+		}
+		r.bans[b.String()] = b
+	}
+	it.Release()
+
+	it = r.db.NewIteratorWithPrefix(registryTrustedPrefix)
+	for it.Next() {
+		n, err := ParseNode(string(it.Value()))
+		if err != nil {
+			glog.V(logger.Warn).Infof("Invalid trusted node in registry: %v", err)
+			continue
+		}
+		r.trusted[n.ID] = n
+	}
+	it.Release()
+}
+
+// Close closes the registry database.
+func (r *Registry) Close() {
+	if r != nil {
+		r.db.Close()
+	}
+}
+
+// Ban adds or replaces a ban.
+func (r *Registry) Ban(b *Ban) error {
+	var expiry int64
+	if !b.Expiry.IsZero() {
+		expiry = b.Expiry.Unix()
+	}
+	blob := make([]byte, binary.MaxVarintLen64)
+	blob = blob[:binary.PutVarint(blob, expiry)]
+	key := b.String()
+	if err := r.db.Put(append(registryBanPrefix, key...), blob); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.bans[key] = b
+	onBan := r.onBan
+	r.mu.Unlock()
+	if onBan != nil {
+		onBan(b)
+	}
+	return nil
+}
+
+// Unban removes the ban of the same node or network as b and reports
+// whether there was one.
+func (r *Registry) Unban(b *Ban) (bool, error) {
+	key := b.String()
+	r.mu.Lock()
+	_, ok := r.bans[key]
+	delete(r.bans, key)
+	r.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, r.db.Delete(append(registryBanPrefix, key...))
+}
+
+// Banned tells if the node with the given ID and IP is banned. The IP may
+// be nil if it is not known. A nil registry bans nothing.
+func (r *Registry) Banned(id NodeID, ip net.IP) bool {
+	if r == nil {
+		return false
+	}
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, b := range r.bans {
+		if !b.expired(now) && b.Matches(id, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bans removes the expired bans and returns the bans in effect.
+func (r *Registry) Bans() []*Ban {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bans := make([]*Ban, 0, len(r.bans))
+	for key, b := range r.bans {
+		if !b.expired(now) {
+			bans = append(bans, b)
+			continue
+		}
+		delete(r.bans, key)
+		if err := r.db.Delete(append(registryBanPrefix, key...)); err != nil {
+			glog.V(logger.Warn).Infof("Failed to delete expired ban %s: %v", key, err)
+		}
+	}
+	return bans
+}
+
+// AddTrusted marks a node as trusted.
+func (r *Registry) AddTrusted(n *Node) error {
+	if err := r.db.Put(append(registryTrustedPrefix, n.ID[:]...), []byte(n.String())); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.trusted[n.ID] = n
+	r.mu.Unlock()
+	return nil
+}
+
+// RemoveTrusted removes the trusted mark of a node and reports whether it
+// was set.
+func (r *Registry) RemoveTrusted(id NodeID) (bool, error) {
+	r.mu.Lock()
+	_, ok := r.trusted[id]
+	delete(r.trusted, id)
+	r.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, r.db.Delete(append(registryTrustedPrefix, id[:]...))
+}
+
+// IsTrusted tells if the node was marked as trusted. A nil registry
+// trusts no node.
+func (r *Registry) IsTrusted(id NodeID) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.trusted[id] != nil
+}
+
+// Trusted returns the nodes marked as trusted.
+func (r *Registry) Trusted() []*Node {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]*Node, 0, len(r.trusted))
+	for _, n := range r.trusted {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
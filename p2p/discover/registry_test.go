@@ -0,0 +1,144 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseBan(t *testing.T) {
+	id := MustHexID("0x1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+	tests := []struct {
+		target string
+		str    string
+		id     NodeID
+		ip     net.IP
+		match  bool
+	}{
+		{target: id.String(), str: id.String(), id: id, match: true},
+		{target: "enode://" + id.String() + "@10.0.0.1:30303", str: id.String(), id: id, match: true},
+		{target: "10.0.0.1", str: "10.0.0.1/32", ip: net.IP{10, 0, 0, 1}, match: true},
+		{target: "10.0.0.0/8", str: "10.0.0.0/8", ip: net.IP{10, 1, 2, 3}, match: true},
+		{target: "10.0.0.0/8", str: "10.0.0.0/8", ip: net.IP{11, 1, 2, 3}, match: false},
+		{target: "10.0.0.0/8", str: "10.0.0.0/8", id: id, match: false},
+	}
+	for _, test := range tests {
+		b, err := ParseBan(test.target, time.Time{})
+		if err != nil {
+			t.Errorf("%q: %v", test.target, err)
+			continue
+		}
+		if b.String() != test.str {
+			t.Errorf("%q: got string %q, want %q", test.target, b.String(), test.str)
+		}
+		if b.Matches(test.id, test.ip) != test.match {
+			t.Errorf("%q: match %x %v = %v, want %v", test.target, test.id[:4], test.ip, !test.match, test.match)
+		}
+	}
+	if _, err := ParseBan("foo", time.Time{}); err == nil {
+		t.Error("expected error for invalid target")
+	}
+}
+
+func TestRegistryPersistency(t *testing.T) {
+	root, err := ioutil.TempDir("", "registry-")
+	if err != nil {
+		t.Fatalf("failed to create temporary data folder: %v", err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "database")
+
+	r, err := OpenRegistry("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banned := &Ban{ID: NodeID{1}}
+	expired := &Ban{ID: NodeID{2}, Expiry: time.Now().Add(-time.Second)}
+	_, ipnet, _ := net.ParseCIDR("192.168.0.0/16")
+	netban := &Ban{Net: ipnet, Expiry: time.Now().Add(time.Hour)}
+	for _, b := range []*Ban{banned, expired, netban} {
+		if err := r.Ban(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trusted := NewNode(NodeID{3}, net.IP{127, 0, 0, 1}, 30303, 30303)
+	if err := r.AddTrusted(trusted); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	r, err = OpenRegistry("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !r.Banned(NodeID{1}, nil) {
+		t.Error("node ban not persisted")
+	}
+	if r.Banned(NodeID{2}, nil) {
+		t.Error("expired ban in effect")
+	}
+	if !r.Banned(NodeID{9}, net.IP{192, 168, 1, 1}) {
+		t.Error("network ban not persisted")
+	}
+	if bans := r.Bans(); len(bans) != 2 {
+		t.Errorf("got %d bans, want 2", len(bans))
+	}
+	if !r.IsTrusted(trusted.ID) {
+		t.Error("trusted node not persisted")
+	}
+	if ok, err := r.Unban(banned); !ok || err != nil {
+		t.Errorf("unban: got %v, %v", ok, err)
+	}
+	if r.Banned(NodeID{1}, nil) {
+		t.Error("node still banned after unban")
+	}
+	if ok, err := r.RemoveTrusted(trusted.ID); !ok || err != nil {
+		t.Errorf("remove trusted: got %v, %v", ok, err)
+	}
+	if len(r.Trusted()) != 0 {
+		t.Error("trusted node not removed")
+	}
+}
+
+func TestRegistryExpiry(t *testing.T) {
+	r, err := OpenRegistry("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Ban(&Ban{ID: NodeID{1}, Expiry: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Banned(NodeID{1}, nil) {
+		t.Error("expired ban in effect")
+	}
+	if bans := r.Bans(); len(bans) != 0 {
+		t.Errorf("got %d bans, want 0", len(bans))
+	}
+	if len(r.bans) != 0 {
+		t.Errorf("expired ban kept in memory")
+	}
+	if _, err := r.db.Get(append(registryBanPrefix, NodeID{1}.String()...)); err == nil {
+		t.Errorf("expired ban kept in the database")
+	}
+}
@@ -58,6 +58,8 @@ type Table struct {
 	nursery []*Node           // bootstrap nodes
 	db      *nodeDB           // database of known nodes
 
+	registry *Registry // banned and trusted nodes
+
 	refreshReq chan chan struct{}
 	closeReq   chan struct{}
 	closed     chan struct{}
@@ -92,9 +94,9 @@ type transport interface {
 // that was most recently active is the first element in entries.
 type bucket struct{ entries []*Node }
 
-func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string) (*Table, error) {
+func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBBackend, nodeDBPath string, registry *Registry) (*Table, error) {
 	// If no node database was given, use an in-memory one
-	db, err := newNodeDB(nodeDBPath, Version, ourID)
+	db, err := newNodeDB(nodeDBBackend, nodeDBPath, Version, ourID)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +109,12 @@ func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string
 		refreshReq: make(chan chan struct{}),
 		closeReq:   make(chan struct{}),
 		closed:     make(chan struct{}),
+		registry:   registry,
+	}
+	if registry != nil {
+		registry.mu.Lock()
+		registry.onBan = tab.evict
+		registry.mu.Unlock()
 	}
 	for i := 0; i < cap(tab.bondslots); i++ {
 		tab.bondslots <- struct{}{}
@@ -461,6 +469,9 @@ func (tab *Table) bond(pinged bool, id NodeID, addr *net.UDPAddr, tcpPort uint16
 	if id == tab.self.ID {
 		return nil, errors.New("is self")
 	}
+	if tab.registry.Banned(id, addr.IP) {
+		return nil, errBanned
+	}
 	// Retrieve a previously known node and any recent findnode failures
 	node, fails := tab.db.node(id), 0
 	if node != nil {
@@ -546,6 +557,21 @@ func (tab *Table) ping(id NodeID, addr *net.UDPAddr) error {
 	return nil
 }
 
+// evict removes the nodes matching a ban from the table.
+func (tab *Table) evict(b *Ban) {
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+	for _, bucket := range tab.buckets {
+		entries := bucket.entries[:0]
+		for _, n := range bucket.entries {
+			if !b.Matches(n.ID, n.IP) {
+				entries = append(entries, n)
+			}
+		}
+		bucket.entries = entries
+	}
+}
+
 // add attempts to add the given node its corresponding bucket. If the
 // bucket has space available, adding the node succeeds immediately.
 // Otherwise, the node is added if the least recently active node in
@@ -210,8 +210,11 @@ type reply struct {
 	matched chan<- bool
 }
 
-// ListenUDP returns a new table that listens for UDP packets on laddr.
-func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, nodeDBPath string, netrestrict *netutil.Netlist) (*Table, error) {
+// ListenUDP returns a new table that listens for UDP packets on laddr. Known
+// nodes are kept in the database at nodeDBPath, which is opened with the
+// database backend nodeDBBackend. Nodes banned in the registry are ignored,
+// the registry may be nil.
+func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, nodeDBBackend, nodeDBPath string, registry *Registry, netrestrict *netutil.Netlist) (*Table, error) {
 	addr, err := net.ResolveUDPAddr("udp", laddr)
 	if err != nil {
 		return nil, err
@@ -220,7 +223,7 @@ func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, nodeDBP
 	if err != nil {
 		return nil, err
 	}
-	tab, _, err := newUDP(priv, conn, natm, nodeDBPath, netrestrict)
+	tab, _, err := newUDP(priv, conn, natm, nodeDBBackend, nodeDBPath, registry, netrestrict)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +231,7 @@ func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, nodeDBP
 	return tab, nil
 }
 
-func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBPath string, netrestrict *netutil.Netlist) (*Table, *udp, error) {
+func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBBackend, nodeDBPath string, registry *Registry, netrestrict *netutil.Netlist) (*Table, *udp, error) {
 	udp := &udp{
 		conn:        c,
 		priv:        priv,
@@ -249,7 +252,7 @@ func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBPath strin
 	}
 	// TODO: separate TCP port
 	udp.ourEndpoint = makeEndpoint(realaddr, uint16(realaddr.Port))
-	tab, err := newTable(udp, PubkeyID(&priv.PublicKey), realaddr, nodeDBPath)
+	tab, err := newTable(udp, PubkeyID(&priv.PublicKey), realaddr, nodeDBBackend, nodeDBPath, registry)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -521,6 +524,10 @@ func (t *udp) handlePacket(from *net.UDPAddr, buf []byte) error {
 		glog.V(logger.Debug).Infof("Bad packet from %v: %v\n", from, err)
 		return err
 	}
+	if t.registry.Banned(fromID, from.IP) {
+		glog.V(logger.Detail).Infof("<<< %v %T: %v\n", from, packet, errBanned)
+		return errBanned
+	}
 	status := "ok"
 	if err = packet.handle(t, from, fromID, hash); err != nil {
 		status = err.Error()
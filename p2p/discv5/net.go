@@ -67,6 +67,7 @@ type Network struct {
 	db          *nodeDB // database of known nodes
 	conn        transport
 	netrestrict *netutil.Netlist
+	banned      func(NodeID, net.IP) bool // set by SetBanFilter, used by the loop
 
 	closed           chan struct{}          // closed when loop is done
 	closeReq         chan struct{}          // 'request to close'
@@ -222,6 +223,16 @@ func (net *Network) SetFallbackNodes(nodes []*Node) error {
 	return nil
 }
 
+// SetBanFilter sets the function telling which nodes are banned. Packets
+// from banned nodes are dropped and banned nodes are not added to the table.
+func (net *Network) SetBanFilter(banned func(NodeID, net.IP) bool) {
+	net.reqTableOp(func() { net.banned = banned })
+}
+
+func (net *Network) isBanned(id NodeID, ip net.IP) bool {
+	return net.banned != nil && net.banned(id, ip)
+}
+
 // Resolve searches for a specific node with the given ID.
 // It returns nil if the node could not be found.
 func (net *Network) Resolve(targetID NodeID) *Node {
@@ -431,6 +442,10 @@ loop:
 		case pkt := <-net.read:
 			//fmt.Println("read", pkt.ev)
 			debugLog("<-net.read")
+			if net.isBanned(pkt.remoteID, pkt.remoteAddr.IP) {
+				glog.V(logger.Detail).Infof("<<< %v from %x@%v: banned", pkt.ev, pkt.remoteID[:8], pkt.remoteAddr)
+				continue
+			}
 			n := net.internNode(&pkt)
 			prestate := n.state
 			status := "ok"
@@ -699,6 +714,9 @@ func (net *Network) refresh(done chan<- struct{}) {
 			}
 			glog.Infof("seed node (age %s): %v", age, n)
 		}
+		if net.isBanned(n.ID, n.IP) {
+			continue
+		}
 		n = net.internNodeFromDB(n)
 		if n.state == unknown {
 			net.transition(n, verifyinit)
@@ -746,6 +764,9 @@ func (net *Network) internNodeFromNeighbours(sender *net.UDPAddr, rn rpcNode) (n
 	if rn.UDP <= lowPort {
 		return nil, errors.New("low port")
 	}
+	if net.isBanned(rn.ID, rn.IP) {
+		return nil, errors.New("banned")
+	}
 	n = net.nodes[rn.ID]
 	if n == nil {
 		// We haven't seen this node before.
@@ -1201,7 +1222,7 @@ func (net *Network) handleQueryEvent(n *Node, ev nodeEvent, pkt *ingressPacket)
 		return n.state, nil
 	case topicNodesPacket:
 		p := pkt.data.(*topicNodes)
-		if net.ticketStore.gotTopicNodes(n, p.Echo, p.Nodes) {
+		if net.ticketStore.gotTopicNodes(n, p.Echo, p.Nodes, net.isBanned) {
 			n.queryTimeouts++
 			if n.queryTimeouts > maxFindnodeFailures && n.state == known {
 				return contested, errors.New("too many timeouts")
@@ -63,6 +63,40 @@ func TestNetwork_Lookup(t *testing.T) {
 	// TODO: check result nodes are actually closest
 }
 
+func TestNetwork_BanFilter(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tn := &preminedTestnet{}
+	network, err := newNetwork(tn, key.PublicKey, nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn.net = network
+	defer network.Close()
+
+	banned, allowed := NodeID{1}, NodeID{2}
+	network.SetBanFilter(func(id NodeID, ip net.IP) bool { return id == banned })
+
+	addr := &net.UDPAddr{IP: net.IP{10, 0, 2, 1}, Port: lowPort + 1}
+	for _, id := range []NodeID{banned, allowed} {
+		network.reqReadPacket(ingressPacket{remoteID: id, remoteAddr: addr, ev: pingPacket, data: &ping{}})
+	}
+	// packets are queued, wait until the loop has handled them
+	for handled := false; !handled; {
+		network.reqTableOp(func() { handled = len(network.read) == 0 })
+	}
+	network.reqTableOp(func() {
+		if network.nodes[banned] != nil {
+			t.Error("packet from banned node handled")
+		}
+		if network.nodes[allowed] == nil {
+			t.Error("packet from allowed node not handled")
+		}
+		if _, err := network.internNodeFromNeighbours(addr, rpcNode{ID: banned, IP: addr.IP, UDP: lowPort + 1}); err == nil {
+			t.Error("banned neighbour added")
+		}
+	})
+}
+
 // This is the test network for the Lookup test.
 // The nodes were obtained by running testnet.mine with a random NodeID as target.
 var lookupTestnet = &preminedTestnet{
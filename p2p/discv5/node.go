@@ -123,6 +123,20 @@ func (n *Node) String() string {
 	return u.String()
 }
 
+// MarshalText implements encoding.TextMarshaler, encoding the node as a URL.
+func (n *Node) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing a node URL.
+func (n *Node) UnmarshalText(text []byte) error {
+	dec, err := ParseNode(string(text))
+	if err == nil {
+		*n = *dec
+	}
+	return err
+}
+
 var incompleteNodeURL = regexp.MustCompile("(?i)^(?:enode://)?([0-9a-f]+)$")
 
 // ParseNode parses a node designator.
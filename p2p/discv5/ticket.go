@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"net"
 	"sort"
 	"time"
 
@@ -617,7 +618,9 @@ func (s *ticketStore) cleanupTopicQueries(now mclock.AbsTime) {
 	s.nextTopicQueryCleanup = now + mclock.AbsTime(topicQueryTimeout)
 }
 
-func (s *ticketStore) gotTopicNodes(from *Node, hash common.Hash, nodes []rpcNode) (timeout bool) {
+// gotTopicNodes adjusts the topic radius by the result of a topic query
+// and passes the nodes that are not banned to the search
+func (s *ticketStore) gotTopicNodes(from *Node, hash common.Hash, nodes []rpcNode, banned func(NodeID, net.IP) bool) (timeout bool) {
 	now := mclock.Now()
 	//fmt.Println("got", from.addr().String(), hash, len(nodes))
 	qq := s.queriesSent[from]
@@ -643,6 +646,9 @@ func (s *ticketStore) gotTopicNodes(from *Node, hash common.Hash, nodes []rpcNod
 		if ip.IsUnspecified() || ip.IsLoopback() {
 			ip = from.IP
 		}
+		if banned(node.ID, ip) {
+			continue
+		}
 		n := NewNode(node.ID, ip, node.UDP-1, node.TCP-1) // subtract one from port while discv5 is running in test mode on UDPport+1
 		select {
 		case chn <- n:
@@ -25,6 +25,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
 )
 
 func ExampleMsgPipe() {
@@ -150,3 +152,135 @@ func unhex(str string) []byte {
 	}
 	return b
 }
+
+func TestMsgEventer(t *testing.T) {
+	rw1, rw2 := MsgPipe()
+	defer rw1.Close()
+	feed := new(peerFeed)
+	sub := feed.subscribe()
+	defer sub.Unsubscribe()
+	id := discover.NodeID{1}
+	ev1 := newMsgEventer(rw1, feed, id, "test")
+
+	go Send(ev1, 3, []uint{1, 2})
+	expect := func(typ PeerEventType) {
+		select {
+		case ev := <-sub.Chan():
+			pe := ev.Data.(PeerEvent)
+			if pe.Type != typ || pe.Peer != id || pe.Protocol != "test" || *pe.MsgCode != 3 || *pe.MsgSize != 3 {
+				t.Fatalf("unexpected event: %+v", pe)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %v event", typ)
+		}
+	}
+	if err := ExpectMsg(rw2, 3, []uint{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	expect(PeerEventTypeMsgSend)
+
+	go Send(rw2, 3, []uint{1, 2})
+	go func() {
+		if err := ExpectMsg(ev1, 3, []uint{1, 2}); err != nil {
+			t.Error(err)
+		}
+	}()
+	expect(PeerEventTypeMsgRecv)
+}
+
+// Tests that a subscriber which never reads doesn't block message I/O.
+func TestMsgEventerSlowSubscriber(t *testing.T) {
+	rw1, rw2 := MsgPipe()
+	defer rw1.Close()
+	feed := new(peerFeed)
+	sub := feed.subscribe() // never read
+	defer sub.Unsubscribe()
+	ev1 := newMsgEventer(rw1, feed, discover.NodeID{1}, "test")
+
+	const n = 2 * peerEventBuffer
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := Send(ev1, 1, []uint{uint(i)}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := ExpectMsg(rw2, 1, []uint{uint(i)}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("messaging stalled by slow subscriber")
+	}
+	if len(sub.Chan()) != peerEventBuffer {
+		t.Errorf("got %d buffered events, want %d", len(sub.Chan()), peerEventBuffer)
+	}
+}
+
+// Tests that add and drop events reach a slow subscriber in order.
+func TestPeerFeedSlowSubscriber(t *testing.T) {
+	feed := new(peerFeed)
+	sub := feed.subscribe()
+	defer sub.Unsubscribe()
+
+	code, size := uint64(1), uint32(1)
+	msg := PeerEvent{Type: PeerEventTypeMsgSend, MsgCode: &code, MsgSize: &size}
+	for i := 0; i < peerEventBuffer; i++ {
+		feed.post(msg)
+	}
+	feed.post(PeerEvent{Type: PeerEventTypeAdd, Peer: discover.NodeID{1}})
+	feed.post(msg)
+	feed.post(PeerEvent{Type: PeerEventTypeDrop, Peer: discover.NodeID{1}})
+
+	var types []PeerEventType
+	for len(types) < peerEventBuffer+2 {
+		select {
+		case ev := <-sub.Chan():
+			types = append(types, ev.Data.(PeerEvent).Type)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d events, want %d", len(types), peerEventBuffer+2)
+		}
+	}
+	if types[peerEventBuffer-1] != PeerEventTypeMsgSend || types[peerEventBuffer] != PeerEventTypeAdd || types[peerEventBuffer+1] != PeerEventTypeDrop {
+		t.Fatalf("unexpected events after the buffer: %v", types[peerEventBuffer-1:])
+	}
+	select {
+	case ev := <-sub.Chan():
+		t.Fatalf("unexpected event %v", ev.Data)
+	default:
+	}
+}
+
+// Tests that unsubscribing while add and drop events are queued closes the
+// subscription channel.
+func TestPeerFeedUnsubscribeQueued(t *testing.T) {
+	feed := new(peerFeed)
+	sub := feed.subscribe()
+	for i := 0; i < peerEventBuffer+10; i++ {
+		feed.post(PeerEvent{Type: PeerEventTypeAdd, Peer: discover.NodeID{1}})
+	}
+	sub.Unsubscribe()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("subscription channel not closed")
+		}
+	}
+}
@@ -141,6 +141,9 @@ type extIP net.IP
 func (n extIP) ExternalIP() (net.IP, error) { return net.IP(n), nil }
 func (n extIP) String() string              { return fmt.Sprintf("ExtIP(%v)", net.IP(n)) }
 
+// MarshalText returns the description of the mechanism accepted by Parse.
+func (n extIP) MarshalText() ([]byte, error) { return []byte("extip:" + net.IP(n).String()), nil }
+
 // These do nothing.
 func (extIP) AddMapping(string, int, int, string, time.Duration) error { return nil }
 func (extIP) DeleteMapping(string, int, int) error                     { return nil }
@@ -150,7 +153,7 @@ func (extIP) DeleteMapping(string, int, int) error                     { return
 func Any() Interface {
 	// TODO: attempt to discover whether the local machine has an
 	// Internet-class address. Return ExtIP in this case.
-	return startautodisc("UPnP or NAT-PMP", func() Interface {
+	return startautodisc("UPnP or NAT-PMP", "any", func() Interface {
 		found := make(chan Interface, 2)
 		go func() { found <- discoverUPnP() }()
 		go func() { found <- discoverPMP() }()
@@ -166,7 +169,7 @@ func Any() Interface {
 // UPnP returns a port mapper that uses UPnP. It will attempt to
 // discover the address of your router using UDP broadcasts.
 func UPnP() Interface {
-	return startautodisc("UPnP", discoverUPnP)
+	return startautodisc("UPnP", "upnp", discoverUPnP)
 }
 
 // PMP returns a port mapper that uses NAT-PMP. The provided gateway
@@ -176,7 +179,7 @@ func PMP(gateway net.IP) Interface {
 	if gateway != nil {
 		return &pmp{gw: gateway, c: natpmp.NewClient(gateway)}
 	}
-	return startautodisc("NAT-PMP", discoverPMP)
+	return startautodisc("NAT-PMP", "pmp", discoverPMP)
 }
 
 // autodisc represents a port mapping mechanism that is still being
@@ -188,6 +191,7 @@ func PMP(gateway net.IP) Interface {
 // want return an Interface value from UPnP, PMP and Auto immediately.
 type autodisc struct {
 	what string // type of interface being autodiscovered
+	spec string // description accepted by Parse
 	once sync.Once
 	doit func() Interface
 
@@ -195,9 +199,9 @@ type autodisc struct {
 	found Interface
 }
 
-func startautodisc(what string, doit func() Interface) Interface {
+func startautodisc(what, spec string, doit func() Interface) Interface {
 	// TODO: monitor network configuration and rerun doit when it changes.
-	return &autodisc{what: what, doit: doit}
+	return &autodisc{what: what, spec: spec, doit: doit}
 }
 
 func (n *autodisc) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
@@ -231,6 +235,11 @@ func (n *autodisc) String() string {
 	}
 }
 
+// MarshalText returns the description of the mechanism accepted by Parse.
+func (n *autodisc) MarshalText() ([]byte, error) {
+	return []byte(n.spec), nil
+}
+
 // wait blocks until auto-discovery has been performed.
 func (n *autodisc) wait() error {
 	n.once.Do(func() {
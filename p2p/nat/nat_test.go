@@ -26,7 +26,7 @@ import (
 // consistent results when multiple goroutines call its methods
 // concurrently.
 func TestAutoDiscRace(t *testing.T) {
-	ad := startautodisc("thing", func() Interface {
+	ad := startautodisc("thing", "thing", func() Interface {
 		time.Sleep(500 * time.Millisecond)
 		return extIP{33, 44, 55, 66}
 	})
@@ -61,3 +61,18 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMarshalText(t *testing.T) {
+	for _, spec := range []string{"any", "upnp", "pmp", "pmp:192.168.0.1", "extip:77.12.33.4"} {
+		m, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		text, err := m.(interface {
+			MarshalText() ([]byte, error)
+		}).MarshalText()
+		if err != nil || string(text) != spec {
+			t.Errorf("%s: got %q (error %v)", spec, text, err)
+		}
+	}
+}
@@ -36,6 +36,11 @@ func (n *pmp) String() string {
 	return fmt.Sprintf("NAT-PMP(%v)", n.gw)
 }
 
+// MarshalText returns the description of the mechanism accepted by Parse.
+func (n *pmp) MarshalText() ([]byte, error) {
+	return []byte("pmp:" + n.gw.String()), nil
+}
+
 func (n *pmp) ExternalIP() (net.IP, error) {
 	response, err := n.c.GetExternalAddress()
 	if err != nil {
@@ -99,6 +99,11 @@ func (n *upnp) String() string {
 	return "UPNP " + n.service
 }
 
+// MarshalText returns the description of the mechanism accepted by Parse.
+func (n *upnp) MarshalText() ([]byte, error) {
+	return []byte("upnp"), nil
+}
+
 // discoverUPnP searches for Internet Gateway Devices
 // and returns the first one it can find on the local network.
 func discoverUPnP() Interface {
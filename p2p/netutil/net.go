@@ -18,6 +18,7 @@
 package netutil
 
 import (
+	"encoding/json"
 	"errors"
 	"net"
 	"strings"
@@ -84,6 +85,29 @@ func ParseNetlist(s string) (*Netlist, error) {
 	return &l, nil
 }
 
+// MarshalJSON encodes the list as an array of CIDR masks.
+func (l Netlist) MarshalJSON() ([]byte, error) {
+	masks := make([]string, len(l))
+	for i, n := range l {
+		masks[i] = n.String()
+	}
+	return json.Marshal(masks)
+}
+
+// UnmarshalJSON decodes an array of CIDR masks.
+func (l *Netlist) UnmarshalJSON(input []byte) error {
+	var masks []string
+	if err := json.Unmarshal(input, &masks); err != nil {
+		return err
+	}
+	list, err := ParseNetlist(strings.Join(masks, ","))
+	if err != nil {
+		return err
+	}
+	*l = *list
+	return nil
+}
+
 // Add parses a CIDR mask and appends it to the list. It panics for invalid masks and is
 // intended to be used for setting up static lists.
 func (l *Netlist) Add(cidr string) {
@@ -0,0 +1,143 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// peerEventBuffer is the number of events buffered per subscriber.
+const peerEventBuffer = 256
+
+// peerFeed delivers peer events to subscribers without blocking the sender, so
+// that slow subscribers can't stall peer connections. Message events are
+// dropped for subscribers whose buffer is full, add and drop events are queued
+// until the subscriber catches up.
+type peerFeed struct {
+	mu   sync.Mutex
+	subs map[*peerFeedSub]struct{}
+}
+
+type peerFeedSub struct {
+	feed    *peerFeed
+	ch      chan *event.Event
+	dropped int
+
+	// guarded by feed.mu
+	queue    []*event.Event // add and drop events that didn't fit into ch
+	flushing bool           // a flush goroutine delivers the queue
+	closed   bool
+	quit     chan struct{}
+}
+
+// subscribe creates a subscription receiving the events posted from now on.
+func (f *peerFeed) subscribe() *peerFeedSub {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[*peerFeedSub]struct{})
+	}
+	sub := &peerFeedSub{
+		feed: f,
+		ch:   make(chan *event.Event, peerEventBuffer),
+		quit: make(chan struct{}),
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// post delivers ev to all subscribers.
+func (f *peerFeed) post(ev PeerEvent) {
+	e := &event.Event{Time: time.Now(), Data: ev}
+	conn := ev.Type == PeerEventTypeAdd || ev.Type == PeerEventTypeDrop
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		// queued events go first to keep the order
+		if len(sub.queue) == 0 {
+			select {
+			case sub.ch <- e:
+				continue
+			default:
+			}
+		}
+		if !conn {
+			if sub.dropped++; sub.dropped == 1 {
+				glog.V(logger.Debug).Infof("peer event subscriber is slow, dropping message events")
+			}
+			continue
+		}
+		sub.queue = append(sub.queue, e)
+		if !sub.flushing {
+			sub.flushing = true
+			go sub.flush()
+		}
+	}
+}
+
+// flush delivers the queued events until the queue is empty or the
+// subscription ends.
+func (s *peerFeedSub) flush() {
+	for {
+		s.feed.mu.Lock()
+		if s.closed || len(s.queue) == 0 {
+			if s.closed {
+				close(s.ch)
+			}
+			s.flushing = false
+			s.feed.mu.Unlock()
+			return
+		}
+		e := s.queue[0]
+		s.feed.mu.Unlock()
+
+		select {
+		case s.ch <- e:
+			s.feed.mu.Lock()
+			if !s.closed {
+				s.queue = s.queue[1:]
+			}
+			s.feed.mu.Unlock()
+		case <-s.quit:
+		}
+	}
+}
+
+func (s *peerFeedSub) Chan() <-chan *event.Event {
+	return s.ch
+}
+
+func (s *peerFeedSub) Unsubscribe() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	if s.closed {
+		return
+	}
+	delete(s.feed.subs, s)
+	s.closed = true
+	s.queue = nil
+	close(s.quit)
+	// a running flush closes ch once it stops sending
+	if !s.flushing {
+		close(s.ch)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	mrand "math/rand"
 	"net"
 	"sync"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
 )
 
 const (
@@ -127,6 +129,12 @@ func (t *rlpx) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err
 	if err := <-werr; err != nil {
 		return nil, fmt.Errorf("write error: %v", err)
 	}
+	// if both sides support it, all further messages are compressed
+	t.rmu.Lock()
+	t.wmu.Lock()
+	t.rw.snappy = our.Version >= snappyProtocolVersion && their.Version >= snappyProtocolVersion
+	t.wmu.Unlock()
+	t.rmu.Unlock()
 	return their, nil
 }
 
@@ -556,6 +564,8 @@ type rlpxFrameRW struct {
 	macCipher  cipher.Block
 	egressMAC  hash.Hash
 	ingressMAC hash.Hash
+
+	snappy bool // message payloads are snappy compressed
 }
 
 func newRLPXFrameRW(conn io.ReadWriter, s secrets) *rlpxFrameRW {
@@ -583,6 +593,20 @@ func newRLPXFrameRW(conn io.ReadWriter, s secrets) *rlpxFrameRW {
 func (rw *rlpxFrameRW) WriteMsg(msg Msg) error {
 	ptype, _ := rlp.EncodeToBytes(msg.Code)
 
+	// compress the payload if negotiated
+	if rw.snappy {
+		if msg.Size > maxUint24 {
+			return errors.New("message size overflows uint24")
+		}
+		payload, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			return err
+		}
+		payload = snappy.Encode(nil, payload)
+		msg.Size = uint32(len(payload))
+		msg.Payload = bytes.NewReader(payload)
+	}
+
 	// write header
 	headbuf := make([]byte, 32)
 	fsize := uint32(len(ptype)) + msg.Size
@@ -668,6 +692,25 @@ func (rw *rlpxFrameRW) ReadMsg() (msg Msg, err error) {
 	}
 	msg.Size = uint32(content.Len())
 	msg.Payload = content
+
+	// decompress the payload if negotiated, checking the announced size
+	// before allocating to protect against compression bombs
+	if rw.snappy {
+		payload, _ := ioutil.ReadAll(msg.Payload)
+		size, err := snappy.DecodedLen(payload)
+		if err != nil {
+			return msg, err
+		}
+		if size > int(maxUint24) {
+			return msg, errors.New("message size overflows uint24")
+		}
+		payload, err = snappy.Decode(nil, payload)
+		if err != nil {
+			return msg, err
+		}
+		msg.Size = uint32(size)
+		msg.Payload = bytes.NewReader(payload)
+	}
 	return msg, nil
 }
 
@@ -19,6 +19,7 @@ package p2p
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -374,6 +375,117 @@ func TestRLPXFrameRW(t *testing.T) {
 	}
 }
 
+func newTestFrameRWs(conn io.ReadWriter) (*rlpxFrameRW, *rlpxFrameRW) {
+	var (
+		aesSecret      = make([]byte, 16)
+		macSecret      = make([]byte, 16)
+		egressMACinit  = make([]byte, 32)
+		ingressMACinit = make([]byte, 32)
+	)
+	for _, s := range [][]byte{aesSecret, macSecret, egressMACinit, ingressMACinit} {
+		rand.Read(s)
+	}
+	s1 := secrets{
+		AES:        aesSecret,
+		MAC:        macSecret,
+		EgressMAC:  sha3.NewKeccak256(),
+		IngressMAC: sha3.NewKeccak256(),
+	}
+	s1.EgressMAC.Write(egressMACinit)
+	s1.IngressMAC.Write(ingressMACinit)
+	s2 := secrets{
+		AES:        aesSecret,
+		MAC:        macSecret,
+		EgressMAC:  sha3.NewKeccak256(),
+		IngressMAC: sha3.NewKeccak256(),
+	}
+	s2.EgressMAC.Write(ingressMACinit)
+	s2.IngressMAC.Write(egressMACinit)
+	return newRLPXFrameRW(conn, s1), newRLPXFrameRW(conn, s2)
+}
+
+func TestRLPXFrameSnappy(t *testing.T) {
+	conn := new(bytes.Buffer)
+	rw1, rw2 := newTestFrameRWs(conn)
+	rw1.snappy, rw2.snappy = true, true
+
+	wmsg := []interface{}{"foo", strings.Repeat("test", 1000)}
+	if err := Send(rw1, 3, wmsg); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	wantPayload, _ := rlp.EncodeToBytes(wmsg)
+	if conn.Len() >= len(wantPayload) {
+		t.Fatalf("payload not compressed: frame size %d, payload size %d", conn.Len(), len(wantPayload))
+	}
+	msg, err := rw2.ReadMsg()
+	if err != nil {
+		t.Fatalf("ReadMsg error: %v", err)
+	}
+	if msg.Code != 3 || msg.Size != uint32(len(wantPayload)) {
+		t.Fatalf("msg mismatch: got code %d size %d, want code 3 size %d", msg.Code, msg.Size, len(wantPayload))
+	}
+	payload, _ := ioutil.ReadAll(msg.Payload)
+	if !bytes.Equal(payload, wantPayload) {
+		t.Fatalf("msg payload mismatch:\ngot  %x\nwant %x", payload, wantPayload)
+	}
+}
+
+func TestRLPXFrameSnappyBomb(t *testing.T) {
+	conn := new(bytes.Buffer)
+	rw1, rw2 := newTestFrameRWs(conn)
+	rw2.snappy = true
+
+	// snappy block announcing a decoded length beyond the message size limit
+	bomb := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(bomb, uint64(maxUint24)+1)
+	if err := rw1.WriteMsg(Msg{Code: 3, Size: uint32(n), Payload: bytes.NewReader(bomb[:n])}); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if _, err := rw2.ReadMsg(); err == nil {
+		t.Fatalf("oversized message accepted")
+	}
+}
+
+func TestProtocolHandshakeSnappy(t *testing.T) {
+	tests := []struct {
+		ourVersion, theirVersion uint64
+		snappy                   bool
+	}{
+		{baseProtocolVersion, baseProtocolVersion, true},
+		{baseProtocolVersion, 4, false},
+		{4, baseProtocolVersion, false},
+	}
+	for i, test := range tests {
+		fd0, fd1 := net.Pipe()
+		rlpx0, rlpx1 := &rlpx{fd: fd0}, &rlpx{fd: fd1}
+		rlpx0.rw, rlpx1.rw = newTestFrameRWs(nil)
+		rlpx0.rw.conn, rlpx1.rw.conn = fd0, fd1
+
+		id0, id1 := randomID(), randomID()
+		errc := make(chan error, 1)
+		go func() {
+			_, err := rlpx1.doProtoHandshake(&protoHandshake{Version: test.theirVersion, ID: id1})
+			errc <- err
+		}()
+		if _, err := rlpx0.doProtoHandshake(&protoHandshake{Version: test.ourVersion, ID: id0}); err != nil {
+			t.Fatalf("test %d: handshake error: %v", i, err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("test %d: remote handshake error: %v", i, err)
+		}
+		if rlpx0.rw.snappy != test.snappy || rlpx1.rw.snappy != test.snappy {
+			t.Errorf("test %d: snappy mismatch: got %v/%v, want %v", i, rlpx0.rw.snappy, rlpx1.rw.snappy, test.snappy)
+		}
+		// messages still pass after the upgrade
+		go Send(rlpx1, 0x10, []uint{1, 2, 3})
+		if err := ExpectMsg(rlpx0, 0x10, []uint{1, 2, 3}); err != nil {
+			t.Errorf("test %d: %v", i, err)
+		}
+		fd0.Close()
+		fd1.Close()
+	}
+}
+
 type handshakeAuthTest struct {
 	input       string
 	isPlain     bool
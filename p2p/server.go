@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p/discover"
@@ -109,6 +110,14 @@ type Config struct {
 	// live nodes in the network.
 	NodeDatabase string
 
+	// NodeDatabaseBackend is the database backend of the node database and
+	// the registry database, the default backend is used if empty.
+	NodeDatabaseBackend string
+
+	// RegistryDatabase is the path to the database containing the banned
+	// and trusted nodes. It is kept in memory if empty.
+	RegistryDatabase string
+
 	// Protocols should contain the protocols supported
 	// by the server. Matching protocols are launched for
 	// each peer.
@@ -122,6 +131,11 @@ type Config struct {
 	// the server is started.
 	ListenAddr string
 
+	// If Listener is set to a non-nil value, inbound connections are
+	// accepted from it instead of listening on ListenAddr. This is
+	// used to run servers on in-memory connections.
+	Listener net.Listener
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -129,10 +143,14 @@ type Config struct {
 
 	// If Dialer is set to a non-nil value, the given Dialer
 	// is used to dial outbound peer connections.
-	Dialer *net.Dialer
+	Dialer NodeDialer
 
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool
+
+	// If EnableMsgEvents is set, the server emits a PeerEvent for every message
+	// sent to or received from a peer.
+	EnableMsgEvents bool
 }
 
 // Server manages all peer connections.
@@ -149,6 +167,7 @@ type Server struct {
 	running bool
 
 	ntab         discoverTable
+	registry     *discover.Registry
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
@@ -165,6 +184,7 @@ type Server struct {
 	addpeer       chan *conn
 	delpeer       chan *Peer
 	loopWG        sync.WaitGroup // loop, listenLoop
+	peerFeed      peerFeed       // PeerEvent of connected peers
 }
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
@@ -284,6 +304,84 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// nodeRegistry returns the registry of banned and trusted nodes or nil
+// if the server is not running.
+func (srv *Server) nodeRegistry() *discover.Registry {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if !srv.running {
+		return nil
+	}
+	return srv.registry
+}
+
+// AddBan bans a node or network and disconnects the connected peers
+// matching the ban. Bans are kept in the registry database.
+func (srv *Server) AddBan(ban *discover.Ban) error {
+	registry := srv.nodeRegistry()
+	if registry == nil {
+		return errServerStopped
+	}
+	if err := registry.Ban(ban); err != nil {
+		return err
+	}
+	for _, p := range srv.Peers() {
+		if ban.Matches(p.ID(), remoteIP(p.rw.fd)) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+	return nil
+}
+
+// RemoveBan lifts the ban of the node or network of the given ban and
+// reports whether it was banned.
+func (srv *Server) RemoveBan(ban *discover.Ban) (bool, error) {
+	registry := srv.nodeRegistry()
+	if registry == nil {
+		return false, errServerStopped
+	}
+	return registry.Unban(ban)
+}
+
+// Bans returns the bans in effect.
+func (srv *Server) Bans() []*discover.Ban {
+	registry := srv.nodeRegistry()
+	if registry == nil {
+		return nil
+	}
+	return registry.Bans()
+}
+
+// AddTrustedPeer marks a node as trusted, allowing it to connect even if
+// the server is at its peer limit. The node is kept in the registry database.
+func (srv *Server) AddTrustedPeer(node *discover.Node) error {
+	registry := srv.nodeRegistry()
+	if registry == nil {
+		return errServerStopped
+	}
+	return registry.AddTrusted(node)
+}
+
+// RemoveTrustedPeer removes the trusted mark of a node added with
+// AddTrustedPeer and reports whether it was set. Nodes configured in
+// TrustedNodes cannot be removed.
+func (srv *Server) RemoveTrustedPeer(node *discover.Node) (bool, error) {
+	registry := srv.nodeRegistry()
+	if registry == nil {
+		return false, errServerStopped
+	}
+	return registry.RemoveTrusted(node.ID)
+}
+
+// TrustedPeers returns the configured and the dynamically trusted nodes.
+func (srv *Server) TrustedPeers() []*discover.Node {
+	nodes := append([]*discover.Node{}, srv.TrustedNodes...)
+	if registry := srv.nodeRegistry(); registry != nil {
+		nodes = append(nodes, registry.Trusted()...)
+	}
+	return nodes
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *discover.Node {
 	srv.lock.Lock()
@@ -295,12 +393,12 @@ func (srv *Server) Self() *discover.Node {
 	}
 	// If the node is running but discovery is off, manually assemble the node infos
 	if srv.ntab == nil {
-		// Inbound connections disabled, use zero address
-		if srv.listener == nil {
+		// Inbound connections disabled or not on TCP, use zero address
+		addr, ok := srv.listenAddr()
+		if !ok {
 			return &discover.Node{IP: net.ParseIP("0.0.0.0"), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
 		}
 		// Otherwise inject the listener address too
-		addr := srv.listener.Addr().(*net.TCPAddr)
 		return &discover.Node{
 			ID:  discover.PubkeyID(&srv.PrivateKey.PublicKey),
 			IP:  addr.IP,
@@ -311,6 +409,15 @@ func (srv *Server) Self() *discover.Node {
 	return srv.ntab.Self()
 }
 
+// listenAddr returns the TCP address of the listener, if any.
+func (srv *Server) listenAddr() (*net.TCPAddr, bool) {
+	if srv.listener == nil {
+		return nil, false
+	}
+	addr, ok := srv.listener.Addr().(*net.TCPAddr)
+	return addr, ok
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -347,7 +454,7 @@ func (srv *Server) Start() (err error) {
 		srv.newTransport = newRLPX
 	}
 	if srv.Dialer == nil {
-		srv.Dialer = &net.Dialer{Timeout: defaultDialTimeout}
+		srv.Dialer = TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
 	srv.quit = make(chan struct{})
 	srv.addpeer = make(chan *conn)
@@ -358,13 +465,22 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	// banned and trusted nodes
+	registry, err := discover.OpenRegistry(srv.NodeDatabaseBackend, srv.RegistryDatabase)
+	if err != nil {
+		return err
+	}
+	srv.registry = registry
+
 	// node table
 	if srv.Discovery {
-		ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT, srv.NodeDatabase, srv.NetRestrict)
+		ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT, srv.NodeDatabaseBackend, srv.NodeDatabase, srv.registry, srv.NetRestrict)
 		if err != nil {
+			srv.registry.Close()
 			return err
 		}
 		if err := ntab.SetFallbackNodes(srv.BootstrapNodes); err != nil {
+			srv.registry.Close()
 			return err
 		}
 		srv.ntab = ntab
@@ -378,6 +494,10 @@ func (srv *Server) Start() (err error) {
 		if err := ntab.SetFallbackNodes(srv.BootstrapNodesV5); err != nil {
 			return err
 		}
+		registry := srv.registry
+		ntab.SetBanFilter(func(id discv5.NodeID, ip net.IP) bool {
+			return registry.Banned(discover.NodeID(id), ip)
+		})
 		srv.DiscV5 = ntab
 	}
 
@@ -385,7 +505,7 @@ func (srv *Server) Start() (err error) {
 	if !srv.Discovery {
 		dynPeers = 0
 	}
-	dialer := newDialState(srv.StaticNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer := newDialState(srv.StaticNodes, srv.ntab, dynPeers, srv.NetRestrict, srv.registry)
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -393,12 +513,16 @@ func (srv *Server) Start() (err error) {
 		srv.ourHandshake.Caps = append(srv.ourHandshake.Caps, p.cap())
 	}
 	// listen/dial
-	if srv.ListenAddr != "" {
+	if srv.Listener != nil {
+		srv.listener = srv.Listener
+		srv.loopWG.Add(1)
+		go srv.listenLoop()
+	} else if srv.ListenAddr != "" {
 		if err := srv.startListening(); err != nil {
 			return err
 		}
 	}
-	if srv.NoDial && srv.ListenAddr == "" {
+	if srv.NoDial && srv.ListenAddr == "" && srv.Listener == nil {
 		glog.V(logger.Warn).Infoln("I will be kind-of useless, neither dialing nor listening.")
 	}
 
@@ -447,8 +571,8 @@ func (srv *Server) run(dialstate dialer) {
 		queuedTasks  []task // tasks that can't run yet
 	)
 	// Put trusted nodes into a map to speed up checks.
-	// Trusted peers are loaded on startup and cannot be
-	// modified while the server is running.
+	// Configured trusted peers are loaded on startup, those
+	// added while the server is running are kept in the registry.
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID] = true
 	}
@@ -521,7 +645,7 @@ running:
 		case c := <-srv.posthandshake:
 			// A connection has passed the encryption handshake so
 			// the remote identity is known (but hasn't been verified yet).
-			if trusted[c.id] {
+			if trusted[c.id] || srv.registry.IsTrusted(c.id) {
 				// Ensure that the trusted flag is set before checking against MaxPeers.
 				c.flags |= trustedConn
 			}
@@ -538,6 +662,9 @@ running:
 			} else {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				if srv.EnableMsgEvents {
+					p.events = &srv.peerFeed
+				}
 				peers[c.id] = p
 				go srv.runPeer(p)
 			}
@@ -559,6 +686,7 @@ running:
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
+	srv.registry.Close()
 	// Disconnect all peers.
 	for _, p := range peers {
 		p.Disconnect(DiscQuitting)
@@ -586,6 +714,8 @@ func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn
 
 func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
 	switch {
+	case srv.registry.Banned(c.id, remoteIP(c.fd)):
+		return DiscUselessPeer
 	case !c.is(trustedConn|staticDialedConn) && len(peers) >= srv.MaxPeers:
 		return DiscTooManyPeers
 	case peers[c.id] != nil:
@@ -649,6 +779,14 @@ func (srv *Server) listenLoop() {
 			}
 		}
 
+		// Reject banned networks before running the handshake.
+		if ip := remoteIP(fd); ip != nil && srv.registry.Banned(discover.NodeID{}, ip) {
+			glog.V(logger.Debug).Infof("Rejected conn %v because it is banned", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
+
 		fd = newMeteredConn(fd, true)
 		glog.V(logger.Debug).Infof("Accepted conn %v", fd.RemoteAddr())
 
@@ -661,6 +799,15 @@ func (srv *Server) listenLoop() {
 	}
 }
 
+// remoteIP returns the IP address of the remote end of a connection
+// or nil if it is not a TCP connection.
+func remoteIP(fd net.Conn) net.IP {
+	if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	return nil
+}
+
 // setupConn runs the handshakes and attempts to add the connection
 // as a peer. It returns when the connection has been added as a peer
 // or the handshakes have failed.
@@ -739,14 +886,31 @@ func (srv *Server) runPeer(p *Peer) {
 	if srv.newPeerHook != nil {
 		srv.newPeerHook(p)
 	}
+	srv.peerFeed.post(PeerEvent{
+		Type: PeerEventTypeAdd,
+		Peer: p.ID(),
+	})
 	discreason := p.run()
 	// Note: run waits for existing peers to be sent on srv.delpeer
 	// before returning, so this send should not select on srv.quit.
 	srv.delpeer <- p
+	srv.peerFeed.post(PeerEvent{
+		Type:  PeerEventTypeDrop,
+		Peer:  p.ID(),
+		Error: discreason.Error(),
+	})
 
 	glog.V(logger.Debug).Infof("Removed %v (%v)\n", p, discreason)
 }
 
+// SubscribeEvents subscribes the given channel to peer events. The event
+// data of the subscription is a PeerEvent. Events are buffered per subscription,
+// message events are dropped when the buffer is full, so subscribers must keep
+// up. Add and drop events are never dropped.
+func (srv *Server) SubscribeEvents() event.Subscription {
+	return srv.peerFeed.subscribe()
+}
+
 // NodeInfo represents a short summary of the information known about the host.
 type NodeInfo struct {
 	ID    string `json:"id"`    // Unique node identifier (also the encryption key)
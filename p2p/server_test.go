@@ -481,6 +481,69 @@ func (c *setupTransport) ReadMsg() (Msg, error) {
 	panic("ReadMsg called on setupTransport")
 }
 
+func TestServerBanAndTrust(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			MaxPeers:   1,
+			NoDial:     true,
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id discover.NodeID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(id, fd)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, id: id, cont: make(chan error)}
+	}
+
+	// Ban a connected peer, it should be dropped and not be let back in.
+	bannedID := randomID()
+	c := newconn(bannedID)
+	if err := srv.checkpoint(c, srv.addpeer); err != nil {
+		t.Fatalf("could not add conn: %v", err)
+	}
+	if err := srv.AddBan(&discover.Ban{ID: bannedID}); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); srv.PeerCount() > 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("banned peer not dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := srv.checkpoint(newconn(bannedID), srv.posthandshake); err != DiscUselessPeer {
+		t.Errorf("wrong error for banned conn: %v", err)
+	}
+	if ok, err := srv.RemoveBan(&discover.Ban{ID: bannedID}); !ok || err != nil {
+		t.Errorf("remove ban: got %v, %v", ok, err)
+	}
+	if err := srv.checkpoint(newconn(bannedID), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for unbanned conn: %v", err)
+	}
+
+	// Fill the peer set, then let a runtime trusted peer in.
+	if err := srv.checkpoint(newconn(randomID()), srv.addpeer); err != nil {
+		t.Fatalf("could not add conn: %v", err)
+	}
+	trusted := &discover.Node{ID: randomID()}
+	if err := srv.checkpoint(newconn(trusted.ID), srv.posthandshake); err != DiscTooManyPeers {
+		t.Errorf("wrong error for untrusted conn: %v", err)
+	}
+	if err := srv.AddTrustedPeer(trusted); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.checkpoint(newconn(trusted.ID), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for trusted conn: %v", err)
+	}
+	if peers := srv.TrustedPeers(); len(peers) != 1 || peers[0].ID != trusted.ID {
+		t.Errorf("wrong trusted peers: %v", peers)
+	}
+}
+
 func newkey() *ecdsa.PrivateKey {
 	key, err := crypto.GenerateKey()
 	if err != nil {
@@ -495,3 +558,57 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+func TestServerPeerEvents(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not setup listener: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Error("accept error:", err)
+			return
+		}
+		accepted <- conn
+	}()
+
+	remid := randomID()
+	srv := startTestServer(t, remid, nil)
+	defer srv.Stop()
+	sub := srv.SubscribeEvents()
+	defer sub.Unsubscribe()
+	// A subscriber which never reads must not hold up the others.
+	slow := srv.SubscribeEvents()
+	defer slow.Unsubscribe()
+
+	tcpAddr := listener.Addr().(*net.TCPAddr)
+	srv.AddPeer(&discover.Node{ID: remid, IP: tcpAddr.IP, TCP: uint16(tcpAddr.Port)})
+
+	expect := func(typ PeerEventType) PeerEvent {
+		select {
+		case ev := <-sub.Chan():
+			pe := ev.Data.(PeerEvent)
+			if pe.Type != typ || pe.Peer != remid {
+				t.Fatalf("unexpected event: got %v for %v, want %v for %v", pe.Type, pe.Peer, typ, remid)
+			}
+			return pe
+		case <-time.After(1 * time.Second):
+			t.Fatalf("no %v event within one second", typ)
+		}
+		return PeerEvent{}
+	}
+	var conn net.Conn
+	select {
+	case conn = <-accepted:
+	case <-time.After(1 * time.Second):
+		t.Fatal("server did not connect within one second")
+	}
+	expect(PeerEventTypeAdd)
+	conn.Close()
+	if ev := expect(PeerEventTypeDrop); ev.Error == "" {
+		t.Errorf("drop event without reason")
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulations runs networks of p2p nodes connected by in-memory
+// pipes instead of TCP, so protocols can be tested against many peers within
+// a single process.
+package simulations
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+var (
+	errUnknownNode = errors.New("unknown node")
+	errNodeDown    = errors.New("node is down")
+)
+
+// Event types posted on the network event mux
+const (
+	EventTypeNode = "node"
+	EventTypeConn = "conn"
+	EventTypeMsg  = "msg"
+)
+
+// Event is posted whenever a node is started or stopped, a connection is
+// established or dropped or a protocol message is sent or received.
+type Event struct {
+	Type string          `json:"type"`
+	Node discover.NodeID `json:"node"`
+
+	// Up is set for node events if the node was started and for conn
+	// events if the connection was established
+	Up bool `json:"up"`
+
+	// Peer is the underlying p2p event for conn and msg events
+	Peer *p2p.PeerEvent `json:"peer,omitempty"`
+}
+
+// Network is a simulated network of nodes running the same set of services.
+// It dials peer connections for its nodes over in-memory pipes.
+type Network struct {
+	services []node.ServiceConstructor
+
+	lock  sync.RWMutex
+	nodes map[discover.NodeID]*Node
+	order []discover.NodeID
+	conns map[connID]bool
+
+	events event.TypeMux
+}
+
+// connID identifies a connection regardless of its direction
+type connID [2]discover.NodeID
+
+func newConnID(one, other discover.NodeID) connID {
+	for i := range one {
+		if one[i] != other[i] {
+			if one[i] > other[i] {
+				one, other = other, one
+			}
+			break
+		}
+	}
+	return connID{one, other}
+}
+
+// NewNetwork creates an empty network; each node added to it will run the
+// services created by the given constructors.
+func NewNetwork(services ...node.ServiceConstructor) *Network {
+	return &Network{
+		services: services,
+		nodes:    make(map[discover.NodeID]*Node),
+		conns:    make(map[connID]bool),
+	}
+}
+
+// Node is a member of the simulated network
+type Node struct {
+	ID  discover.NodeID
+	key *ecdsa.PrivateKey
+
+	net      *Network
+	node     *node.Node
+	listener *pipeListener
+	sub      event.Subscription
+	done     chan struct{}
+}
+
+// Up tells if the node is running
+func (self *Node) Up() bool {
+	return self.node != nil
+}
+
+// Node returns the running node stack or nil if the node is down
+func (self *Node) Node() *node.Node {
+	return self.node
+}
+
+// String returns the abbreviated node ID
+func (self *Node) String() string {
+	return fmt.Sprintf("%x", self.ID[:4])
+}
+
+// addr is the (fake) endpoint the node is dialed at by its peers
+func (self *Node) addr() *discover.Node {
+	return discover.NewNode(self.ID, net.IP{127, 0, 0, 1}, 30303, 30303)
+}
+
+// NewNode adds a new node with a random key to the network. The node is
+// not started.
+func (self *Network) NewNode() (*Node, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return self.addNode(key)
+}
+
+func (self *Network) addNode(key *ecdsa.PrivateKey) (*Node, error) {
+	id := discover.PubkeyID(&key.PublicKey)
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, ok := self.nodes[id]; ok {
+		return nil, fmt.Errorf("node %x already exists", id[:4])
+	}
+	n := &Node{ID: id, key: key, net: self}
+	self.nodes[id] = n
+	self.order = append(self.order, id)
+	return n, nil
+}
+
+// GetNode returns the node with the given ID or nil if it is not in the
+// network
+func (self *Network) GetNode(id discover.NodeID) *Node {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.nodes[id]
+}
+
+// Nodes returns all nodes in the order they were added
+func (self *Network) Nodes() []*Node {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	nodes := make([]*Node, len(self.order))
+	for i, id := range self.order {
+		nodes[i] = self.nodes[id]
+	}
+	return nodes
+}
+
+// Start starts the node with the given ID
+func (self *Network) Start(id discover.NodeID) error {
+	self.lock.Lock()
+	n, ok := self.nodes[id]
+	if !ok {
+		self.lock.Unlock()
+		return errUnknownNode
+	}
+	if n.node != nil {
+		self.lock.Unlock()
+		return node.ErrNodeRunning
+	}
+	listener := newPipeListener(n.String())
+	stack, err := node.New(&node.Config{
+		Name:            n.String(),
+		PrivateKey:      n.key,
+		NoDiscovery:     true,
+		MaxPeers:        len(self.nodes),
+		Dialer:          self,
+		Listener:        listener,
+		EnableMsgEvents: true,
+	})
+	if err == nil {
+		for _, service := range self.services {
+			if err = stack.Register(service); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = stack.Start()
+	}
+	if err != nil {
+		self.lock.Unlock()
+		return err
+	}
+	n.node = stack
+	n.listener = listener
+	n.sub = stack.Server().SubscribeEvents()
+	n.done = make(chan struct{})
+	self.lock.Unlock()
+
+	glog.V(logger.Debug).Infof("simulation: started node %v", n)
+	self.events.Post(&Event{Type: EventTypeNode, Node: id, Up: true})
+	// peers added before the subscription have no event
+	for _, p := range stack.Server().Peers() {
+		self.connChanged(id, p.ID(), true)
+	}
+	go self.watchPeerEvents(n, n.sub, n.done)
+	return nil
+}
+
+// Stop stops the node with the given ID
+func (self *Network) Stop(id discover.NodeID) error {
+	self.lock.Lock()
+	n, ok := self.nodes[id]
+	if !ok {
+		self.lock.Unlock()
+		return errUnknownNode
+	}
+	if n.node == nil {
+		self.lock.Unlock()
+		return node.ErrNodeStopped
+	}
+	stack, sub, done := n.node, n.sub, n.done
+	n.node, n.listener, n.sub, n.done = nil, nil, nil, nil
+	self.lock.Unlock()
+
+	err := stack.Stop()
+	// drop events are posted while the server stops, those still queued
+	// when the subscription is closed are handled below
+	sub.Unsubscribe()
+	<-done
+	self.lock.RLock()
+	var peers []discover.NodeID
+	for c := range self.conns {
+		if c[0] == id {
+			peers = append(peers, c[1])
+		} else if c[1] == id {
+			peers = append(peers, c[0])
+		}
+	}
+	self.lock.RUnlock()
+	for _, peer := range peers {
+		self.connChanged(id, peer, false)
+	}
+	glog.V(logger.Debug).Infof("simulation: stopped node %v", n)
+	self.events.Post(&Event{Type: EventTypeNode, Node: id, Up: false})
+	return err
+}
+
+// watchPeerEvents translates peer events of a node into network events
+func (self *Network) watchPeerEvents(n *Node, sub event.Subscription, done chan struct{}) {
+	defer close(done)
+	for ev := range sub.Chan() {
+		pev, ok := ev.Data.(p2p.PeerEvent)
+		if !ok {
+			continue
+		}
+		e := &Event{Node: n.ID, Peer: &pev}
+		switch pev.Type {
+		case p2p.PeerEventTypeAdd, p2p.PeerEventTypeDrop:
+			e.Type = EventTypeConn
+			e.Up = pev.Type == p2p.PeerEventTypeAdd
+			if !self.setConn(n.ID, pev.Peer, e.Up) {
+				// the other end already reported the change
+				continue
+			}
+		default:
+			e.Type = EventTypeMsg
+		}
+		self.events.Post(e)
+	}
+}
+
+// connChanged records a connection change which was not reported by a peer
+// event and posts it
+func (self *Network) connChanged(one, other discover.NodeID, up bool) {
+	if !self.setConn(one, other, up) {
+		return
+	}
+	typ := p2p.PeerEventTypeDrop
+	if up {
+		typ = p2p.PeerEventTypeAdd
+	}
+	self.events.Post(&Event{Type: EventTypeConn, Node: one, Up: up, Peer: &p2p.PeerEvent{Type: typ, Peer: other}})
+}
+
+// setConn records the state of a connection and reports whether it changed
+func (self *Network) setConn(one, other discover.NodeID, up bool) bool {
+	id := newConnID(one, other)
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.conns[id] == up {
+		return false
+	}
+	if up {
+		self.conns[id] = true
+	} else {
+		delete(self.conns, id)
+	}
+	return true
+}
+
+// Dial implements p2p.NodeDialer, connecting to the listener of the
+// destination node
+func (self *Network) Dial(dest *discover.Node) (net.Conn, error) {
+	self.lock.RLock()
+	n, ok := self.nodes[dest.ID]
+	var listener *pipeListener
+	if ok {
+		listener = n.listener
+	}
+	self.lock.RUnlock()
+	if !ok {
+		return nil, errUnknownNode
+	}
+	if listener == nil {
+		return nil, errNodeDown
+	}
+	return listener.connect()
+}
+
+// Connect makes the first node dial the other one. The connection is
+// established asynchronously, the conn event signals when it is up.
+func (self *Network) Connect(one, other discover.NodeID) error {
+	n, peer, err := self.running(one, other)
+	if err != nil {
+		return err
+	}
+	n.Server().AddPeer(peer.addr())
+	return nil
+}
+
+// Disconnect drops the connection between the two nodes
+func (self *Network) Disconnect(one, other discover.NodeID) error {
+	n, peer, err := self.running(one, other)
+	if err != nil {
+		return err
+	}
+	n.Server().RemovePeer(peer.addr())
+	if other := peer.Node(); other != nil {
+		// the connection might have been dialed by the other end
+		other.Server().RemovePeer(self.GetNode(one).addr())
+	}
+	return nil
+}
+
+// running returns the stack of the first node and the other node, both of
+// which have to be up
+func (self *Network) running(one, other discover.NodeID) (*node.Node, *Node, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	n, ok := self.nodes[one]
+	peer, ok2 := self.nodes[other]
+	if !ok || !ok2 {
+		return nil, nil, errUnknownNode
+	}
+	if n.node == nil || peer.node == nil {
+		return nil, nil, errNodeDown
+	}
+	return n.node, peer, nil
+}
+
+// Connected tells if the two nodes are connected
+func (self *Network) Connected(one, other discover.NodeID) bool {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.conns[newConnID(one, other)]
+}
+
+// Events subscribes to network events, the subscriber has to keep reading
+// the channel for the simulation to proceed
+func (self *Network) Events() event.Subscription {
+	return self.events.Subscribe(&Event{})
+}
+
+// Shutdown stops all running nodes and the event mux
+func (self *Network) Shutdown() {
+	for _, n := range self.Nodes() {
+		if n.Up() {
+			if err := self.Stop(n.ID); err != nil {
+				glog.V(logger.Warn).Infof("simulation: error stopping node %v: %v", n, err)
+			}
+		}
+	}
+	self.events.Stop()
+}
+
+// NodeSnapshot is the saved state of a node
+type NodeSnapshot struct {
+	Key string `json:"key"`
+	Up  bool   `json:"up"`
+}
+
+// ConnSnapshot is a saved connection between two nodes
+type ConnSnapshot struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+// Snapshot is the saved state of a network which can be loaded into a new
+// one to rerun a simulation from the same topology
+type Snapshot struct {
+	Nodes []NodeSnapshot `json:"nodes"`
+	Conns []ConnSnapshot `json:"conns"`
+}
+
+// Snapshot returns the nodes and live connections of the network
+func (self *Network) Snapshot() *Snapshot {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	snap := &Snapshot{}
+	for _, id := range self.order {
+		n := self.nodes[id]
+		snap.Nodes = append(snap.Nodes, NodeSnapshot{
+			Key: hex.EncodeToString(crypto.FromECDSA(n.key)),
+			Up:  n.node != nil,
+		})
+	}
+	for id := range self.conns {
+		snap.Conns = append(snap.Conns, ConnSnapshot{
+			One:   id[0].String(),
+			Other: id[1].String(),
+		})
+	}
+	return snap
+}
+
+// Load adds the nodes of the snapshot to the network, starts the ones that
+// were up and dials the saved connections
+func (self *Network) Load(snap *Snapshot) error {
+	for _, ns := range snap.Nodes {
+		b, err := hex.DecodeString(ns.Key)
+		if err != nil {
+			return err
+		}
+		key := crypto.ToECDSA(b)
+		if key == nil {
+			return fmt.Errorf("invalid key in snapshot")
+		}
+		n, err := self.addNode(key)
+		if err != nil {
+			return err
+		}
+		if ns.Up {
+			if err := self.Start(n.ID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, cs := range snap.Conns {
+		one, err := discover.HexID(cs.One)
+		if err != nil {
+			return err
+		}
+		other, err := discover.HexID(cs.Other)
+		if err != nil {
+			return err
+		}
+		if err := self.Connect(one, other); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// pingService runs a protocol which sends a single ping to each peer and
+// then waits for the connection to be dropped
+type pingService struct{}
+
+func newPingService(*node.ServiceContext) (node.Service, error) { return new(pingService), nil }
+
+func (s *pingService) APIs() []rpc.API         { return nil }
+func (s *pingService) Start(*p2p.Server) error { return nil }
+func (s *pingService) Stop() error             { return nil }
+
+func (s *pingService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    "ping",
+		Version: 1,
+		Length:  1,
+		Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			// pipes are unbuffered, so send while reading
+			go p2p.Send(rw, 0, "ping")
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+		},
+	}}
+}
+
+// collectEvents reads network events in the background until the returned
+// function is called, which returns the events read
+func collectEvents(net *Network) func() []*Event {
+	sub := net.Events()
+	var events []*Event
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range sub.Chan() {
+			events = append(events, ev.Data.(*Event))
+		}
+	}()
+	return func() []*Event {
+		sub.Unsubscribe()
+		<-done
+		return events
+	}
+}
+
+func waitConn(t *testing.T, net *Network, one, other discover.NodeID, up bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for net.Connected(one, other) != up {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for connection %x-%x up=%v", one[:4], other[:4], up)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNetworkSimulation(t *testing.T) {
+	net := NewNetwork(newPingService)
+	defer net.Shutdown()
+	stopEvents := collectEvents(net)
+
+	var ids []discover.NodeID
+	for i := 0; i < 3; i++ {
+		n, err := net.NewNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := net.Start(n.ID); err != nil {
+			t.Fatalf("start node %d: %v", i, err)
+		}
+		ids = append(ids, n.ID)
+	}
+	if err := net.Start(ids[0]); err != node.ErrNodeRunning {
+		t.Errorf("starting running node: got %v, want %v", err, node.ErrNodeRunning)
+	}
+
+	// connect the nodes in a chain
+	for i := 0; i < len(ids)-1; i++ {
+		if err := net.Connect(ids[i], ids[i+1]); err != nil {
+			t.Fatal(err)
+		}
+		waitConn(t, net, ids[i], ids[i+1], true)
+	}
+	if net.Connected(ids[0], ids[2]) {
+		t.Errorf("unexpected connection between first and last node")
+	}
+
+	snap := net.Snapshot()
+	if len(snap.Nodes) != 3 || len(snap.Conns) != 2 {
+		t.Errorf("snapshot has %d nodes and %d conns, want 3 and 2", len(snap.Nodes), len(snap.Conns))
+	}
+
+	if err := net.Disconnect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	waitConn(t, net, ids[0], ids[1], false)
+
+	// stopping a node drops its connections
+	if err := net.Stop(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if net.Connected(ids[1], ids[2]) {
+		t.Errorf("connection of stopped node still up")
+	}
+	if err := net.Connect(ids[1], ids[2]); err != errNodeDown {
+		t.Errorf("connecting to stopped node: got %v, want %v", err, errNodeDown)
+	}
+
+	counts := make(map[string]int)
+	for _, ev := range stopEvents() {
+		if ev.Type == EventTypeConn && ev.Up {
+			counts["connup"]++
+		} else if ev.Type == EventTypeConn {
+			counts["conndown"]++
+		} else {
+			counts[ev.Type]++
+		}
+	}
+	// 3 starts and 1 stop
+	if counts[EventTypeNode] != 4 {
+		t.Errorf("got %d node events, want 4", counts[EventTypeNode])
+	}
+	if counts["connup"] != 2 || counts["conndown"] != 2 {
+		t.Errorf("got %d conn up and %d conn down events, want 2 and 2", counts["connup"], counts["conndown"])
+	}
+	// each side sends and receives a ping on both connections
+	if counts[EventTypeMsg] != 8 {
+		t.Errorf("got %d msg events, want 8", counts[EventTypeMsg])
+	}
+}
+
+func TestNetworkSnapshotLoad(t *testing.T) {
+	net := NewNetwork(newPingService)
+	var ids []discover.NodeID
+	for i := 0; i < 3; i++ {
+		n, err := net.NewNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	for _, id := range ids[:2] {
+		if err := net.Start(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := net.Connect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	waitConn(t, net, ids[0], ids[1], true)
+	snap := net.Snapshot()
+	net.Shutdown()
+
+	loaded := NewNetwork(newPingService)
+	defer loaded.Shutdown()
+	if err := loaded.Load(snap); err != nil {
+		t.Fatal(err)
+	}
+	nodes := loaded.Nodes()
+	if len(nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(nodes))
+	}
+	for i, n := range nodes {
+		if n.ID != ids[i] {
+			t.Errorf("node %d: ID mismatch", i)
+		}
+		if up := i < 2; n.Up() != up {
+			t.Errorf("node %d: up=%v, want %v", i, n.Up(), up)
+		}
+	}
+	waitConn(t, loaded, ids[0], ids[1], true)
+}
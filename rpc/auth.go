@@ -0,0 +1,245 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid bearer token")
+	errTokenExpired = errors.New("token expired")
+)
+
+// Authenticator verifies the credentials of HTTP and WebSocket requests.
+type Authenticator interface {
+	// Authenticate returns the identity of the client that sent the request.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// TokenAuth authenticates clients by static bearer tokens. It maps client names to
+// their tokens.
+type TokenAuth map[string]string
+
+// Authenticate implements Authenticator.
+func (ta TokenAuth) Authenticate(r *http.Request) (string, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+	for client, t := range ta {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return client, nil
+		}
+	}
+	return "", errInvalidToken
+}
+
+// JWTAuth authenticates clients by JSON Web Tokens signed with HMAC-SHA256 using
+// a shared secret. The client identity is taken from the "sub" claim.
+type JWTAuth struct {
+	Secret []byte
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// Authenticate implements Authenticator.
+func (ja *JWTAuth) Authenticate(r *http.Request) (string, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", errInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errInvalidToken
+	}
+	mac := hmac.New(sha256.New, ja.Secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errInvalidToken
+	}
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", errInvalidToken
+	}
+	now := time.Now().Unix()
+	if claims.ExpiresAt != nil && now >= *claims.ExpiresAt {
+		return "", errTokenExpired
+	}
+	if claims.NotBefore != nil && now < *claims.NotBefore {
+		return "", errInvalidToken
+	}
+	return claims.Subject, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// MultiAuth accepts requests passing any of its authenticators.
+type MultiAuth []Authenticator
+
+// Authenticate implements Authenticator.
+func (ma MultiAuth) Authenticate(r *http.Request) (string, error) {
+	err := errMissingToken
+	for _, auth := range ma {
+		var client string
+		if client, err = auth.Authenticate(r); err == nil {
+			return client, nil
+		}
+	}
+	return "", err
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", errMissingToken
+	}
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return "", errInvalidToken
+	}
+	return strings.TrimSpace(header[7:]), nil
+}
+
+// AccessList restricts the modules ("eth") and methods ("eth_sendTransaction") a
+// client may call. It maps client identities to the permitted modules and methods,
+// the "*" entry applies to all clients without an entry of their own. An empty
+// access list permits everything.
+type AccessList map[string][]string
+
+// Allowed reports whether the client may call the given method.
+func (acl AccessList) Allowed(client, service, method string) bool {
+	if len(acl) == 0 {
+		return true
+	}
+	rules, ok := acl[client]
+	if !ok {
+		if rules, ok = acl["*"]; !ok {
+			return false
+		}
+	}
+	for _, rule := range rules {
+		if rule == "*" || rule == service || rule == service+serviceMethodSeparator+method {
+			return true
+		}
+	}
+	return false
+}
+
+// peerInfo describes the remote end of a connection served by Server.
+type peerInfo struct {
+	transport  string // "ipc", "http", "ws" or "inproc"
+	client     string // authenticated client identity, empty if unauthenticated
+	remoteAddr string
+}
+
+type peerInfoKey struct{}
+
+// peerFromContext returns the peer of the connection a request arrived on.
+func peerFromContext(ctx context.Context) peerInfo {
+	p, _ := ctx.Value(peerInfoKey{}).(peerInfo)
+	return p
+}
+
+// codecPeer describes the peer of a codec served by ServeCodec. Connections
+// created by net.Pipe are in-process, all others are assumed to be IPC.
+func codecPeer(codec ServerCodec) peerInfo {
+	p := peerInfo{transport: "ipc"}
+	if jc, ok := codec.(*jsonCodec); ok {
+		if conn, ok := jc.rw.(net.Conn); ok {
+			if addr := conn.RemoteAddr(); addr != nil {
+				if addr.Network() == "pipe" {
+					p.transport = "inproc"
+				}
+				p.remoteAddr = addr.String()
+			}
+		}
+	}
+	return p
+}
+
+// limiterKey returns the key rate limits are tracked under: the client identity
+// if authenticated, the remote host otherwise.
+func (p peerInfo) limiterKey() string {
+	if p.client != "" {
+		return p.client
+	}
+	if host, _, err := net.SplitHostPort(p.remoteAddr); err == nil {
+		return host
+	}
+	return p.remoteAddr
+}
+
+// authenticate checks the request against the server's authenticator, replying
+// with 401 Unauthorized on failure.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, transport string) (peerInfo, bool) {
+	p := peerInfo{transport: transport, remoteAddr: r.RemoteAddr}
+	if s.auth == nil {
+		return p, true
+	}
+	client, err := s.auth.Authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="rpc"`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return p, false
+	}
+	p.client = client
+	return p, true
+}
+
+// SetAuthenticator requires HTTP and WebSocket clients to authenticate. It must
+// be called before the server starts serving requests.
+func (s *Server) SetAuthenticator(auth Authenticator) {
+	s.auth = auth
+}
+
+// SetAccessList restricts the methods clients may call. It must be called before
+// the server starts serving requests.
+func (s *Server) SetAccessList(acl AccessList) {
+	s.acl = acl
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func makeJWT(secret []byte, claims string) string {
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticators(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	auth := MultiAuth{
+		TokenAuth{"alice": "secret-a", "bob": "secret-b"},
+		&JWTAuth{Secret: secret},
+	}
+	future, past := time.Now().Add(time.Hour).Unix(), time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		header, client string
+		err            error
+	}{
+		{"", "", errMissingToken},
+		{"Basic Zm9vOmJhcg==", "", errInvalidToken},
+		{"Bearer secret-b", "bob", nil},
+		{"bearer secret-a", "alice", nil},
+		{"Bearer secret-c", "", errInvalidToken},
+		{"Bearer " + makeJWT(secret, `{"sub":"carol"}`), "carol", nil},
+		{"Bearer " + makeJWT(secret, fmt.Sprintf(`{"sub":"carol","exp":%d}`, future)), "carol", nil},
+		{"Bearer " + makeJWT(secret, fmt.Sprintf(`{"sub":"carol","exp":%d}`, past)), "", errTokenExpired},
+		{"Bearer " + makeJWT(secret, fmt.Sprintf(`{"sub":"carol","nbf":%d}`, future)), "", errInvalidToken},
+		{"Bearer " + makeJWT([]byte("wrong secret"), `{"sub":"carol"}`), "", errInvalidToken},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", "/", nil)
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+		client, err := auth.Authenticate(req)
+		if client != test.client || err != test.err {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", test.header, client, err, test.client, test.err)
+		}
+	}
+}
+
+func TestAccessList(t *testing.T) {
+	acl := AccessList{
+		"admin": {"*"},
+		"alice": {"eth", "net_version"},
+		"*":     {"web3"},
+	}
+	tests := []struct {
+		client, service, method string
+		allowed                 bool
+	}{
+		{"admin", "personal", "unlockAccount", true},
+		{"alice", "eth", "getBalance", true},
+		{"alice", "net", "version", true},
+		{"alice", "net", "peerCount", false},
+		{"alice", "web3", "clientVersion", false},
+		{"", "web3", "clientVersion", true},
+		{"bob", "eth", "getBalance", false},
+	}
+	for _, test := range tests {
+		if allowed := acl.Allowed(test.client, test.service, test.method); allowed != test.allowed {
+			t.Errorf("%s calling %s_%s: allowed = %t, want %t", test.client, test.service, test.method, allowed, test.allowed)
+		}
+	}
+	if !AccessList(nil).Allowed("", "admin", "peers") {
+		t.Error("empty access list denies calls")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	if !l.allow("a", 3) {
+		t.Fatal("initial burst denied")
+	}
+	if l.allow("a", 1) {
+		t.Fatal("request allowed with empty bucket")
+	}
+	if !l.allow("b", 1) {
+		t.Fatal("other client affected by limit")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !l.allow("a", 1) || l.allow("a", 1) {
+		t.Fatal("wrong refill after 500ms")
+	}
+	now = now.Add(time.Hour)
+	if !l.allow("a", 3) || l.allow("a", 1) {
+		t.Fatal("bucket refilled beyond burst")
+	}
+}
+
+func TestHTTPAuthAndLimits(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	server.SetAuthenticator(TokenAuth{"alice": "secret-a", "bob": "secret-b"})
+	server.SetAccessList(AccessList{"alice": {"service"}, "bob": {"rpc"}})
+	server.SetRateLimit(0.001, 2)
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+
+	// Unauthenticated requests are rejected by the transport.
+	noauth, _ := DialHTTP(hs.URL)
+	if err := noauth.Call(nil, "service_echo", "x", 1); err == nil {
+		t.Fatal("unauthenticated call succeeded")
+	}
+
+	alice, _ := DialHTTPWithToken(hs.URL, "secret-a")
+	var res Result
+	if err := alice.Call(&res, "service_echo", "x", 1); err != nil {
+		t.Fatalf("alice's call failed: %v", err)
+	}
+	bob, _ := DialHTTPWithToken(hs.URL, "secret-b")
+	if err := bob.Call(&res, "service_echo", "x", 1); err == nil || err.Error() != "access to service_echo denied" {
+		t.Fatalf("bob's call: got error %v, want access denied", err)
+	}
+	// The burst is used up, alice is limited while bob is not.
+	if err := alice.Call(&res, "service_echo", "x", 1); err != nil {
+		t.Fatalf("alice's second call failed: %v", err)
+	}
+	if err := alice.Call(&res, "service_echo", "x", 1); err == nil || err.Error() != (&rateLimitError{}).Error() {
+		t.Fatalf("alice's third call: got error %v, want rate limit", err)
+	}
+	var modules map[string]string
+	if err := bob.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatalf("bob's call failed: %v", err)
+	}
+}
+
+func TestWebsocketAuth(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	server.SetAuthenticator(TokenAuth{"alice": "secret-a"})
+	hs := httptest.NewServer(server.WebsocketHandler("*"))
+	defer hs.Close()
+	wsURL := "ws:" + hs.URL[len("http:"):]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if client, err := DialWebsocket(ctx, wsURL, ""); err == nil {
+		client.Close()
+		t.Fatal("unauthenticated websocket connection succeeded")
+	}
+	client, err := DialWebsocketWithToken(ctx, wsURL, "", "secret-a")
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	defer client.Close()
+	var res Result
+	if err := client.Call(&res, "service_echo", "x", 1); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+}
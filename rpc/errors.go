@@ -16,7 +16,10 @@
 
 package rpc
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // request is for an unknown service
 type methodNotFoundError struct {
@@ -64,3 +67,60 @@ type shutdownError struct{}
 func (e *shutdownError) ErrorCode() int { return -32000 }
 
 func (e *shutdownError) Error() string { return "server is shutting down" }
+
+// issued when the client is not permitted to call the requested method.
+type accessDeniedError struct {
+	service string
+	method  string
+}
+
+func (e *accessDeniedError) ErrorCode() int { return -32004 }
+
+func (e *accessDeniedError) Error() string {
+	return fmt.Sprintf("access to %s%s%s denied", e.service, serviceMethodSeparator, e.method)
+}
+
+// issued when the client exceeds its request rate.
+type rateLimitError struct{}
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string { return "request rate limit exceeded" }
+
+// issued when a batch contains more requests than permitted.
+type batchTooLargeError struct{ limit int }
+
+func (e *batchTooLargeError) ErrorCode() int { return -32006 }
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch too large, the limit is %d requests", e.limit)
+}
+
+// issued when the result of a request exceeds the permitted size.
+type responseTooLargeError struct{ limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32007 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large, the limit is %d bytes", e.limit)
+}
+
+// issued when a method call doesn't complete within its timeout.
+type timeoutError struct {
+	service string
+	method  string
+	timeout time.Duration
+}
+
+func (e *timeoutError) ErrorCode() int { return -32008 }
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s%s%s timed out after %v", e.service, serviceMethodSeparator, e.method, e.timeout)
+}
+
+// issued when the client goes away while its request is executing.
+type canceledError struct{}
+
+func (e *canceledError) ErrorCode() int { return -32000 }
+
+func (e *canceledError) Error() string { return "request canceled" }
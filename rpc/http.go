@@ -65,12 +65,21 @@ func (hc *httpConn) Close() error {
 
 // DialHTTP creates a new RPC clients that connection to an RPC server over HTTP.
 func DialHTTP(endpoint string) (*Client, error) {
+	return DialHTTPWithToken(endpoint, "")
+}
+
+// DialHTTPWithToken creates a new RPC client that connects to an RPC server over
+// HTTP, authenticating with the given bearer token.
+func DialHTTPWithToken(endpoint, token string) (*Client, error) {
 	req, err := http.NewRequest("POST", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
@@ -152,14 +161,37 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.StatusRequestEntityTooLarge)
 		return
 	}
+	peer, ok := srv.authenticate(w, r, "http")
+	if !ok {
+		return
+	}
 	w.Header().Set("content-type", "application/json")
 
-	// create a codec that reads direct from the request body until
-	// EOF and writes the response to w and order the server to process
-	// a single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	// read the whole body up front, this is also required for disconnects to be
+	// noticed while the request executes.
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHTTPRequestContentLength))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// create a codec that reads the request body and writes the response to w
+	// and order the server to process a single request.
+	codec := NewJSONCodec(&httpReadWriteNopCloser{bytes.NewReader(body), w})
 	defer codec.Close()
-	srv.ServeSingleRequest(codec, OptionMethodInvocation)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), peerInfoKey{}, peer))
+	defer cancel()
+	// cancel the request if the client disconnects before it is answered
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed := cn.CloseNotify()
+		go func() {
+			select {
+			case <-closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	srv.serveRequest(ctx, codec, true, OptionMethodInvocation)
 }
 
 func newCorsHandler(srv *Server, corsString string) http.Handler {
@@ -170,6 +202,7 @@ func newCorsHandler(srv *Server, corsString string) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
 		AllowedMethods: []string{"POST", "GET"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
 		MaxAge:         600,
 	})
 	return c.Handler(srv)
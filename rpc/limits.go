@@ -0,0 +1,68 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Limits bounds the resources a single request may consume. Zero values mean
+// unlimited.
+type Limits struct {
+	// Timeout is the maximum execution time of a method call. When it expires, the
+	// call's context is canceled and the client receives an error response.
+	Timeout time.Duration
+
+	// MethodTimeouts overrides Timeout for modules ("debug") or individual methods
+	// ("debug_traceTransaction"). Method entries take precedence over modules.
+	MethodTimeouts map[string]time.Duration
+
+	// MaxBatchSize is the maximum number of requests in a batch.
+	MaxBatchSize int
+
+	// MaxResponseSize is the maximum size of the encoded result of a request, or
+	// of all results of a batch, in bytes.
+	MaxResponseSize int
+}
+
+// SetLimits applies the given resource limits to all requests. It must be called
+// before the server starts serving requests.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// timeout returns the execution timeout of the given method.
+func (l *Limits) timeout(service, method string) time.Duration {
+	if t, ok := l.MethodTimeouts[service+serviceMethodSeparator+method]; ok {
+		return t
+	}
+	if t, ok := l.MethodTimeouts[service]; ok {
+		return t
+	}
+	return l.Timeout
+}
+
+// responseSize returns the encoded size of a response. Responses which can't be
+// encoded are reported as empty, the codec deals with them.
+func responseSize(response interface{}) int {
+	enc, err := json.Marshal(response)
+	if err != nil {
+		return 0
+	}
+	return len(enc)
+}
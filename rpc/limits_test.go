@@ -0,0 +1,176 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// BlockingService has a method which runs until the test releases it.
+type BlockingService struct {
+	canceled chan struct{} // closed when the call's context is canceled
+	release  chan struct{}
+}
+
+func newBlockingService() *BlockingService {
+	return &BlockingService{canceled: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (s *BlockingService) Block(ctx context.Context) {
+	<-ctx.Done()
+	close(s.canceled)
+	<-s.release
+}
+
+func errorCode(err error) int {
+	if jerr, ok := err.(*jsonError); ok {
+		return jerr.Code
+	}
+	return 0
+}
+
+func TestServerMethodTimeout(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	blocker := newBlockingService()
+	defer close(blocker.release)
+	server.RegisterName("block", blocker)
+	server.SetLimits(Limits{MethodTimeouts: map[string]time.Duration{"block_block": 50 * time.Millisecond}})
+	client := DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "block_block")
+	if code := errorCode(err); code != -32008 {
+		t.Fatalf("got error %v (code %d), want timeout", err, code)
+	}
+	select {
+	case <-blocker.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("call context not canceled")
+	}
+	// Methods without a timeout are unaffected.
+	if err := client.Call(nil, "service_sleep", 100*time.Millisecond); err != nil {
+		t.Fatalf("call without timeout failed: %v", err)
+	}
+}
+
+func TestLimitsTimeoutLookup(t *testing.T) {
+	l := Limits{
+		Timeout:        time.Second,
+		MethodTimeouts: map[string]time.Duration{"debug": time.Minute, "debug_traceTransaction": time.Hour},
+	}
+	tests := []struct {
+		service, method string
+		want            time.Duration
+	}{
+		{"eth", "call", time.Second},
+		{"debug", "stacks", time.Minute},
+		{"debug", "traceTransaction", time.Hour},
+	}
+	for _, test := range tests {
+		if got := l.timeout(test.service, test.method); got != test.want {
+			t.Errorf("%s_%s: got timeout %v, want %v", test.service, test.method, got, test.want)
+		}
+	}
+}
+
+func TestServerResponseLimit(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	server.SetLimits(Limits{MaxResponseSize: 200})
+	client := DialInProc(server)
+	defer client.Close()
+
+	var res Result
+	if err := client.Call(&res, "service_echo", "short", 1); err != nil {
+		t.Fatalf("small response failed: %v", err)
+	}
+	err := client.Call(&res, "service_echo", strings.Repeat("x", 300), 1)
+	if code := errorCode(err); code != -32007 {
+		t.Fatalf("got error %v (code %d), want response too large", err, code)
+	}
+
+	// In batches, results beyond the limit are replaced.
+	batch := []BatchElem{
+		{Method: "service_echo", Args: []interface{}{strings.Repeat("x", 100), 1}, Result: new(Result)},
+		{Method: "service_echo", Args: []interface{}{strings.Repeat("x", 100), 2}, Result: new(Result)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Error != nil {
+		t.Errorf("first batch element failed: %v", batch[0].Error)
+	}
+	if code := errorCode(batch[1].Error); code != -32007 {
+		t.Errorf("got error %v (code %d) for second batch element, want response too large", batch[1].Error, code)
+	}
+}
+
+func TestServerBatchLimit(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	server.SetLimits(Limits{MaxBatchSize: 2})
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+
+	post := func(body string) string {
+		resp, err := http.Post(hs.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := ioutil.ReadAll(resp.Body)
+		return string(out)
+	}
+	req := `{"jsonrpc":"2.0","id":%d,"method":"rpc_modules"}`
+	if out := post(fmt.Sprintf("["+req+","+req+"]", 1, 2)); strings.Contains(out, "error") {
+		t.Errorf("batch within limit failed: %s", out)
+	}
+	if out := post(fmt.Sprintf("["+req+","+req+","+req+"]", 1, 2, 3)); !strings.Contains(out, `"code":-32006`) {
+		t.Errorf("got %s for oversized batch, want batch too large error", out)
+	}
+}
+
+func TestHTTPDisconnectCancels(t *testing.T) {
+	server := NewServer()
+	blocker := newBlockingService()
+	server.RegisterName("block", blocker)
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+	defer close(blocker.release)
+
+	conn, err := net.Dial("tcp", hs.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"jsonrpc":"2.0","id":1,"method":"block_block"}`
+	fmt.Fprintf(conn, "POST / HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+
+	select {
+	case <-blocker.canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("call context not canceled after client disconnect")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"golang.org/x/net/context"
+)
+
+// unknownMethod is the name requests for methods that don't exist are reported
+// under, so arbitrary method names can't flood the metrics registry.
+const unknownMethod = "unknown"
+
+// methodMetrics are the metrics collected for a single method and transport.
+type methodMetrics struct {
+	calls    gometrics.Counter // number of requests
+	duration gometrics.Timer   // execution time
+	errors   gometrics.Meter   // requests answered with an error
+}
+
+// callMetrics keeps the metrics of all methods called on a server, registering
+// them in the metrics package on first use.
+type callMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*methodMetrics
+}
+
+func (cm *callMetrics) get(transport, method string) *methodMetrics {
+	prefix := "rpc/" + transport + "/" + method + "/"
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.methods == nil {
+		cm.methods = make(map[string]*methodMetrics)
+	}
+	m := cm.methods[prefix]
+	if m == nil {
+		m = &methodMetrics{
+			calls:    metrics.NewCounter(prefix + "calls"),
+			duration: metrics.NewTimer(prefix + "duration"),
+			errors:   metrics.NewMeter(prefix + "errors"),
+		}
+		cm.methods[prefix] = m
+	}
+	return m
+}
+
+// accessLogEntry is a single line of the access log.
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Transport string    `json:"transport"`
+	Remote    string    `json:"remote,omitempty"`
+	Client    string    `json:"client,omitempty"`
+	Duration  float64   `json:"duration"` // seconds
+	Error     int       `json:"error,omitempty"`
+}
+
+// accessLog writes one JSON object per request to an output stream.
+type accessLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (l *accessLog) write(entry *accessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(entry); err != nil {
+		glog.V(logger.Warn).Infof("can't write RPC access log: %v", err)
+	}
+}
+
+// SetAccessLog enables the access log, which records every request as a JSON
+// object on its own line. It must be called before the server starts serving
+// requests.
+func (s *Server) SetAccessLog(w io.Writer) {
+	if w == nil {
+		s.accessLog = nil
+		return
+	}
+	s.accessLog = &accessLog{enc: json.NewEncoder(w)}
+}
+
+// record updates the metrics and the access log after a request was executed.
+func (s *Server) record(ctx context.Context, req *serverRequest, duration time.Duration, err Error) {
+	peer := peerFromContext(ctx)
+	method := req.name
+	if method == "" {
+		method = unknownMethod
+	}
+	m := s.metrics.get(peer.transport, method)
+	m.calls.Inc(1)
+	m.duration.Update(duration)
+	if err != nil {
+		m.errors.Mark(1)
+	}
+
+	if s.accessLog != nil {
+		entry := &accessLogEntry{
+			Time:      time.Now(),
+			Method:    method,
+			Transport: peer.transport,
+			Remote:    peer.remoteAddr,
+			Client:    peer.client,
+			Duration:  duration.Seconds(),
+		}
+		if err != nil {
+			entry.Error = err.ErrorCode()
+		}
+		s.accessLog.write(entry)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) entries(t *testing.T) []accessLogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []accessLogEntry
+	dec := json.NewDecoder(bytes.NewReader(b.buf.Bytes()))
+	for dec.More() {
+		var e accessLogEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("invalid access log entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAccessLog(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	log := new(syncBuffer)
+	server.SetAccessLog(log)
+	server.SetAuthenticator(TokenAuth{"alice": "secret-a"})
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+
+	inproc := DialInProc(server)
+	defer inproc.Close()
+	var res Result
+	if err := inproc.Call(&res, "service_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := inproc.Call(nil, "service_noSuchMethod"); err == nil {
+		t.Fatal("call to missing method succeeded")
+	}
+	client, _ := DialHTTPWithToken(hs.URL, "secret-a")
+	if err := client.Call(nil, "service_invalidRets1"); err == nil {
+		t.Fatal("call to invalid method succeeded")
+	}
+
+	entries := log.entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("got %d access log entries, want 3", len(entries))
+	}
+	want := []accessLogEntry{
+		{Method: "service_echo", Transport: "inproc"},
+		{Method: unknownMethod, Transport: "inproc", Error: -32601},
+		{Method: unknownMethod, Transport: "http", Client: "alice", Error: -32601},
+	}
+	for i, e := range entries {
+		if e.Method != want[i].Method || e.Transport != want[i].Transport || e.Client != want[i].Client || e.Error != want[i].Error {
+			t.Errorf("entry %d: got %+v, want %+v", i, e, want[i])
+		}
+		if e.Time.IsZero() || e.Duration < 0 {
+			t.Errorf("entry %d: invalid time or duration: %+v", i, e)
+		}
+	}
+	if entries[2].Remote == "" {
+		t.Error("remote address of HTTP request not logged")
+	}
+}
+
+func TestCallMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	server := newTestServer("service", new(Service))
+	client := DialInProc(server)
+	defer client.Close()
+
+	var res Result
+	for i := 0; i < 3; i++ {
+		client.Call(&res, "service_echo", "x", 1)
+	}
+	client.Call(nil, "service_echo")
+
+	calls, ok := gometrics.DefaultRegistry.Get("rpc/inproc/service_echo/calls").(gometrics.Counter)
+	if !ok {
+		t.Fatal("call counter not registered")
+	}
+	if n := calls.Count(); n != 4 {
+		t.Errorf("got %d calls, want 4", n)
+	}
+	if timer, ok := gometrics.DefaultRegistry.Get("rpc/inproc/service_echo/duration").(gometrics.Timer); !ok || timer.Count() != 4 {
+		t.Error("call durations not recorded")
+	}
+	if meter, ok := gometrics.DefaultRegistry.Get("rpc/inproc/service_echo/errors").(gometrics.Meter); !ok || meter.Count() != 1 {
+		t.Error("call error not recorded")
+	}
+}
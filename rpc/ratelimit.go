@@ -0,0 +1,107 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// maxLimiterBuckets is the number of tracked clients above which full (idle)
+// buckets are discarded.
+const maxLimiterBuckets = 4096
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // bucket capacity
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes n tokens from the client's bucket, reporting whether there were
+// enough.
+func (l *rateLimiter) allow(client string, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.buckets[client]
+	if b == nil {
+		if len(l.buckets) >= maxLimiterBuckets {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	} else {
+		b.refill(now, l.rate, l.burst)
+	}
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// prune drops buckets that have refilled completely, they are equivalent to new ones.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.refill(now, l.rate, l.burst); b.tokens >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
+
+// SetRateLimit limits each client to the given number of requests per second,
+// allowing bursts of up to burst requests. Clients are identified by their
+// authenticated identity or remote host. Each request of a batch counts. A rate
+// of zero disables limiting. It must be called before the server starts serving
+// requests.
+func (s *Server) SetRateLimit(rate float64, burst int) {
+	if rate <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newRateLimiter(rate, burst)
+}
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -143,7 +144,9 @@ func hasOption(option CodecOption, options []CodecOption) bool {
 // If singleShot is true it will process a single request, otherwise it will handle
 // requests until the codec returns an error when reading a request (in most cases
 // an EOF). It executes requests in parallel when singleShot is false.
-func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecOption) error {
+//
+// The given context carries information about the connection's peer.
+func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot bool, options CodecOption) error {
 	defer func() {
 		if err := recover(); err != nil {
 			const size = 64 << 10
@@ -159,7 +162,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		return
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// if the codec supports notification include a notifier that callbacks can use
@@ -178,13 +181,28 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 
 	// test if the server is ordered to stop
 	for atomic.LoadInt32(&s.run) == 1 {
-		reqs, batch, err := s.readRequest(codec)
+		reqs, batch, err := s.readRequest(ctx, codec)
 		if err != nil {
 			glog.V(logger.Debug).Infof("read error %v\n", err)
 			codec.Write(codec.CreateErrorResponse(nil, err))
 			return nil
 		}
 
+		if limit := s.limits.MaxBatchSize; batch && limit > 0 && len(reqs) > limit {
+			codec.Write(codec.CreateErrorResponse(nil, &batchTooLargeError{limit}))
+			if singleShot {
+				return nil
+			}
+			continue
+		}
+		if s.limiter != nil && !s.limiter.allow(peerFromContext(ctx).limiterKey(), len(reqs)) {
+			for _, r := range reqs {
+				if r.err == nil {
+					r.err = &rateLimitError{}
+				}
+			}
+		}
+
 		// check if server is ordered to shutdown and return an error
 		// telling the client that his request failed.
 		if atomic.LoadInt32(&s.run) != 1 {
@@ -222,14 +240,16 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(codec, false, options)
+	ctx := context.WithValue(context.Background(), peerInfoKey{}, codecPeer(codec))
+	s.serveRequest(ctx, codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
 // close the codec unless a non-recoverable error has occurred. Note, this method will return after
 // a single request has been processed!
 func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
-	s.serveRequest(codec, true, options)
+	ctx := context.WithValue(context.Background(), peerInfoKey{}, codecPeer(codec))
+	s.serveRequest(ctx, codec, true, options)
 }
 
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
@@ -260,33 +280,34 @@ func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *ser
 	return reply[0].Interface().(*Subscription).ID, nil
 }
 
-// handle executes a request and returns the response from the callback.
-func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverRequest) (interface{}, func()) {
+// handle executes a request and returns the response from the callback, along
+// with the error it carries.
+func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverRequest) (interface{}, func(), Error) {
 	if req.err != nil {
-		return codec.CreateErrorResponse(&req.id, req.err), nil
+		return errorResponse(codec, req, req.err)
 	}
 
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
 			notifier, supported := NotifierFromContext(ctx)
 			if !supported { // interface doesn't support subscriptions (e.g. http)
-				return codec.CreateErrorResponse(&req.id, &callbackError{ErrNotificationsUnsupported.Error()}), nil
+				return errorResponse(codec, req, &callbackError{ErrNotificationsUnsupported.Error()})
 			}
 
 			subid := ID(req.args[0].String())
 			if err := notifier.unsubscribe(subid); err != nil {
-				return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+				return errorResponse(codec, req, &callbackError{err.Error()})
 			}
 
-			return codec.CreateResponse(req.id, true), nil
+			return codec.CreateResponse(req.id, true), nil, nil
 		}
-		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
+		return errorResponse(codec, req, &invalidParamsError{"Expected subscription id as first argument"})
 	}
 
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
-			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+			return errorResponse(codec, req, &callbackError{err.Error()})
 		}
 
 		// active the subscription after the sub id was successfully sent to the client
@@ -295,7 +316,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 			notifier.activate(subid)
 		}
 
-		return codec.CreateResponse(req.id, subid), activateSub
+		return codec.CreateResponse(req.id, subid), activateSub, nil
 	}
 
 	// regular RPC call, prepare arguments
@@ -303,9 +324,16 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		rpcErr := &invalidParamsError{fmt.Sprintf("%s%s%s expects %d parameters, got %d",
 			req.svcname, serviceMethodSeparator, req.callb.method.Name,
 			len(req.callb.argTypes), len(req.args))}
-		return codec.CreateErrorResponse(&req.id, rpcErr), nil
+		return errorResponse(codec, req, rpcErr)
 	}
 
+	method := formatName(req.callb.method.Name)
+	timeout := s.limits.timeout(req.svcname, method)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -315,30 +343,81 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	var reply []reflect.Value
+	if timeout > 0 {
+		var err Error
+		if reply, err = s.callWithTimeout(ctx, req, arguments); err != nil {
+			if _, ok := err.(*timeoutError); ok {
+				err = &timeoutError{req.svcname, method, timeout}
+			}
+			return errorResponse(codec, req, err)
+		}
+	} else {
+		reply = req.callb.method.Func.Call(arguments)
+	}
 	if len(reply) == 0 {
-		return codec.CreateResponse(req.id, nil), nil
+		return codec.CreateResponse(req.id, nil), nil, nil
 	}
 
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+			return errorResponse(codec, req, &callbackError{e.Error()})
 		}
 	}
-	return codec.CreateResponse(req.id, reply[0].Interface()), nil
+	return codec.CreateResponse(req.id, reply[0].Interface()), nil, nil
+}
+
+// errorResponse creates the response to a failed request.
+func errorResponse(codec ServerCodec, req *serverRequest, err Error) (interface{}, func(), Error) {
+	return codec.CreateErrorResponse(&req.id, err), nil, err
+}
+
+// callWithTimeout invokes the callback of a request, returning when the call
+// completes or the context expires, whichever happens first. In the latter case
+// the callback keeps running until it observes the canceled context.
+func (s *Server) callWithTimeout(ctx context.Context, req *serverRequest, arguments []reflect.Value) ([]reflect.Value, Error) {
+	done := make(chan []reflect.Value, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				glog.V(logger.Error).Infof("RPC method %s%s%s crashed: %v", req.svcname, serviceMethodSeparator, req.callb.method.Name, err)
+				done <- nil
+			}
+		}()
+		done <- req.callb.method.Func.Call(arguments)
+	}()
+	select {
+	case reply := <-done:
+		if reply == nil {
+			return nil, &callbackError{"method handler crashed"}
+		}
+		return reply, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &timeoutError{}
+		}
+		return nil, &canceledError{}
+	}
 }
 
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
-	var response interface{}
-	var callback func()
+	start := time.Now()
+	var (
+		response interface{}
+		callback func()
+		err      Error
+	)
 	if req.err != nil {
-		response = codec.CreateErrorResponse(&req.id, req.err)
+		response, _, err = errorResponse(codec, req, req.err)
 	} else {
-		response, callback = s.handle(ctx, codec, req)
+		response, callback, err = s.handle(ctx, codec, req)
 	}
+	if limit := s.limits.MaxResponseSize; limit > 0 && responseSize(response) > limit {
+		response, _, err = errorResponse(codec, req, &responseTooLargeError{limit})
+	}
+	s.record(ctx, req, time.Since(start), err)
 
 	if err := codec.Write(response); err != nil {
 		glog.V(logger.Error).Infof("%v\n", err)
@@ -356,15 +435,25 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
+	size, limit := 0, s.limits.MaxResponseSize
 	for i, req := range requests {
+		start := time.Now()
+		var err Error
 		if req.err != nil {
-			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
+			responses[i], _, err = errorResponse(codec, req, req.err)
 		} else {
 			var callback func()
-			if responses[i], callback = s.handle(ctx, codec, req); callback != nil {
+			if responses[i], callback, err = s.handle(ctx, codec, req); callback != nil {
 				callbacks = append(callbacks, callback)
 			}
 		}
+		// once the batch grows too large, the remaining results are replaced by errors
+		if limit > 0 {
+			if size += responseSize(responses[i]); size > limit {
+				responses[i], _, err = errorResponse(codec, req, &responseTooLargeError{limit})
+			}
+		}
+		s.record(ctx, req, time.Since(start), err)
 	}
 
 	if err := codec.Write(responses); err != nil {
@@ -381,13 +470,14 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 // readRequest requests the next (batch) request from the codec. It will return the collection
 // of requests, an indication if the request was a batch, the invalid request identifier and an
 // error when the request could not be read/parsed.
-func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error) {
+func (s *Server) readRequest(ctx context.Context, codec ServerCodec) ([]*serverRequest, bool, Error) {
 	reqs, batch, err := codec.ReadRequestHeaders()
 	if err != nil {
 		return nil, batch, err
 	}
 
 	requests := make([]*serverRequest, len(reqs))
+	client := peerFromContext(ctx).client
 
 	// verify requests
 	for i, r := range reqs {
@@ -415,6 +505,11 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 			continue
 		}
 
+		if !s.acl.Allowed(client, r.service, r.method) {
+			requests[i] = &serverRequest{id: r.id, err: &accessDeniedError{r.service, r.method}}
+			continue
+		}
+
 		if r.isPubSub { // eth_subscribe, r.method contains the subscription method name
 			if callb, ok := svc.subscriptions[r.method]; ok {
 				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
@@ -447,6 +542,32 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service, r.method}}
 	}
+	for i, r := range reqs {
+		requests[i].name = s.methodName(r)
+	}
 
 	return requests, batch, nil
 }
+
+// methodName returns the name a request is reported under in metrics and logs,
+// or the empty string if it doesn't refer to a known method.
+func (s *Server) methodName(r rpcRequest) string {
+	if r.err != nil {
+		return ""
+	}
+	if r.isPubSub && r.method == unsubscribeMethod {
+		return unsubscribeMethod
+	}
+	svc, ok := s.services[r.service]
+	if !ok {
+		return ""
+	}
+	if r.isPubSub {
+		if _, ok := svc.subscriptions[r.method]; ok {
+			return subscribeMethod
+		}
+	} else if _, ok := svc.callbacks[r.method]; ok {
+		return r.service + serviceMethodSeparator + r.method
+	}
+	return ""
+}
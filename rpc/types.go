@@ -63,6 +63,7 @@ type serverRequest struct {
 	args          []reflect.Value
 	isUnsubscribe bool
 	err           Error
+	name          string // method name for metrics and logs, empty if unknown
 }
 
 type serviceRegistry map[string]*service       // collection of services
@@ -79,6 +80,14 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	auth    Authenticator // verifies HTTP and WebSocket clients, nil if open
+	acl     AccessList    // methods permitted per client
+	limiter *rateLimiter  // per client request rate limit, nil if unlimited
+	limits  Limits        // per request resource limits
+
+	metrics   callMetrics
+	accessLog *accessLog // nil if disabled
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -36,13 +36,25 @@ import (
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
+//
+// If the server has an authenticator, clients must authenticate the upgrade request.
 func (srv *Server) WebsocketHandler(allowedOrigins string) http.Handler {
-	return websocket.Server{
-		Handshake: wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
-		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
-		},
-	}
+	handshake := wsHandshakeValidator(strings.Split(allowedOrigins, ","))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, ok := srv.authenticate(w, r, "ws")
+		if !ok {
+			return
+		}
+		websocket.Server{
+			Handshake: handshake,
+			Handler: func(conn *websocket.Conn) {
+				codec := NewJSONCodec(conn)
+				defer codec.Close()
+				ctx := context.WithValue(context.Background(), peerInfoKey{}, peer)
+				srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
+			},
+		}.ServeHTTP(w, r)
+	})
 }
 
 // NewWSServer creates a new websocket RPC server around an API provider.
@@ -96,6 +108,12 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 // The context is used for the initial connection establishment. It does not
 // affect subsequent interactions with the client.
 func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
+	return DialWebsocketWithToken(ctx, endpoint, origin, "")
+}
+
+// DialWebsocketWithToken creates a new RPC client that communicates with a JSON-RPC
+// server listening on the given endpoint, authenticating with the given bearer token.
+func DialWebsocketWithToken(ctx context.Context, endpoint, origin, token string) (*Client, error) {
 	if origin == "" {
 		var err error
 		if origin, err = os.Hostname(); err != nil {
@@ -111,6 +129,9 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 	if err != nil {
 		return nil, err
 	}
+	if token != "" {
+		config.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	return newClient(ctx, func(ctx context.Context) (net.Conn, error) {
 		return wsDialContext(ctx, config)
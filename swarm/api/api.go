@@ -45,15 +45,19 @@ on top of the dpa
 it is the public interface of the dpa which is included in the ethereum stack
 */
 type Api struct {
-	dpa *storage.DPA
-	dns Resolver
+	dpa       *storage.DPA
+	dns       Resolver
+	receipter Receipter // nil if push-sync is not available
+	lock      sync.Mutex
+	pushSyncs map[string]*PushSyncStatus // receipt progress of uploads by key
 }
 
 //the api constructor initialises
 func NewApi(dpa *storage.DPA, dns Resolver) (self *Api) {
 	self = &Api{
-		dpa: dpa,
-		dns: dns,
+		dpa:       dpa,
+		dns:       dns,
+		pushSyncs: make(map[string]*PushSyncStatus),
 	}
 	return
 }
@@ -71,6 +75,13 @@ type ErrResolve error
 
 // DNS Resolver
 func (self *Api) Resolve(hostPort string, nameresolver bool) (storage.Key, error) {
+	if owner, topic, ok := parseResourceName(hostPort); ok && nameresolver {
+		update, err := self.LookupResource(owner, topic)
+		if err != nil {
+			return nil, ErrResolve(err)
+		}
+		return update.Content, nil
+	}
 	if hashMatcher.MatchString(hostPort) || self.dns == nil {
 		glog.V(logger.Detail).Infof("host is a contentHash: '%v'", hostPort)
 		return storage.Key(common.Hex2Bytes(hostPort)), nil
@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/swarm/network"
@@ -48,44 +49,38 @@ type Config struct {
 	*network.HiveParams
 	Swap *swap.SwapParams
 	*network.SyncParams
-	Path      string
-	Port      string
-	PublicKey string
-	BzzKey    string
-	EnsRoot   common.Address
-	NetworkId uint64
-	RTMPPort  string
+	Path       string
+	Port       string
+	PublicKey  string
+	BzzKey     string
+	EnsRoot    common.Address
+	NetworkId  uint64
+	RTMPPort   string
+	Transcoder bool   // registers the transcoder discovery topic
+	DbBackend  string // database backend of the names db, see SetDbBackend
 }
 
-// config is agnostic to where private key is coming from
+// config is agnostic to where the account signer is coming from
 // so managing accounts is outside swarm and left to wrappers
-func NewConfig(path string, contract common.Address, prvKey *ecdsa.PrivateKey, networkId uint64, rtmpPort string) (self *Config, err error) {
-	address := crypto.PubkeyToAddress(prvKey.PublicKey) // default beneficiary address
-	dirpath := filepath.Join(path, "bzz-"+common.Bytes2Hex(address.Bytes()))
+func NewConfig(path string, contract common.Address, signer accounts.Signer, networkId uint64, rtmpPort string) (self *Config, err error) {
+	pubKey, err := accounts.SignerPublicKey(signer)
+	if err != nil {
+		return nil, fmt.Errorf("can't get public key of swarm account: %v", err)
+	}
+	dirpath := configDir(path, signer.Address())
 	err = os.MkdirAll(dirpath, os.ModePerm)
 	if err != nil {
 		return
 	}
 	confpath := filepath.Join(dirpath, "config.json")
 	var data []byte
-	pubkey := crypto.FromECDSAPub(&prvKey.PublicKey)
+	pubkey := crypto.FromECDSAPub(pubKey)
 	pubkeyhex := common.ToHex(pubkey)
 	keyhex := crypto.Sha3Hash(pubkey).Hex()
 
-	self = &Config{
-		SyncParams:    network.NewSyncParams(dirpath),
-		HiveParams:    network.NewHiveParams(dirpath),
-		ChunkerParams: storage.NewChunkerParams(),
-		StoreParams:   storage.NewStoreParams(dirpath),
-		Port:          port,
-		Path:          dirpath,
-		Swap:          swap.DefaultSwapParams(contract, prvKey),
-		PublicKey:     pubkeyhex,
-		BzzKey:        keyhex,
-		EnsRoot:       ensRootAddress,
-		NetworkId:     networkId,
-		RTMPPort:      rtmpPort,
-	}
+	self = newDefaultConfig(dirpath, contract, signer, pubKey, networkId, rtmpPort)
+	self.PublicKey = pubkeyhex
+	self.BzzKey = keyhex
 
 	data, err = ioutil.ReadFile(confpath)
 
@@ -114,7 +109,7 @@ func NewConfig(path string, contract common.Address, prvKey *ecdsa.PrivateKey, n
 	if keyhex != self.BzzKey {
 		return nil, fmt.Errorf("bzz key does not match the one in the config file %v != %v", keyhex, self.BzzKey)
 	}
-	self.Swap.SetKey(prvKey)
+	self.Swap.SetSigner(signer, pubKey)
 
 	if (self.EnsRoot == common.Address{}) {
 		self.EnsRoot = ensRootAddress
@@ -123,6 +118,86 @@ func NewConfig(path string, contract common.Address, prvKey *ecdsa.PrivateKey, n
 	return
 }
 
+// ReadConfig returns the configuration of the swarm node of the account with the
+// given address, read from its config file if there is one. Unlike NewConfig it
+// neither needs the account's signer nor writes anything.
+func ReadConfig(path string, address, contract common.Address, networkId uint64, rtmpPort string) (*Config, error) {
+	dirpath := configDir(path, address)
+	self := newDefaultConfig(dirpath, contract, nil, nil, networkId, rtmpPort)
+	data, err := ioutil.ReadFile(filepath.Join(dirpath, "config.json"))
+	if os.IsNotExist(err) {
+		return self, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, self); err != nil {
+		return nil, fmt.Errorf("unable to parse config: %v", err)
+	}
+	if (self.EnsRoot == common.Address{}) {
+		self.EnsRoot = ensRootAddress
+	}
+	return self, nil
+}
+
+// the directory of the swarm node of the account with address, the account's
+// address is the default beneficiary
+func configDir(path string, address common.Address) string {
+	return filepath.Join(path, "bzz-"+common.Bytes2Hex(address.Bytes()))
+}
+
+func newDefaultConfig(dirpath string, contract common.Address, signer accounts.Signer, pubKey *ecdsa.PublicKey, networkId uint64, rtmpPort string) *Config {
+	return &Config{
+		SyncParams:    network.NewSyncParams(dirpath),
+		HiveParams:    network.NewHiveParams(dirpath),
+		ChunkerParams: storage.NewChunkerParams(),
+		StoreParams:   storage.NewStoreParams(dirpath),
+		Port:          port,
+		Path:          dirpath,
+		Swap:          swap.DefaultSwapParams(contract, signer, pubKey),
+		EnsRoot:       ensRootAddress,
+		NetworkId:     networkId,
+		RTMPPort:      rtmpPort,
+	}
+}
+
+// DatabasePaths returns the paths of the databases of the swarm nodes whose
+// directories are in path, see NewConfig.
+func DatabasePaths(path string) ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join(path, "bzz-*"))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, dir := range dirs {
+		// the configuration may place databases elsewhere
+		self := &Config{
+			SyncParams:  network.NewSyncParams(dir),
+			HiveParams:  network.NewHiveParams(dir),
+			StoreParams: storage.NewStoreParams(dir),
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, self); err != nil {
+			return nil, fmt.Errorf("unable to parse config %s: %v", dir, err)
+		}
+		paths = append(paths, self.ChunkDbPath, self.RequestDbPath, self.ReputationDbPath, filepath.Join(dir, "names"))
+	}
+	return paths, nil
+}
+
+// SetDbBackend sets the database backend of all databases of the node. It
+// must match the backend existing databases were created with, see ethdb.Open.
+func (self *Config) SetDbBackend(backend string) {
+	self.DbBackend = backend
+	self.ChunkDbBackend = backend
+	self.RequestDbBackend = backend
+	self.ReputationDbBackend = backend
+}
+
 func (self *Config) Save() error {
 	data, err := json.MarshalIndent(self, "", "    ")
 	if err != nil {
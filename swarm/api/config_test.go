@@ -23,6 +23,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -31,6 +32,7 @@ var (
 	hexprvkey     = "65138b2aa745041b372153550584587da326ab440576b2a1191dd95cee30039c"
 	defaultConfig = `{
     "ChunkDbPath": "` + filepath.Join("TMPDIR", "chunks") + `",
+    "ChunkDbBackend": "",
     "DbCapacity": 5000000,
     "CacheCapacity": 5000,
     "Radius": 0,
@@ -38,6 +40,16 @@ var (
     "Hash": "SHA3",
     "CallInterval": 3000000000,
     "KadDbPath": "` + filepath.Join("TMPDIR", "bzz-peers.json") + `",
+    "ReputationDbPath": "` + filepath.Join("TMPDIR", "bzz-reputation") + `",
+    "ReputationDbBackend": "",
+    "MaxBandwidth": 0,
+    "MaxPeerBandwidth": 0,
+    "TrafficWeights": [
+        8,
+        4,
+        2,
+        1
+    ],
     "MaxProx": 8,
     "ProxBinSize": 2,
     "BucketSize": 4,
@@ -60,6 +72,7 @@ var (
         "Beneficiary": "0x0d2f62485607cf38d9d795d93682a517661e513e"
     },
     "RequestDbPath": "` + filepath.Join("TMPDIR", "requests") + `",
+    "RequestDbBackend": "",
     "RequestDbBatchSize": 512,
     "KeyBufferSize": 1024,
     "SyncBatchSize": 128,
@@ -84,7 +97,10 @@ var (
     "PublicKey": "0x045f5cfd26692e48d0017d380349bcf50982488bc11b5145f3ddf88b24924299048450542d43527fbe29a5cb32f38d62755393ac002e6bfdd71b8d7ba725ecd7a3",
     "BzzKey": "0xe861964402c0b78e2d44098329b8545726f215afa737d803714a4338552fcb81",
     "EnsRoot": "0x112234455c3a32fd11230c42e7bccd4a84e02010",
-    "NetworkId": 323
+    "NetworkId": 323,
+    "RTMPPort": "",
+    "Transcoder": false,
+    "DbBackend": ""
 }`
 )
 
@@ -96,7 +112,7 @@ func TestConfigWriteRead(t *testing.T) {
 	defer os.RemoveAll(tmp)
 
 	prvkey := crypto.ToECDSA(common.Hex2Bytes(hexprvkey))
-	orig, err := NewConfig(tmp, common.Address{}, prvkey, 323)
+	orig, err := NewConfig(tmp, common.Address{}, accounts.NewKeySigner(prvkey), 323, "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -110,7 +126,7 @@ func TestConfigWriteRead(t *testing.T) {
 		t.Fatalf("default config mismatch:\nexpected: %v\ngot: %v", exp, string(data))
 	}
 
-	conf, err := NewConfig(tmp, common.Address{}, prvkey, 323)
+	conf, err := NewConfig(tmp, common.Address{}, accounts.NewKeySigner(prvkey), 323, "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -20,9 +20,16 @@ A simple http server interface to Swarm
 package http
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -32,11 +39,14 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
 	"github.com/rs/cors"
 )
 
 const (
-	rawType = "application/octet-stream"
+	rawType       = "application/octet-stream"
+	tarType       = "application/x-tar"
+	multipartType = "multipart/form-data"
 )
 
 var (
@@ -135,11 +145,27 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 
 	switch {
 	case r.Method == "POST" || r.Method == "PUT":
+		if r.Method == "POST" && !raw {
+			mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err == nil && (mediaType == tarType || mediaType == multipartType) {
+				handleDirectoryUpload(w, r, a, path, mediaType, params, nameresolver)
+				return
+			}
+		}
 		if r.Header.Get("content-length") == "" {
 			http.Error(w, "Missing Content-Length header in request.", http.StatusBadRequest)
 			return
 		}
-		key, err := a.Store(io.LimitReader(r.Body, r.ContentLength), r.ContentLength, nil)
+		var (
+			key storage.Key
+			err error
+		)
+		if r.URL.Query().Get("sync") == "true" {
+			// block until every chunk has a storage receipt
+			key, err = a.StoreWithReceipts(io.LimitReader(r.Body, r.ContentLength), r.ContentLength)
+		} else {
+			key, err = a.Store(io.LimitReader(r.Body, r.ContentLength), r.ContentLength, nil)
+		}
 		if err == nil {
 			glog.V(logger.Debug).Infof("Content for %v stored", key.Log())
 		} else {
@@ -206,6 +232,7 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 			// retrieving content
 			reader := a.Retrieve(key)
 			quitC := make(chan bool)
+			defer close(quitC)
 			size, err := reader.Size(quitC)
 			if err != nil {
 				glog.V(logger.Debug).Infof("Could not determine size: %v", err.Error())
@@ -254,6 +281,7 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 				status = 200
 			}
 			quitC := make(chan bool)
+			defer close(quitC)
 			size, err := reader.Size(quitC)
 			if err != nil {
 				glog.V(logger.Debug).Infof("Could not determine size: %v", err.Error())
@@ -271,6 +299,169 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 	}
 }
 
+// handleDirectoryUpload stores all files of a tar archive or multipart form
+// sent in a single POST request and builds their manifest server-side.
+// Files are added under the path of the request URL to the manifest its host
+// resolves to, or to a new manifest if the URL has no host (bzz:/).
+// The query parameter defaultpath names the file served for the empty path.
+func handleDirectoryUpload(w http.ResponseWriter, r *http.Request, a *api.Api, path, mediaType string, params map[string]string, nameresolver bool) {
+	path = api.RegularSlashes(path)
+	var host, prefix string
+	if parts := strings.SplitN(path, "/", 2); len(parts) == 2 {
+		host, prefix = parts[0], parts[1]+"/"
+	} else {
+		host = parts[0]
+	}
+
+	var root storage.Key
+	if host != "" {
+		var err error
+		root, err = a.Resolve(host, nameresolver)
+		if err != nil {
+			glog.V(logger.Debug).Infof("%v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	quitC := make(chan bool)
+	defer close(quitC)
+	writer, err := a.NewManifestWriter(root, quitC)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	defaultPath := api.RegularSlashes(r.URL.Query().Get("defaultpath"))
+	var defaultKey storage.Key
+	var defaultType string
+	add := func(name string, data io.Reader, size int64, contentType string) error {
+		name = api.RegularSlashes(filepath.ToSlash(name))
+		if name == "" {
+			return fmt.Errorf("empty file name in upload")
+		}
+		key, err := writer.AddEntry(data, size, prefix+name, contentType)
+		if err != nil {
+			return err
+		}
+		glog.V(logger.Debug).Infof("Content for '%s' stored as %v", name, key.Log())
+		if name == defaultPath {
+			defaultKey, defaultType = key, contentType
+		}
+		return nil
+	}
+
+	switch mediaType {
+	case tarType:
+		err = readTarUpload(r.Body, add)
+	case multipartType:
+		if params["boundary"] == "" {
+			err = fmt.Errorf("missing multipart boundary")
+		} else {
+			err = readMultipartUpload(r, add)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Directory upload failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if defaultPath != "" {
+		if defaultKey == nil {
+			http.Error(w, "Default path '"+defaultPath+"' not found in upload.", http.StatusBadRequest)
+			return
+		}
+		writer.AddHash(prefix, defaultKey, defaultType)
+	}
+
+	key, err := writer.Store()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	glog.V(logger.Debug).Infof("Swarm stored directory upload as manifest %v", key.Log())
+	w.Header().Set("Content-Type", "text/plain")
+	http.ServeContent(w, r, "", time.Now(), bytes.NewReader([]byte(key.String())))
+}
+
+// readTarUpload calls add for every regular file in a tar archive
+func readTarUpload(body io.Reader, add func(string, io.Reader, int64, string) error) error {
+	tr := tar.NewReader(body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		data := bufio.NewReader(tr)
+		if err := add(hdr.Name, data, hdr.Size, detectContentType(hdr.Name, data)); err != nil {
+			return err
+		}
+	}
+}
+
+// readMultipartUpload calls add for every file in a multipart form.
+// Since parts carry no size, each one is spooled to a temporary file first.
+func readMultipartUpload(r *http.Request, add func(string, io.Reader, int64, string) error) error {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// part.FileName() strips directories, so the header is parsed here
+		_, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		if err != nil || params["filename"] == "" {
+			// not a file, e.g. a plain form field
+			continue
+		}
+		name := params["filename"]
+		err = func() error {
+			f, err := ioutil.TempFile("", "swarm-upload")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			size, err := io.Copy(f, part)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Seek(0, 0); err != nil {
+				return err
+			}
+			data := bufio.NewReader(f)
+			contentType := part.Header.Get("Content-Type")
+			if contentType == "" || contentType == rawType {
+				contentType = detectContentType(name, data)
+			}
+			return add(name, data, size, contentType)
+		}()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// detectContentType guesses the mime type of a file from its extension,
+// falling back to sniffing the beginning of its content
+func detectContentType(name string, data *bufio.Reader) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+	head, _ := data.Peek(512)
+	return http.DetectContentType(head)
+}
+
 func (self *sequentialReader) ReadAt(target []byte, off int64) (n int, err error) {
 	self.lock.Lock()
 	// assert self.pos <= off
@@ -0,0 +1,142 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+var testFiles = map[string]string{
+	"index.html":     "<html><body>hello</body></html>",
+	"css/index.css":  "body { color: red; }",
+	"img/logo/a.txt": "some text",
+}
+
+func testServer(t *testing.T, f func(*api.Api, *httptest.Server)) {
+	datadir, err := ioutil.TempDir("", "bzz-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+	dpa, err := storage.NewLocalDPA(datadir)
+	if err != nil {
+		t.Fatalf("unable to create dpa: %v", err)
+	}
+	a := api.NewApi(dpa, nil)
+	dpa.Start()
+	defer dpa.Stop()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, a)
+	}))
+	defer srv.Close()
+	f(a, srv)
+}
+
+func postDirectory(t *testing.T, url, contentType string, body *bytes.Buffer) string {
+	resp, err := http.Post(url, contentType, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	hash, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %v: %s", resp.Status, hash)
+	}
+	return string(hash)
+}
+
+func checkDirectory(t *testing.T, a *api.Api, hash string, files map[string]string) {
+	for path, content := range files {
+		reader, mimeType, _, err := a.Get(hash+"/"+path, true)
+		if err != nil {
+			t.Fatalf("unexpected error retrieving %s: %v", path, err)
+		}
+		size, err := reader.Size(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data := make([]byte, size)
+		reader.Read(data)
+		if string(data) != content {
+			t.Errorf("incorrect content for %s: expected '%s', got '%s'", path, content, data)
+		}
+		if mimeType == "" {
+			t.Errorf("missing content type for %s", path)
+		}
+	}
+}
+
+func TestDirectoryUploadTar(t *testing.T) {
+	testServer(t, func(a *api.Api, srv *httptest.Server) {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for path, content := range testFiles {
+			hdr := &tar.Header{Name: path, Mode: 0644, Size: int64(len(content))}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+
+		hash := postDirectory(t, srv.URL+"/bzz:/?defaultpath=index.html", "application/x-tar", buf)
+		checkDirectory(t, a, hash, testFiles)
+		checkDirectory(t, a, hash, map[string]string{"": testFiles["index.html"]})
+
+		// add to the existing manifest under a subdirectory
+		buf = &bytes.Buffer{}
+		tw = tar.NewWriter(buf)
+		tw.WriteHeader(&tar.Header{Name: "new.txt", Mode: 0644, Size: 3})
+		tw.Write([]byte("new"))
+		tw.Close()
+		hash = postDirectory(t, srv.URL+"/bzz:/"+hash+"/sub", "application/x-tar", buf)
+		checkDirectory(t, a, hash, testFiles)
+		checkDirectory(t, a, hash, map[string]string{"sub/new.txt": "new"})
+	})
+}
+
+func TestDirectoryUploadMultipart(t *testing.T) {
+	testServer(t, func(a *api.Api, srv *httptest.Server) {
+		buf := &bytes.Buffer{}
+		mw := multipart.NewWriter(buf)
+		mw.WriteField("comment", "not a file")
+		for path, content := range testFiles {
+			w, err := mw.CreateFormFile("file", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(content))
+		}
+		mw.Close()
+
+		hash := postDirectory(t, srv.URL+"/bzz:/", mw.FormDataContentType(), buf)
+		checkDirectory(t, a, hash, testFiles)
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -339,3 +340,64 @@ func (self *manifestTrie) getEntry(spath string) (entry *manifestTrieEntry, full
 	entry, pos = self.findPrefixOf(path, quitC)
 	return entry, path[:pos]
 }
+
+// ManifestWriter adds entries to a manifest and stores the result in one go,
+// so that a whole directory can be uploaded with a single request
+type ManifestWriter struct {
+	dpa   *storage.DPA
+	trie  *manifestTrie
+	quitC chan bool
+}
+
+// NewManifestWriter returns a ManifestWriter on top of the manifest with root
+// key; if key is nil, entries are added to a new empty manifest
+func (self *Api) NewManifestWriter(key storage.Key, quitC chan bool) (*ManifestWriter, error) {
+	var trie *manifestTrie
+	if key == nil {
+		trie = &manifestTrie{
+			dpa: self.dpa,
+		}
+	} else {
+		var err error
+		trie, err = loadManifest(self.dpa, key, quitC)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ManifestWriter{
+		dpa:   self.dpa,
+		trie:  trie,
+		quitC: quitC,
+	}, nil
+}
+
+// AddEntry stores size bytes of data and adds it to the manifest under path
+func (self *ManifestWriter) AddEntry(data io.Reader, size int64, path, contentType string) (storage.Key, error) {
+	wg := &sync.WaitGroup{}
+	key, err := self.dpa.Store(data, size, wg, nil)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	self.AddHash(path, key, contentType)
+	return key, nil
+}
+
+// AddHash adds already stored content to the manifest under path
+func (self *ManifestWriter) AddHash(path string, key storage.Key, contentType string) {
+	entry := &manifestTrieEntry{
+		Path:        RegularSlashes(path),
+		Hash:        key.String(),
+		ContentType: contentType,
+	}
+	self.trie.addEntry(entry, self.quitC)
+}
+
+// Store stores the manifest and returns its root key
+func (self *ManifestWriter) Store() (storage.Key, error) {
+	err := self.trie.recalcAndStore()
+	if err != nil {
+		return nil, err
+	}
+	return self.trie.hash, nil
+}
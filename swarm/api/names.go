@@ -0,0 +1,143 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+/*
+LocalResolver is a Resolver backed by a database on the local node.
+It stands in for ENS on networks without a chain (e.g. air-gapped testnets)
+and can be used as a fallback behind ENS (see NewMultiResolver).
+Names are managed via the bzz RPC API (bzz.setName, bzz.names).
+*/
+type LocalResolver struct {
+	db   ethdb.Database
+	lock sync.RWMutex
+}
+
+// NewLocalResolver opens (or creates) the name database at path with the
+// given database backend
+func NewLocalResolver(backend, path string) (*LocalResolver, error) {
+	db, err := ethdb.Open(backend, path, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalResolver{db: db}, nil
+}
+
+// names are case insensitive like ENS names
+func normaliseName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Resolve implements Resolver
+func (self *LocalResolver) Resolve(name string) (common.Hash, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	data, err := self.db.Get([]byte(normaliseName(name)))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("name '%s' not registered locally", name)
+	}
+	return common.BytesToHash(data), nil
+}
+
+// SetName points name to the content hash, a zero hash removes the name
+func (self *LocalResolver) SetName(name string, hash common.Hash) error {
+	name = normaliseName(name)
+	if name == "" {
+		return fmt.Errorf("empty name")
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if (hash == common.Hash{}) {
+		glog.V(logger.Debug).Infof("local name '%s' removed", name)
+		return self.db.Delete([]byte(name))
+	}
+	glog.V(logger.Debug).Infof("local name '%s' set to %v", name, hash.Hex())
+	return self.db.Put([]byte(name), hash[:])
+}
+
+// Names returns all locally registered names with their content hash
+func (self *LocalResolver) Names() map[string]common.Hash {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	names := make(map[string]common.Hash)
+	it := self.db.NewIteratorWithPrefix(nil)
+	defer it.Release()
+	for it.Next() {
+		names[string(it.Key())] = common.BytesToHash(it.Value())
+	}
+	return names
+}
+
+func (self *LocalResolver) Close() {
+	self.db.Close()
+}
+
+// multiResolver tries each of its resolvers in turn
+type multiResolver []Resolver
+
+// NewMultiResolver returns a Resolver that returns the first successful
+// resolution of its resolvers in order, e.g. ENS with a local fallback
+func NewMultiResolver(resolvers ...Resolver) Resolver {
+	return multiResolver(resolvers)
+}
+
+func (self multiResolver) Resolve(name string) (hash common.Hash, err error) {
+	err = fmt.Errorf("no resolver for '%s'", name)
+	for _, r := range self {
+		if hash, err = r.Resolve(name); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// Names is the RPC service managing the names of the local resolver
+type Names struct {
+	resolver *LocalResolver
+}
+
+func NewNames(resolver *LocalResolver) *Names {
+	return &Names{resolver}
+}
+
+// SetName registers name for the content hash, an empty hash removes it
+func (self *Names) SetName(name, contentHash string) error {
+	var hash common.Hash
+	if contentHash != "" {
+		if !hashMatcher.MatchString(strings.TrimPrefix(contentHash, "0x")) {
+			return fmt.Errorf("'%s' is not a content hash value", contentHash)
+		}
+		hash = common.HexToHash(contentHash)
+	}
+	return self.resolver.SetName(name, hash)
+}
+
+// Names lists the locally registered names
+func (self *Names) Names() map[string]common.Hash {
+	return self.resolver.Names()
+}
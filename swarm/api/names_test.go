@@ -0,0 +1,100 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testResolver map[string]common.Hash
+
+func (self testResolver) Resolve(name string) (common.Hash, error) {
+	if hash, ok := self[name]; ok {
+		return hash, nil
+	}
+	return common.Hash{}, fmt.Errorf("not found")
+}
+
+func TestLocalResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "names")
+	resolver, err := NewLocalResolver("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := NewNames(resolver)
+	hash := "0x4000000000000000000000000000000000000000000000000000000000000001"
+	if err := names.SetName("Site.test", hash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := names.SetName("other.test", "nohash"); err == nil {
+		t.Fatalf("expected error setting invalid hash")
+	}
+	if err := names.SetName("gone.test", hash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := names.SetName("gone.test", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list := names.Names(); len(list) != 1 || list["site.test"].Hex() != hash {
+		t.Fatalf("unexpected names: %v", list)
+	}
+
+	// names persist
+	resolver.Close()
+	if err := resolver.SetName("closed.test", common.HexToHash(hash)); err == nil {
+		t.Fatalf("expected error setting name in closed database")
+	}
+	resolver, err = NewLocalResolver("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resolver.Close()
+	if got, err := resolver.Resolve("site.TEST"); err != nil || got.Hex() != hash {
+		t.Fatalf("expected %v, got %v (%v)", hash, got.Hex(), err)
+	}
+	if _, err := resolver.Resolve("gone.test"); err == nil {
+		t.Fatalf("expected error resolving removed name")
+	}
+
+	// the local resolver serves as fallback
+	ens := testResolver{"site.test": common.HexToHash("0x01")}
+	multi := NewMultiResolver(ens, resolver)
+	if got, _ := multi.Resolve("site.test"); got != common.HexToHash("0x01") {
+		t.Fatalf("expected first resolver to take precedence, got %v", got.Hex())
+	}
+	if err := resolver.SetName("local.test", common.HexToHash(hash)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := multi.Resolve("local.test"); err != nil || got.Hex() != hash {
+		t.Fatalf("expected fallback to %v, got %v (%v)", hash, got.Hex(), err)
+	}
+	if _, err := multi.Resolve("missing.test"); err == nil {
+		t.Fatalf("expected error resolving missing name")
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/network"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const (
+	maxParallelReceipts = 16        // number of receipts requested in parallel for an upload
+	pushSyncStatusTTL   = time.Hour // time the status of a finished push-sync is kept
+)
+
+// Receipter obtains a storage receipt for a chunk from the node responsible
+// for it, implemented by network.PushSync
+type Receipter interface {
+	Receipt(key storage.Key) (*network.Receipt, error)
+}
+
+// PushSyncStatus is the progress of obtaining receipts for the chunks of an
+// upload
+type PushSyncStatus struct {
+	Chunks   int    `json:"chunks"`
+	Receipts int    `json:"receipts"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
+
+	finished time.Time
+}
+
+func (self *Api) SetReceipter(receipter Receipter) {
+	self.receipter = receipter
+}
+
+// StoreWithReceipts stores data like Store, then blocks until a receipt is
+// obtained for every chunk of it (see PushSync)
+func (self *Api) StoreWithReceipts(data io.Reader, size int64) (storage.Key, error) {
+	wg := &sync.WaitGroup{}
+	key, err := self.Store(data, size, wg)
+	if err != nil {
+		return nil, err
+	}
+	wg.Wait()
+	return key, self.PushSync(key)
+}
+
+// PushSync obtains receipts for all chunks of the content with key from the
+// nodes responsible for them. Progress is reported by PushSyncStatus.
+func (self *Api) PushSync(key storage.Key) error {
+	if self.receipter == nil {
+		return fmt.Errorf("push-sync not available")
+	}
+	keys, err := storage.ChunkKeys(key, self.dpa)
+	if err != nil {
+		return err
+	}
+	status := &PushSyncStatus{Chunks: len(keys)}
+	self.lock.Lock()
+	self.prunePushSyncs(time.Now())
+	self.pushSyncs[key.String()] = status
+	self.lock.Unlock()
+	glog.V(logger.Debug).Infof("push-sync of %v: requesting receipts for %d chunks", key.Log(), len(keys))
+
+	var (
+		wg    sync.WaitGroup
+		keyC  = make(chan storage.Key)
+		errC  = make(chan error, len(keys))
+		count = func(err error) {
+			self.lock.Lock()
+			defer self.lock.Unlock()
+			if err != nil {
+				status.Error = err.Error()
+				errC <- err
+				return
+			}
+			status.Receipts++
+		}
+	)
+	for i := 0; i < maxParallelReceipts && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keyC {
+				receipt, err := self.receipter.Receipt(key)
+				if err == nil {
+					storer, _ := receipt.Storer()
+					glog.V(logger.Detail).Infof("push-sync: receipt for %v from %v", key.Log(), storer)
+				}
+				count(err)
+			}
+		}()
+	}
+	for _, key := range keys {
+		keyC <- key
+	}
+	close(keyC)
+	wg.Wait()
+	close(errC)
+
+	self.lock.Lock()
+	status.Done = true
+	status.finished = time.Now()
+	self.lock.Unlock()
+	if err := <-errC; err != nil {
+		return fmt.Errorf("push-sync of %v: %d of %d chunks without receipt: %v", key.Log(), status.Chunks-status.Receipts, status.Chunks, err)
+	}
+	glog.V(logger.Debug).Infof("push-sync of %v: all %d chunks receipted", key.Log(), len(keys))
+	return nil
+}
+
+// removes the status of push-syncs finished more than pushSyncStatusTTL
+// before now, caller holds the lock
+func (self *Api) prunePushSyncs(now time.Time) {
+	for key, status := range self.pushSyncs {
+		if status.Done && now.Sub(status.finished) > pushSyncStatusTTL {
+			delete(self.pushSyncs, key)
+		}
+	}
+}
+
+// PushSyncStatus returns the receipt progress of the content with key
+// the status of finished push-syncs is kept for pushSyncStatusTTL
+func (self *Api) PushSyncStatus(key storage.Key) (*PushSyncStatus, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	status, ok := self.pushSyncs[key.String()]
+	if !ok {
+		return nil, fmt.Errorf("no push-sync for %v", key.Log())
+	}
+	s := *status
+	return &s, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/swarm/network"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// testReceipter issues receipts for all chunks except the ones in missing
+type testReceipter struct {
+	lock     sync.Mutex
+	receipts map[string]bool
+	missing  map[string]bool
+}
+
+func (self *testReceipter) Receipt(key storage.Key) (*network.Receipt, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.missing[key.String()] {
+		return nil, fmt.Errorf("no receipt")
+	}
+	self.receipts[key.String()] = true
+	return &network.Receipt{Key: key}, nil
+}
+
+func TestApiPushSync(t *testing.T) {
+	testApi(t, func(api *Api) {
+		if err := api.PushSync(storage.ZeroKey); err == nil {
+			t.Fatalf("expected error without receipter")
+		}
+		receipter := &testReceipter{receipts: make(map[string]bool)}
+		api.SetReceipter(receipter)
+
+		// 4 data chunks and their parent
+		data := make([]byte, 3*4096+1)
+		rand.Read(data)
+		key, err := api.StoreWithReceipts(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(receipter.receipts) != 5 || !receipter.receipts[key.String()] {
+			t.Fatalf("expected receipts for 5 chunks including the root, got %v", receipter.receipts)
+		}
+		status, err := api.PushSyncStatus(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status.Chunks != 5 || status.Receipts != 5 || !status.Done || status.Error != "" {
+			t.Fatalf("unexpected status %+v", status)
+		}
+
+		receipter.missing = map[string]bool{key.String(): true}
+		if err := api.PushSync(key); err == nil {
+			t.Fatalf("expected error for missing receipt")
+		}
+		status, _ = api.PushSyncStatus(key)
+		if status.Receipts != 4 || !status.Done || status.Error == "" {
+			t.Fatalf("unexpected status %+v", status)
+		}
+	})
+}
+
+func TestPrunePushSyncs(t *testing.T) {
+	now := time.Now()
+	api := &Api{
+		pushSyncs: map[string]*PushSyncStatus{
+			"running":  {Chunks: 1},
+			"finished": {Chunks: 1, Receipts: 1, Done: true, finished: now.Add(-time.Minute)},
+			"expired":  {Chunks: 1, Receipts: 1, Done: true, finished: now.Add(-2 * pushSyncStatusTTL)},
+		},
+	}
+	api.prunePushSyncs(now)
+	if len(api.pushSyncs) != 2 || api.pushSyncs["expired"] != nil {
+		t.Fatalf("unexpected push-syncs after pruning: %v", api.pushSyncs)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// resource names are of the form <owner>.<topic>, e.g. bzz://0x1234...abcd.vod
+var resourceMatcher = regexp.MustCompile("^(?:0x)?([0-9A-Fa-f]{40})\\.(.+)$")
+
+// parseResourceName splits a resource name into owner and topic
+func parseResourceName(name string) (owner common.Address, topic string, ok bool) {
+	m := resourceMatcher.FindStringSubmatch(name)
+	if m == nil || len(m[2]) > storage.MaxResourceTopicLength {
+		return
+	}
+	return common.HexToAddress(m[1]), m[2], true
+}
+
+// getResourceUpdate retrieves and validates the given version of a resource,
+// it returns nil if the version does not exist or is invalid
+func (self *Api) getResourceUpdate(owner common.Address, topic string, version uint64) *storage.ResourceUpdate {
+	key := storage.ResourceKey(owner, topic, version)
+	chunk, err := self.dpa.Get(key)
+	if err != nil || chunk.SData == nil {
+		return nil
+	}
+	update, err := storage.ParseResourceUpdate(key, chunk.SData)
+	if err != nil {
+		glog.V(logger.Warn).Infof("invalid resource update %v: %v", key.Log(), err)
+		return nil
+	}
+	return update
+}
+
+// LookupResource returns the latest valid update of the resource identified
+// by owner and topic. Versions start at 1 and are consecutive, so the latest
+// one is found by doubling the version until an update is missing, followed by
+// a binary search; the number of chunk lookups is logarithmic in the version.
+func (self *Api) LookupResource(owner common.Address, topic string) (*storage.ResourceUpdate, error) {
+	latest := self.getResourceUpdate(owner, topic, 1)
+	if latest == nil {
+		return nil, fmt.Errorf("resource %x.%s not found", owner, topic)
+	}
+	lo, hi := uint64(1), uint64(2)
+	for {
+		update := self.getResourceUpdate(owner, topic, hi)
+		if update == nil {
+			break
+		}
+		latest, lo, hi = update, hi, hi*2
+	}
+	// invariant: version lo exists, version hi does not
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if update := self.getResourceUpdate(owner, topic, mid); update != nil {
+			latest, lo = update, mid
+		} else {
+			hi = mid
+		}
+	}
+	glog.V(logger.Detail).Infof("resource %x.%s resolved to version %d: %v", owner, topic, latest.Version, latest.Content.Log())
+	return latest, nil
+}
+
+// PublishResource validates an update signed by its owner and stores it
+func (self *Api) PublishResource(update *storage.ResourceUpdate) error {
+	if update.Version == 0 {
+		return fmt.Errorf("resource versions start at 1")
+	}
+	if err := update.Verify(); err != nil {
+		return err
+	}
+	chunk, err := update.Chunk()
+	if err != nil {
+		return err
+	}
+	self.dpa.Put(chunk)
+	glog.V(logger.Debug).Infof("resource %x.%s updated to version %d: %v", update.Owner, update.Topic, update.Version, update.Content.Log())
+	return nil
+}
+
+// UpdateResource points the resource of the account of signer with topic to
+// content, using the version following the latest one found
+func (self *Api) UpdateResource(signer accounts.Signer, topic string, content storage.Key) (*storage.ResourceUpdate, error) {
+	update := &storage.ResourceUpdate{
+		Topic:   topic,
+		Version: 1,
+		Content: content,
+	}
+	if latest, err := self.LookupResource(signer.Address(), topic); err == nil {
+		update.Version = latest.Version + 1
+	}
+	if err := update.Sign(signer); err != nil {
+		return nil, err
+	}
+	if err := self.PublishResource(update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// Resource is the RPC service for mutable resources, updates are signed with
+// the swarm account key of the node
+type Resource struct {
+	api    *Api
+	signer accounts.Signer
+}
+
+func NewResource(api *Api, signer accounts.Signer) *Resource {
+	return &Resource{api, signer}
+}
+
+// ResourceInfo describes a resource update
+type ResourceInfo struct {
+	Owner   common.Address `json:"owner"`
+	Topic   string         `json:"topic"`
+	Version uint64         `json:"version"`
+	Content storage.Key    `json:"content"`
+}
+
+func newResourceInfo(update *storage.ResourceUpdate) *ResourceInfo {
+	return &ResourceInfo{update.Owner, update.Topic, update.Version, update.Content}
+}
+
+// LookupResource returns the latest version of the resource owner.topic
+func (self *Resource) LookupResource(owner common.Address, topic string) (*ResourceInfo, error) {
+	update, err := self.api.LookupResource(owner, topic)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceInfo(update), nil
+}
+
+// UpdateResource points the resource topic owned by this node to the
+// content hash and returns the new version
+func (self *Resource) UpdateResource(topic, contentHash string) (*ResourceInfo, error) {
+	if !hashMatcher.MatchString(contentHash) {
+		return nil, fmt.Errorf("'%s' is not a content hash value", contentHash)
+	}
+	update, err := self.api.UpdateResource(self.signer, topic, storage.Key(common.Hex2Bytes(contentHash)))
+	if err != nil {
+		return nil, err
+	}
+	return newResourceInfo(update), nil
+}
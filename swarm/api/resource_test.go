@@ -0,0 +1,76 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func TestApiResource(t *testing.T) {
+	testApi(t, func(api *Api) {
+		prvKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		owner := crypto.PubkeyToAddress(prvKey.PublicKey)
+		name := fmt.Sprintf("%x.vod", owner)
+		if _, err := api.Resolve(name, true); err == nil {
+			t.Fatalf("expected error resolving missing resource")
+		}
+
+		var hash string
+		for i := 1; i <= 11; i++ {
+			content := fmt.Sprintf("manifest %d", i)
+			hash, err = api.Put(content, "text/plain")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			update, err := api.UpdateResource(accounts.NewKeySigner(prvKey), "vod", storage.Key(common.Hex2Bytes(hash)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if update.Version != uint64(i) {
+				t.Fatalf("expected version %d, got %d", i, update.Version)
+			}
+		}
+
+		key, err := api.Resolve(name, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key.String() != hash {
+			t.Fatalf("expected resource to resolve to %v, got %v", hash, key)
+		}
+		resp := testGet(t, api, "0x"+name)
+		checkResponse(t, resp, expResponse("manifest 11", "text/plain", 0))
+
+		// updates not signed by the owner are rejected
+		other, _ := crypto.GenerateKey()
+		update := &storage.ResourceUpdate{Topic: "vod", Version: 12, Content: key}
+		update.Sign(accounts.NewKeySigner(other))
+		update.Owner = owner
+		if err := api.PublishResource(update); err == nil {
+			t.Fatalf("expected error publishing update signed by another key")
+		}
+	})
+}
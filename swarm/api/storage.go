@@ -16,6 +16,15 @@
 
 package api
 
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
 type Response struct {
 	MimeType string
 	Status   int
@@ -68,3 +77,28 @@ func (self *Storage) Get(bzzpath string) (*Response, error) {
 func (self *Storage) Modify(rootHash, path, contentHash, contentType string) (newRootHash string, err error) {
 	return self.api.Modify(rootHash+"/"+path, contentHash, contentType, true)
 }
+
+// PushSync starts obtaining storage receipts for all chunks of the content
+// with the given hash in the background, progress is reported by
+// PushSyncStatus
+func (self *Storage) PushSync(contentHash string) error {
+	if !hashMatcher.MatchString(contentHash) {
+		return fmt.Errorf("'%s' is not a content hash value", contentHash)
+	}
+	key := storage.Key(common.Hex2Bytes(contentHash))
+	go func() {
+		if err := self.api.PushSync(key); err != nil {
+			glog.V(logger.Warn).Infof("%v", err)
+		}
+	}()
+	return nil
+}
+
+// PushSyncStatus reports how many chunks of the content with the given hash
+// have a storage receipt
+func (self *Storage) PushSyncStatus(contentHash string) (*PushSyncStatus, error) {
+	if !hashMatcher.MatchString(contentHash) {
+		return nil, fmt.Errorf("'%s' is not a content hash value", contentHash)
+	}
+	return self.api.PushSyncStatus(storage.Key(common.Hex2Bytes(contentHash)))
+}
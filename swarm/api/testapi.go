@@ -17,7 +17,12 @@
 package api
 
 import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/swarm/network"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
 )
 
 type Control struct {
@@ -44,3 +49,43 @@ func (self *Control) SwapEnabled(on bool) {
 func (self *Control) Hive() string {
 	return self.hive.String()
 }
+
+// Reputation returns the reputation of known peers by bzz address
+func (self *Control) Reputation() map[string]network.Reputation {
+	reputations := make(map[string]network.Reputation)
+	for addr, rep := range self.hive.Reputations() {
+		reputations[addr.String()] = rep
+	}
+	return reputations
+}
+
+// SyncStatus returns the synchronisation progress with each connected peer
+func (self *Control) SyncStatus() []*network.SyncStatus {
+	return self.hive.SyncStatus()
+}
+
+// Resync offers the peer all chunks with address in the range (start, stop]
+// again. Empty bounds stand for the start and the end of the address space
+func (self *Control) Resync(peer, start, stop string) error {
+	startKey := storage.Key(common.HexToHash(start).Bytes())
+	stopKey := storage.Key(bytes.Repeat([]byte{0xff}, common.HashLength))
+	if stop != "" {
+		stopKey = storage.Key(common.HexToHash(stop).Bytes())
+	}
+	return self.hive.Resync(kademlia.Address(common.HexToHash(peer)), startKey, stopKey)
+}
+
+// Debug exposes the internal state of the network layer
+type Debug struct {
+	hive *network.Hive
+}
+
+func NewDebug(hive *network.Hive) *Debug {
+	return &Debug{hive}
+}
+
+// BzzSyncState returns the internal state of the syncer with each connected
+// peer: sync status, request queues and persisted backlog by priority
+func (self *Debug) BzzSyncState() []*network.SyncDebug {
+	return self.hive.SyncDebug()
+}
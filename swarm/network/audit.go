@@ -0,0 +1,242 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+/*
+Storage audits (proof of custody)
+
+Peers that accept store requests are periodically challenged to prove they
+still hold the chunks sent to them. The challenger sends an auditRequestMsg
+with the key of a chunk it stored with the peer and a random nonce. The holder
+responds with an auditResponseMsg carrying the hash over the nonce and the
+chunk data, which it can only compute if it has the data. The challenger
+computes the same hash from its local copy.
+
+Outcomes are recorded per bzz address in the hive. Peers that fail
+maxAuditFailures audits in a row are deprioritised for retrieval and sync
+until they pass an audit again. Outcomes also count towards the reputation
+of the peer (see reputation.go).
+*/
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const (
+	auditInterval    = 5 * time.Minute // interval between audit rounds over all peers
+	auditTimeout     = 10 * time.Second
+	auditKeyBuffer   = 64 // number of keys stored with a peer remembered for audits
+	auditNonceLength = 32
+	maxAuditFailures = 3 // consecutive failures after which a peer is deprioritised
+)
+
+// auditProof is the response to a challenge for a chunk
+func auditProof(nonce, sdata []byte) []byte {
+	return crypto.Keccak256(nonce, sdata)
+}
+
+// pending challenge sent to a peer
+type pendingAudit struct {
+	key   storage.Key
+	proof []byte
+	timer *time.Timer
+}
+
+// peerAudits is the per connection audit state
+type peerAudits struct {
+	lock    sync.Mutex
+	keys    []storage.Key // ring buffer of keys stored with the peer
+	next    int
+	pending map[uint64]*pendingAudit
+}
+
+func newPeerAudits() *peerAudits {
+	return &peerAudits{
+		pending: make(map[uint64]*pendingAudit),
+	}
+}
+
+// remember a key stored with the peer as a candidate for audits
+func (self *peerAudits) stored(key storage.Key) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.keys) < auditKeyBuffer {
+		self.keys = append(self.keys, key)
+		return
+	}
+	self.keys[self.next] = key
+	self.next = (self.next + 1) % auditKeyBuffer
+}
+
+// random key stored with the peer, nil if none
+func (self *peerAudits) randomKey() storage.Key {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.keys) == 0 {
+		return nil
+	}
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(self.keys))))
+	if err != nil {
+		return nil
+	}
+	return self.keys[i.Int64()]
+}
+
+// sends a challenge for a chunk stored with the peer, the outcome is
+// reported to the hive on response or timeout
+func (self *bzz) audit(key storage.Key) error {
+	chunk, err := self.dbAccess.get(key)
+	if err != nil || chunk.SData == nil {
+		// we can only audit chunks we hold ourselves
+		return nil
+	}
+	nonce := make([]byte, auditNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	id := generateId()
+	a := self.audits
+	a.lock.Lock()
+	a.pending[id] = &pendingAudit{
+		key:   key,
+		proof: auditProof(nonce, chunk.SData),
+		timer: time.AfterFunc(auditTimeout, func() {
+			self.auditResult(id, nil)
+		}),
+	}
+	a.lock.Unlock()
+	glog.V(logger.Detail).Infof("audit %v: challenge %v sent to %v", id, key.Log(), self)
+	return self.send(auditRequestMsg, &auditRequestMsgData{
+		Id:    id,
+		Key:   key,
+		Nonce: nonce,
+	})
+}
+
+// checks the proof received for a pending challenge, a nil proof is a failure
+func (self *bzz) auditResult(id uint64, proof []byte) {
+	a := self.audits
+	a.lock.Lock()
+	pending, ok := a.pending[id]
+	delete(a.pending, id)
+	a.lock.Unlock()
+	if !ok {
+		// timed out or unsolicited
+		return
+	}
+	pending.timer.Stop()
+	passed := proof != nil && bytes.Equal(proof, pending.proof)
+	glog.V(logger.Detail).Infof("audit %v: %v by %v passed: %v", id, pending.key.Log(), self, passed)
+	self.hive.reliability.record(self.remoteAddr.Addr, passed)
+	if passed {
+		self.hive.reputation.record(self.remoteAddr.Addr, auditPassed)
+	} else {
+		self.hive.reputation.record(self.remoteAddr.Addr, auditFailed)
+	}
+}
+
+// stops the timers of pending audits when the peer disconnects
+func (self *bzz) stopAudits() {
+	a := self.audits
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for id, pending := range a.pending {
+		pending.timer.Stop()
+		delete(a.pending, id)
+	}
+}
+
+// AuditScore is the record of audit outcomes for a peer
+type AuditScore struct {
+	Passed   uint64 `json:"passed"`
+	Failed   uint64 `json:"failed"`
+	Failures uint   `json:"failures"` // consecutive failures
+}
+
+// reliabilityTable keeps audit scores by bzz address so that they survive
+// reconnections
+type reliabilityTable struct {
+	lock   sync.RWMutex
+	scores map[kademlia.Address]*AuditScore
+}
+
+func newReliabilityTable() *reliabilityTable {
+	return &reliabilityTable{
+		scores: make(map[kademlia.Address]*AuditScore),
+	}
+}
+
+func (self *reliabilityTable) record(addr kademlia.Address, passed bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	score := self.scores[addr]
+	if score == nil {
+		score = &AuditScore{}
+		self.scores[addr] = score
+	}
+	if passed {
+		score.Passed++
+		score.Failures = 0
+	} else {
+		score.Failed++
+		score.Failures++
+		if score.Failures == maxAuditFailures {
+			glog.V(logger.Warn).Infof("peer %v failed %d audits in a row, deprioritised", addr, score.Failures)
+		}
+	}
+}
+
+// true if the peer failed the last maxAuditFailures audits
+func (self *reliabilityTable) unreliable(addr kademlia.Address) bool {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	score := self.scores[addr]
+	return score != nil && score.Failures >= maxAuditFailures
+}
+
+// moves unreliable peers to the end, otherwise keeping the order by distance
+func (self *reliabilityTable) prioritise(peers []*peer) []*peer {
+	var reliable, unreliable []*peer
+	for _, p := range peers {
+		if self.unreliable(p.Addr()) {
+			unreliable = append(unreliable, p)
+		} else {
+			reliable = append(reliable, p)
+		}
+	}
+	return append(reliable, unreliable...)
+}
+
+func (self *reliabilityTable) copy() map[kademlia.Address]AuditScore {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	scores := make(map[kademlia.Address]AuditScore, len(self.scores))
+	for addr, score := range self.scores {
+		scores[addr] = *score
+	}
+	return scores
+}
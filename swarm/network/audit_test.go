@@ -0,0 +1,164 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func testPeer(b byte) *peer {
+	var addr kademlia.Address
+	addr[0] = b
+	return &peer{bzz: &bzz{remoteAddr: &peerAddr{Addr: addr}}}
+}
+
+func TestReliabilityTable(t *testing.T) {
+	table := newReliabilityTable()
+	peers := []*peer{testPeer(1), testPeer(2), testPeer(3)}
+
+	for i := 0; i < maxAuditFailures; i++ {
+		if table.unreliable(peers[0].Addr()) {
+			t.Fatalf("peer unreliable after %d failures", i)
+		}
+		table.record(peers[0].Addr(), false)
+	}
+	if !table.unreliable(peers[0].Addr()) {
+		t.Fatalf("peer reliable after %d failures", maxAuditFailures)
+	}
+	table.record(peers[1].Addr(), true)
+
+	prioritised := table.prioritise(peers)
+	expected := []*peer{peers[1], peers[2], peers[0]}
+	for i, p := range prioritised {
+		if p != expected[i] {
+			t.Fatalf("peer %d: expected %v, got %v", i, expected[i].Addr(), p.Addr())
+		}
+	}
+
+	// a passed audit restores the peer
+	table.record(peers[0].Addr(), true)
+	if table.unreliable(peers[0].Addr()) {
+		t.Fatalf("peer unreliable after passing audit")
+	}
+	score := table.copy()[peers[0].Addr()]
+	if score.Passed != 1 || score.Failed != uint64(maxAuditFailures) || score.Failures != 0 {
+		t.Fatalf("unexpected score %+v", score)
+	}
+}
+
+func TestPeerAuditsKeys(t *testing.T) {
+	audits := newPeerAudits()
+	if key := audits.randomKey(); key != nil {
+		t.Fatalf("expected no key, got %v", key)
+	}
+	for i := 0; i < 2*auditKeyBuffer; i++ {
+		audits.stored(storage.Key{byte(i)})
+	}
+	if len(audits.keys) != auditKeyBuffer {
+		t.Fatalf("expected %d keys, got %d", auditKeyBuffer, len(audits.keys))
+	}
+	// only the most recent keys are remembered
+	for i := 0; i < 10; i++ {
+		if key := audits.randomKey(); key[0] < auditKeyBuffer {
+			t.Fatalf("expected recent key, got %v", key)
+		}
+	}
+}
+
+// testAuditBzz returns a protocol instance talking to the peer at addr over
+// rw, the chunks it holds are put in the returned local store
+func testAuditBzz(t *testing.T, dir string, rw p2p.MsgReadWriter, addr byte) (*bzz, *storage.LocalStore) {
+	hash := storage.MakeHashFunc("SHA3")
+	local, err := storage.NewLocalStore(hash, storage.NewStoreParams(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hive := &Hive{
+		reliability: newReliabilityTable(),
+		reputation:  newReputationTable(),
+	}
+	return &bzz{
+		storage:    NewDepo(hash, local, nil, nil),
+		hive:       hive,
+		dbAccess:   &DbAccess{loc: local},
+		remoteAddr: testPeer(addr).remoteAddr,
+		rw:         rw,
+		audits:     newPeerAudits(),
+		traffic:    newTrafficScheduler(rw, nil, 0, newTokenBucket(0)),
+	}, local
+}
+
+func TestAuditRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rw1, rw2 := p2p.MsgPipe()
+	defer rw1.Close()
+	challenger, challengerStore := testAuditBzz(t, filepath.Join(dir, "challenger"), rw1, 1)
+	holder, holderStore := testAuditBzz(t, filepath.Join(dir, "holder"), rw2, 2)
+	defer challenger.traffic.stop()
+	defer holder.traffic.stop()
+
+	sdata := make([]byte, 8+32)
+	binary.LittleEndian.PutUint64(sdata, 32)
+	hasher := storage.MakeHashFunc("SHA3")()
+	hasher.Write(sdata)
+	chunk := storage.NewChunk(storage.Key(hasher.Sum(nil)), nil)
+	chunk.SData = sdata
+	chunk.Size = 32
+	challengerStore.Put(chunk)
+
+	audit := func() AuditScore {
+		// writes on the pipe block until the message is read
+		auditc, handlec := make(chan error, 1), make(chan error, 1)
+		go func() { auditc <- challenger.audit(chunk.Key) }()
+		go func() { handlec <- holder.handle() }()
+		if err := challenger.handle(); err != nil {
+			t.Fatalf("challenger: %v", err)
+		}
+		if err := <-auditc; err != nil {
+			t.Fatalf("challenge: %v", err)
+		}
+		if err := <-handlec; err != nil {
+			t.Fatalf("holder: %v", err)
+		}
+		return challenger.hive.AuditScores()[kademlia.Address{1}]
+	}
+
+	// the holder does not have the chunk
+	if score := audit(); score.Passed != 0 || score.Failed != 1 {
+		t.Fatalf("expected failed audit, got %+v", score)
+	}
+	holderStore.Put(chunk)
+	if score := audit(); score.Passed != 1 || score.Failed != 1 || score.Failures != 0 {
+		t.Fatalf("expected passed audit, got %+v", score)
+	}
+	if len(challenger.audits.pending) != 0 {
+		t.Fatalf("%d audits pending", len(challenger.audits.pending))
+	}
+}
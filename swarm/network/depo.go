@@ -32,13 +32,15 @@ type Depo struct {
 	hashfunc   storage.Hasher
 	localStore storage.ChunkStore
 	netStore   storage.ChunkStore
+	pushSync   *PushSync
 }
 
-func NewDepo(hash storage.Hasher, localStore, remoteStore storage.ChunkStore) *Depo {
+func NewDepo(hash storage.Hasher, localStore, remoteStore storage.ChunkStore, pushSync *PushSync) *Depo {
 	return &Depo{
 		hashfunc:   hash,
 		localStore: localStore,
 		netStore:   remoteStore, // entrypoint internal
+		pushSync:   pushSync,
 	}
 }
 
@@ -112,13 +114,15 @@ func (self *Depo) HandleStoreRequestMsg(req *storeRequestMsgData, p *peer) {
 		// found chunk in memory store, needs the data, validate now
 		hasher := self.hashfunc()
 		hasher.Write(req.SData)
-		if !bytes.Equal(hasher.Sum(nil), req.Key) {
+		if !bytes.Equal(hasher.Sum(nil), req.Key) && !storage.IsValidResourceChunk(req.Key, req.SData) {
 			// data does not validate, ignore
-			// TODO: peer should be penalised/dropped?
+			// TODO: peer should be dropped?
 			glog.V(logger.Warn).Infof("Depo.HandleStoreRequest: chunk invalid. store request ignored: %v", req)
+			p.hive.reputation.record(p.Addr(), invalidChunk)
 			return
 		}
 		glog.V(logger.Detail).Infof("Depo.HandleStoreRequest: %v. request entry found", req)
+		p.hive.reputation.record(p.Addr(), chunkDelivered)
 
 	default:
 		// data is found, store request ignored
@@ -160,6 +164,9 @@ func (self *Depo) HandleRetrieveRequestMsg(req *retrieveRequestMsgData, p *peer)
 		glog.V(logger.Detail).Infof("Depo.HandleRetrieveRequest: %v - content found, delivering...", req.Key.Log())
 
 		if req.MaxSize == 0 || int64(req.MaxSize) >= chunk.Size {
+			// the peers response promises delivery within the search timeout
+			t := time.Now().Add(searchTimeout)
+			req.timeout = &t
 			sreq := &storeRequestMsgData{
 				Id:             req.Id,
 				Key:            chunk.Key,
@@ -175,6 +182,53 @@ func (self *Depo) HandleRetrieveRequestMsg(req *retrieveRequestMsgData, p *peer)
 	}
 }
 
+// entrypoint for audit requests coming from the bzz wire protocol
+// only the local store is consulted, the chunk must be held by this node
+// an empty proof is sent if the chunk is not found
+func (self *Depo) HandleAuditRequestMsg(req *auditRequestMsgData, p *peer) error {
+	resp := &auditResponseMsgData{Id: req.Id}
+	chunk, err := self.localStore.Get(req.Key)
+	if err == nil && chunk.SData != nil {
+		resp.Proof = auditProof(req.Nonce, chunk.SData)
+	} else {
+		glog.V(logger.Warn).Infof("Depo.HandleAuditRequest: %v not found locally, audit by %v fails", req.Key.Log(), p)
+	}
+	return p.auditResponse(resp)
+}
+
+// entrypoint for cancel requests coming from the bzz wire protocol
+// removes the peer's retrieve request so the chunk is not delivered to it
+func (self *Depo) HandleCancelRequestMsg(req *cancelRequestMsgData, p *peer) {
+	chunk, err := self.localStore.Get(req.Key)
+	if err != nil || chunk.Req == nil {
+		return
+	}
+	chunk.Req.Lock.Lock()
+	defer chunk.Req.Lock.Unlock()
+	var requesters []interface{}
+	for _, r := range chunk.Req.Requesters[req.Id] {
+		if r.(*retrieveRequestMsgData).from.Addr() != p.Addr() {
+			requesters = append(requesters, r)
+		}
+	}
+	if len(requesters) == 0 {
+		delete(chunk.Req.Requesters, req.Id)
+	} else {
+		chunk.Req.Requesters[req.Id] = requesters
+	}
+	glog.V(logger.Detail).Infof("Depo.HandleCancelRequest: %v - request %v of %v cancelled", req.Key.Log(), req.Id, p)
+}
+
+// entrypoint for receipt requests coming from the bzz wire protocol
+func (self *Depo) HandleReceiptRequestMsg(req *receiptRequestMsgData, p *peer) error {
+	return self.pushSync.handleRequest(req, p)
+}
+
+// entrypoint for receipts coming from the bzz wire protocol
+func (self *Depo) HandleReceiptMsg(req *receiptMsgData, p *peer) error {
+	return self.pushSync.handleReceipt(req, p)
+}
+
 // add peer request the chunk and decides the timeout for the response if still searching
 func (self *Depo) strategyUpdateRequest(rs *storage.RequestStatus, origReq *retrieveRequestMsgData) (req *retrieveRequestMsgData) {
 	glog.V(logger.Detail).Infof("Depo.strategyUpdateRequest: key %v", origReq.Key.Log())
@@ -206,6 +260,8 @@ note this is done irrespective of status (searching or found)
 */
 func (self *Depo) addRequester(rs *storage.RequestStatus, req *retrieveRequestMsgData) {
 	glog.V(logger.Detail).Infof("Depo.addRequester: key %v - add peer to req.Id %v", req.Key.Log(), req.from, req.Id)
+	rs.Lock.Lock()
+	defer rs.Lock.Unlock()
 	list := rs.Requesters[req.Id]
 	rs.Requesters[req.Id] = append(list, req)
 }
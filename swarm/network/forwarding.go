@@ -19,6 +19,7 @@ package network
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -30,7 +31,10 @@ import (
 	"github.com/ethereum/go-ethereum/swarm/storage/streaming"
 )
 
-const requesterCount = 3
+const (
+	requesterCount    = 3
+	maxHedgedRequests = 3 // maximum number of peers a retrieve request is sent to
+)
 
 /*
 forwarder implements the CloudStore interface (use by storage.NetStore)
@@ -43,10 +47,14 @@ which uses an MSB logarithmic distance-based semi-permanent Kademlia table for
 
 type forwarder struct {
 	hive *Hive
+	rtts *rttTable // retrieval round trip times of peers
 }
 
 func NewForwarder(hive *Hive) *forwarder {
-	return &forwarder{hive: hive}
+	return &forwarder{
+		hive: hive,
+		rtts: newRttTable(),
+	}
 }
 
 // generate a unique id uint64
@@ -59,39 +67,180 @@ var searchTimeout = 3 * time.Second
 
 // forwarding logic
 // logic propagating retrieve requests to peers given by the kademlia hive
+// peers with bad reputation are only asked if no other peer is available
+//
+// retrieval is hedged: the request is sent to the closest peer and, if the
+// chunk is not delivered within the delay expected from the peer's round trip
+// time, also to the next closest one, up to maxHedgedRequests peers.
+// Once the chunk is delivered, the requests outstanding with other peers
+// are cancelled.
 func (self *forwarder) Retrieve(chunk *storage.Chunk) {
 	peers := self.hive.getPeers(chunk.Key, 0)
 	glog.V(logger.Detail).Infof("forwarder.Retrieve: %v - received %d peers from KΛÐΞMLIΛ...", chunk.Key.Log(), len(peers))
-OUT:
-	for _, p := range peers {
-		glog.V(logger.Detail).Infof("forwarder.Retrieve: sending retrieveRequest %v to peer [%v]", chunk.Key.Log(), p)
-		for _, recipients := range chunk.Req.Requesters {
-			for _, recipient := range recipients {
-				req := recipient.(*retrieveRequestMsgData)
-				if req.from.Addr() == p.Addr() {
-					continue OUT
-				}
-			}
+	var sent []*hedgedRequest
+	timeout := time.After(searchTimeout)
+	next := 0
+	for len(sent) < maxHedgedRequests {
+		req := self.retrieveNext(chunk, peers, &next)
+		if req == nil {
+			break
 		}
-		req := &retrieveRequestMsgData{
-			Key: chunk.Key,
-			Id:  generateId(),
+		sent = append(sent, req)
+		select {
+		case <-chunk.Req.C:
+			self.delivered(chunk, sent)
+			return
+		case <-time.After(self.rtts.hedgeDelay(req.peer.Addr())):
+			glog.V(logger.Detail).Infof("forwarder.Retrieve: %v - no delivery from [%v], hedging", chunk.Key.Log(), req.peer)
+		case <-timeout:
+			self.timedOut(chunk, sent)
+			return
+		}
+	}
+	if len(sent) == 0 {
+		return
+	}
+	select {
+	case <-chunk.Req.C:
+		self.delivered(chunk, sent)
+	case <-timeout:
+		self.timedOut(chunk, sent)
+	}
+}
+
+// records the timeout in the reputation of the peers asked that claimed to
+// have the chunk, the others may just not have found it
+func (self *forwarder) timedOut(chunk *storage.Chunk, sent []*hedgedRequest) {
+	glog.V(logger.Detail).Infof("forwarder.Retrieve: %v - not delivered by %d peers within %v", chunk.Key.Log(), len(sent), searchTimeout)
+	for _, req := range sent {
+		if self.hive.claims.settle(req.id) {
+			glog.V(logger.Detail).Infof("forwarder.Retrieve: %v - claimed but not delivered by [%v]", chunk.Key.Log(), req.peer)
+			self.hive.reputation.record(req.peer.Addr(), requestTimeout)
+		}
+	}
+}
+
+// claimTable keeps the retrieve requests outstanding with peers and whether
+// the peer claimed to have the chunk
+type claimTable struct {
+	lock   sync.Mutex
+	claims map[uint64]*claim
+}
+
+type claim struct {
+	addr    kademlia.Address
+	claimed bool
+}
+
+func newClaimTable() *claimTable {
+	return &claimTable{
+		claims: make(map[uint64]*claim),
+	}
+}
+
+// registers the retrieve request with id sent to the peer at addr
+func (self *claimTable) expect(id uint64, addr kademlia.Address) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.claims[id] = &claim{addr: addr}
+}
+
+// records the claim of the peer at addr to have the chunk requested with id
+// claims for requests not outstanding with the peer are ignored
+func (self *claimTable) claim(id uint64, addr kademlia.Address) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if c, ok := self.claims[id]; ok && c.addr == addr {
+		c.claimed = true
+	}
+}
+
+// removes the request with id and returns whether the peer claimed the chunk
+func (self *claimTable) settle(id uint64) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	c, ok := self.claims[id]
+	delete(self.claims, id)
+	return ok && c.claimed
+}
+
+// retrieve request sent to a peer as part of a hedged retrieval
+type hedgedRequest struct {
+	peer *peer
+	id   uint64
+	sent time.Time
+}
+
+// sends the retrieve request to the next peer from peers[*next:] that is not
+// a requester of the chunk and has swap headroom
+func (self *forwarder) retrieveNext(chunk *storage.Chunk, peers []*peer, next *int) *hedgedRequest {
+	for ; *next < len(peers); *next++ {
+		p := peers[*next]
+		if isRequester(chunk.Req, p.Addr()) {
+			continue
 		}
 		var err error
 		if p.swap != nil {
 			err = p.swap.Add(-1)
 		}
-		if err == nil {
-			p.retrieve(req)
-			break OUT
+		if err != nil {
+			glog.V(logger.Warn).Infof("forwarder.Retrieve: unable to send retrieveRequest to peer [%v]: %v", chunk.Key.Log(), err)
+			continue
+		}
+		glog.V(logger.Detail).Infof("forwarder.Retrieve: sending retrieveRequest %v to peer [%v]", chunk.Key.Log(), p)
+		req := &retrieveRequestMsgData{
+			Key: chunk.Key,
+			Id:  generateId(),
+		}
+		self.hive.claims.expect(req.Id, p.Addr())
+		p.retrieve(req)
+		*next++
+		return &hedgedRequest{peer: p, id: req.Id, sent: time.Now()}
+	}
+	return nil
+}
+
+// returns whether the peer at addr requested the chunk
+func isRequester(rs *storage.RequestStatus, addr kademlia.Address) bool {
+	rs.Lock.RLock()
+	defer rs.Lock.RUnlock()
+	for _, recipients := range rs.Requesters {
+		for _, recipient := range recipients {
+			if recipient.(*retrieveRequestMsgData).from.Addr() == addr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// records the round trip time of the delivering peer and cancels the
+// requests outstanding with the others
+func (self *forwarder) delivered(chunk *storage.Chunk, sent []*hedgedRequest) {
+	var source kademlia.Address
+	if p, ok := chunk.Source.(*peer); ok {
+		source = p.Addr()
+	}
+	for _, req := range sent {
+		self.hive.claims.settle(req.id)
+		if req.peer.Addr() == source {
+			rtt := time.Since(req.sent)
+			self.rtts.update(source, rtt)
+			updateRetrieveLatency(proximity(common.Hash(self.hive.addr), common.Hash(source)), rtt)
+			glog.V(logger.Detail).Infof("forwarder.Retrieve: %v - delivered by [%v] in %v", chunk.Key.Log(), req.peer, rtt)
+			continue
 		}
-		glog.V(logger.Warn).Infof("forwarder.Retrieve: unable to send retrieveRequest to peer [%v]: %v", chunk.Key.Log(), err)
+		req.peer.cancel(&cancelRequestMsgData{
+			Key: chunk.Key,
+			Id:  req.id,
+		})
 	}
 }
 
 // requests to specific peers given by the kademlia hive
 // except for peers that the store request came from (if any)
 // delivery queueing taken care of by syncer
+// distrusted peers are synced with history priority
 func (self *forwarder) Store(chunk *storage.Chunk) {
 	var n int
 	msg := &storeRequestMsgData{
@@ -107,7 +256,11 @@ func (self *forwarder) Store(chunk *storage.Chunk) {
 
 		if p.syncer != nil && (source == nil || p.Addr() != source.Addr()) {
 			n++
-			Deliver(p, msg, PropagateReq)
+			ty := PropagateReq
+			if self.hive.distrusted(p.Addr()) {
+				ty = HistoryReq
+			}
+			Deliver(p, msg, ty)
 		}
 	}
 	glog.V(logger.Detail).Infof("forwarder.Store: sent to %v peers (chunk = %v)", n, chunk)
@@ -177,8 +330,15 @@ func (self *forwarder) Transcode(streamId string, transcodeId common.Hash, forma
 
 // once a chunk is found deliver it to its requesters unless timed out
 func (self *forwarder) Deliver(chunk *storage.Chunk) {
-	// iterate over request entries
+	// copy the request entries, delivery may block on the syncer queues
+	chunk.Req.Lock.RLock()
+	requests := make(map[uint64][]interface{}, len(chunk.Req.Requesters))
 	for id, requesters := range chunk.Req.Requesters {
+		requests[id] = append([]interface{}(nil), requesters...)
+	}
+	chunk.Req.Lock.RUnlock()
+	// iterate over request entries
+	for id, requesters := range requests {
 		counter := requesterCount
 		msg := &storeRequestMsgData{
 			Key:   chunk.Key,
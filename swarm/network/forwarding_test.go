@@ -0,0 +1,131 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func testRequestChunk(key storage.Key) *storage.Chunk {
+	return storage.NewChunk(key, &storage.RequestStatus{
+		Key:        key,
+		C:          make(chan bool),
+		Requesters: make(map[uint64][]interface{}),
+	})
+}
+
+func TestHedgedRetrieve(t *testing.T) {
+	key := storage.Key(crypto.Keccak256([]byte("chunk")))
+	var self, closerAddr, fartherAddr kademlia.Address
+	copy(self[:], key)
+	self[0] ^= 0x80
+	copy(fartherAddr[:], key)
+	fartherAddr[1] ^= 0x80
+	copy(closerAddr[:], key)
+	closerAddr[31] ^= 0x01
+
+	hive := &Hive{
+		addr:        self,
+		kad:         kademlia.New(self, kademlia.NewKadParams()),
+		reliability: newReliabilityTable(),
+		reputation:  newReputationTable(),
+		claims:      newClaimTable(),
+	}
+	closer, closerRW := testPipePeer(t, hive, closerAddr)
+	farther, fartherRW := testPipePeer(t, hive, fartherAddr)
+	defer closer.traffic.stop()
+	defer farther.traffic.stop()
+
+	fwd := NewForwarder(hive)
+	// a fast peer is hedged after the minimum delay
+	fwd.rtts.update(closerAddr, time.Millisecond)
+	chunk := testRequestChunk(key)
+	done := make(chan struct{})
+	go func() {
+		fwd.Retrieve(chunk)
+		close(done)
+	}()
+
+	// the closest peer is asked first, the next one after the hedge delay
+	var first, second retrieveRequestMsgData
+	readMsg(t, closerRW, retrieveRequestMsg, &first)
+	start := time.Now()
+	readMsg(t, fartherRW, retrieveRequestMsg, &second)
+	if delay := time.Since(start); delay >= defaultHedgeDelay {
+		t.Fatalf("expected hedging after %v, took %v", minHedgeDelay, delay)
+	}
+	if !bytes.Equal(first.Key, key) || !bytes.Equal(second.Key, key) || first.Id == second.Id {
+		t.Fatalf("unexpected requests %v and %v", &first, &second)
+	}
+
+	// delivery by the second peer cancels the request with the first
+	chunk.Source = farther
+	close(chunk.Req.C)
+	var cancel cancelRequestMsgData
+	readMsg(t, closerRW, cancelRequestMsg, &cancel)
+	if cancel.Id != first.Id || !bytes.Equal(cancel.Key, key) {
+		t.Fatalf("unexpected cancel %v, expected request id %v", &cancel, first.Id)
+	}
+	<-done
+
+	if _, ok := fwd.rtts.rtts[fartherAddr]; !ok {
+		t.Fatalf("no round trip time recorded for the delivering peer")
+	}
+	if len(hive.claims.claims) != 0 {
+		t.Fatalf("%d claims left after delivery", len(hive.claims.claims))
+	}
+}
+
+func TestHandleCancelRequest(t *testing.T) {
+	store := make(mapChunkStore)
+	depo := NewDepo(nil, store, nil, nil)
+	key := storage.Key(crypto.Keccak256([]byte("chunk")))
+	chunk := testRequestChunk(key)
+	store.Put(chunk)
+
+	peers := []*peer{testPeer(1), testPeer(2)}
+	for _, p := range peers {
+		depo.addRequester(chunk.Req, &retrieveRequestMsgData{Key: key, Id: 1, from: p})
+	}
+	depo.addRequester(chunk.Req, &retrieveRequestMsgData{Key: key, Id: 2, from: peers[0]})
+
+	// only the request of the cancelling peer is removed
+	depo.HandleCancelRequestMsg(&cancelRequestMsgData{Key: key, Id: 1}, peers[0])
+	if !isRequester(chunk.Req, peers[1].Addr()) {
+		t.Fatalf("request of other peer cancelled")
+	}
+	if reqs := chunk.Req.Requesters[1]; len(reqs) != 1 || reqs[0].(*retrieveRequestMsgData).from != peers[1] {
+		t.Fatalf("unexpected requesters %v", reqs)
+	}
+	if len(chunk.Req.Requesters[2]) != 1 {
+		t.Fatalf("request with other id cancelled")
+	}
+
+	depo.HandleCancelRequestMsg(&cancelRequestMsgData{Key: key, Id: 1}, peers[1])
+	if _, ok := chunk.Req.Requesters[1]; ok {
+		t.Fatalf("request id not removed after all requesters cancelled")
+	}
+	if isRequester(chunk.Req, peers[1].Addr()) {
+		t.Fatalf("cancelled peer still a requester")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/rand"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -50,6 +51,17 @@ type Hive struct {
 	quit         chan bool
 	toggle       chan bool
 	more         chan bool
+	reliability  *reliabilityTable // storage audit scores of peers
+	reputation   *reputationTable  // outcomes of interactions with peers
+	claims       *claimTable       // retrieve requests outstanding with peers
+	repPath      string
+	repBackend   string
+	bandwidth    *tokenBucket        // limit of traffic to all peers
+	peerRate     uint64              // limit of traffic to a peer
+	weights      []uint              // bandwidth share of traffic classes
+	candidates   chan *discover.Node // nodes found by topic discovery
+	lock         sync.Mutex
+	transcoders  map[discover.NodeID]*discover.Node // transcoders found by topic discovery
 
 	// for testing only
 	swapEnabled bool
@@ -66,8 +78,13 @@ const (
 )
 
 type HiveParams struct {
-	CallInterval uint64
-	KadDbPath    string
+	CallInterval        uint64
+	KadDbPath           string
+	ReputationDbPath    string
+	ReputationDbBackend string // database backend of the reputation db, see ethdb.Open
+	MaxBandwidth        uint64 // bytes per second sent to all peers, 0 is unlimited
+	MaxPeerBandwidth    uint64 // bytes per second sent to a peer, 0 is unlimited
+	TrafficWeights      []uint // bandwidth share of stream, retrieval, push-sync and history traffic
 	*kademlia.KadParams
 }
 
@@ -78,9 +95,11 @@ func NewHiveParams(path string) *HiveParams {
 	// kad.ProxBinSize = proxBinSize
 
 	return &HiveParams{
-		CallInterval: callInterval,
-		KadDbPath:    filepath.Join(path, "bzz-peers.json"),
-		KadParams:    kad,
+		CallInterval:     callInterval,
+		KadDbPath:        filepath.Join(path, "bzz-peers.json"),
+		ReputationDbPath: filepath.Join(path, "bzz-reputation"),
+		TrafficWeights:   defaultTrafficWeights,
+		KadParams:        kad,
 	}
 }
 
@@ -91,6 +110,16 @@ func NewHive(addr common.Hash, params *HiveParams, swapEnabled, syncEnabled bool
 		kad:          kad,
 		addr:         kad.Addr(),
 		path:         params.KadDbPath,
+		reliability:  newReliabilityTable(),
+		reputation:   newReputationTable(),
+		claims:       newClaimTable(),
+		repPath:      params.ReputationDbPath,
+		repBackend:   params.ReputationDbBackend,
+		bandwidth:    newTokenBucket(params.MaxBandwidth),
+		peerRate:     params.MaxPeerBandwidth,
+		weights:      validTrafficWeights(params.TrafficWeights),
+		candidates:   make(chan *discover.Node, maxCandidates),
+		transcoders:  make(map[discover.NodeID]*discover.Node),
 		swapEnabled:  swapEnabled,
 		syncEnabled:  syncEnabled,
 	}
@@ -117,6 +146,11 @@ func (self *Hive) Addr() kademlia.Address {
 	return self.addr
 }
 
+// PeerCount returns the number of connected peers.
+func (self *Hive) PeerCount() int {
+	return self.kad.Count()
+}
+
 // Start receives network info only at startup
 // listedAddr is a function to retrieve listening address to advertise to peers
 // connectPeer is a function to connect to a peer based on its NodeID or enode URL
@@ -132,8 +166,15 @@ func (self *Hive) Start(id discover.NodeID, listenAddr func() string, connectPee
 		glog.V(logger.Warn).Infof("Warning: error reading kaddb '%s' (skipping): %v", self.path, err)
 		err = nil
 	}
+	if err = self.reputation.load(self.repBackend, self.repPath); err != nil {
+		return fmt.Errorf("error opening reputation db '%s': %v", self.repPath, err)
+	}
+	// distrusted peers are not called
+	self.kad.SetUnwanted(self.distrusted)
 	// this loop is doing bootstrapping and maintains a healthy table
 	go self.keepAlive()
+	// this loop challenges peers to prove custody of chunks stored with them
+	go self.auditLoop()
 	go func() {
 		// whenever toggled ask kademlia about most preferred peer
 		for alive := range self.more {
@@ -149,6 +190,14 @@ func (self *Hive) Start(id discover.NodeID, listenAddr func() string, connectPee
 				// enode or any lower level connection address is unnecessary in future
 				// discovery table is used to look it up.
 				connectPeer(node.Url)
+			} else if need {
+				// no known bee to call, try one found by topic discovery
+				select {
+				case n := <-self.candidates:
+					glog.V(logger.Detail).Infof("call discovered bee %v", n)
+					connectPeer(n.String())
+				default:
+				}
 			}
 			if need {
 				// a random peer is taken from the table
@@ -189,7 +238,7 @@ func (self *Hive) keepAlive() {
 	for {
 		select {
 		case <-alarm:
-			if self.kad.DBCount() > 0 {
+			if self.kad.DBCount() > 0 || len(self.candidates) > 0 {
 				select {
 				case self.more <- true:
 					glog.V(logger.Debug).Infof("buzz wakeup")
@@ -210,9 +259,125 @@ func (self *Hive) keepAlive() {
 	}
 }
 
+// auditLoop is a forever loop
+// each round every connected peer is audited for a random chunk stored with it
+func (self *Hive) auditLoop() {
+	ticker := time.NewTicker(auditInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			self.kad.EachLiveNode(func(node kademlia.Node) bool {
+				p := node.(*peer)
+				if key := p.audits.randomKey(); key != nil {
+					if err := p.audit(key); err != nil {
+						glog.V(logger.Warn).Infof("audit of %v failed to start: %v", p, err)
+					}
+				}
+				return true
+			})
+		case <-self.quit:
+			return
+		}
+	}
+}
+
+// AuditScores returns the storage audit record of peers by address
+func (self *Hive) AuditScores() map[kademlia.Address]AuditScore {
+	return self.reliability.copy()
+}
+
+// Reputations returns the reputation of known peers by address
+func (self *Hive) Reputations() map[kademlia.Address]Reputation {
+	return self.reputation.copy()
+}
+
+// SyncStatus returns the synchronisation progress with each connected peer
+func (self *Hive) SyncStatus() (statuses []*SyncStatus) {
+	self.kad.EachLiveNode(func(node kademlia.Node) bool {
+		p := node.(*peer)
+		status := &SyncStatus{}
+		if p.syncer != nil {
+			status = p.syncer.status()
+		}
+		status.Peer = p.Addr().String()
+		statuses = append(statuses, status)
+		return true
+	})
+	return statuses
+}
+
+// SyncDebug returns the internal state of the syncer with each connected peer
+func (self *Hive) SyncDebug() (debugs []*SyncDebug) {
+	self.kad.EachLiveNode(func(node kademlia.Node) bool {
+		p := node.(*peer)
+		debug := &SyncDebug{SyncStatus: &SyncStatus{}}
+		if p.syncer != nil {
+			debug = p.syncer.debug()
+		}
+		debug.Peer = p.Addr().String()
+		debugs = append(debugs, debug)
+		return true
+	})
+	return
+}
+
+// Resync offers the connected peer with address addr all chunks in the
+// address range (start, stop] again
+func (self *Hive) Resync(addr kademlia.Address, start, stop storage.Key) error {
+	for _, node := range self.kad.FindClosest(addr, 1) {
+		p := node.(*peer)
+		if p.Addr() != addr {
+			break
+		}
+		if p.syncer == nil {
+			return fmt.Errorf("peer %v is not syncing", addr)
+		}
+		return p.syncer.resync(start, stop)
+	}
+	return fmt.Errorf("peer %v not connected", addr)
+}
+
+// discovered queues a node found by topic discovery to be called when
+// kademlia has no known peer to suggest
+func (self *Hive) discovered(n *discover.Node) {
+	if n.ID == self.id {
+		return
+	}
+	select {
+	case self.candidates <- n:
+	default:
+		// enough candidates queued
+	}
+}
+
+// discoveredTranscoder records a node found by searching the transcoder topic
+func (self *Hive) discoveredTranscoder(n *discover.Node) {
+	if n.ID == self.id {
+		return
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, ok := self.transcoders[n.ID]; ok || len(self.transcoders) < maxCandidates {
+		self.transcoders[n.ID] = n
+	}
+}
+
+// Transcoders returns the transcoding nodes found by topic discovery
+func (self *Hive) Transcoders() []*discover.Node {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	nodes := make([]*discover.Node, 0, len(self.transcoders))
+	for _, n := range self.transcoders {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
 func (self *Hive) Stop() error {
 	// closing toggle channel quits the updateloop
 	close(self.quit)
+	self.reputation.close()
 	return self.kad.Save(self.path, saveSync)
 }
 
@@ -255,14 +420,20 @@ func (self *Hive) removePeer(p *peer) {
 	}
 }
 
+// true if the peer failed its last audits or has a bad reputation
+func (self *Hive) distrusted(addr kademlia.Address) bool {
+	return self.reliability.unreliable(addr) || self.reputation.bad(addr)
+}
+
 // Retrieve a list of live peers that are closer to target than us
+// peers with bad reputation come last, preceded by unreliable ones
 func (self *Hive) getPeers(target storage.Key, max int) (peers []*peer) {
 	var addr kademlia.Address
 	copy(addr[:], target[:])
 	for _, node := range self.kad.FindClosest(addr, max) {
 		peers = append(peers, node.(*peer))
 	}
-	return
+	return self.reputation.prioritise(self.reliability.prioritise(peers))
 }
 
 func (self *Hive) getPeersCloserThanSelf(target storage.Key, max int) (peers []*peer) {
@@ -281,9 +452,10 @@ func (self *Hive) getPeersCloserThanSelf(target storage.Key, max int) (peers []*
 // disconnects all the peers
 func (self *Hive) DropAll() {
 	glog.V(logger.Info).Infof("dropping all bees")
-	for _, node := range self.kad.FindClosest(kademlia.Address{}, 0) {
+	self.kad.EachLiveNode(func(node kademlia.Node) bool {
 		node.Drop()
-	}
+		return true
+	})
 }
 
 // contructor for kademlia.NodeRecord based on peer address alone
@@ -311,6 +483,11 @@ func (self *Hive) HandlePeersMsg(req *peersMsgData, from *peer) {
 		nrs = append(nrs, newNodeRecord(p))
 	}
 	self.kad.Add(nrs)
+	// a delivery promised in response to our retrieve request is a claim
+	// to have the chunk
+	if req.Id != 0 && req.Timeout > 0 {
+		self.claims.claim(req.Id, from.Addr())
+	}
 }
 
 // peer wraps the protocol instance to represent a connected peer
@@ -167,9 +167,11 @@ offline past peer)
 || (proxBin(a) == proxBin(b) && lastChecked(a) < lastChecked(b))
 
 
+Records for which unwanted (if not nil) returns true are skipped.
+
 The second argument returned names the first missing slot found
 */
-func (self *KadDb) findBest(maxBinSize int, binSize func(int) int) (node *NodeRecord, need bool, proxLimit int) {
+func (self *KadDb) findBest(maxBinSize int, binSize func(int) int, unwanted func(Address) bool) (node *NodeRecord, need bool, proxLimit int) {
 	// return nil, proxLimit indicates that all buckets are filled
 	defer self.lock.Unlock()
 	self.lock.Lock()
@@ -211,6 +213,12 @@ func (self *KadDb) findBest(maxBinSize int, binSize func(int) int) (node *NodeRe
 					continue ROW
 				}
 
+				// skip unwanted nodes
+				if unwanted != nil && unwanted(node.Addr) {
+					glog.V(logger.Debug).Infof("kaddb record %v (PO%03d:%d) unwanted", node.Addr, po, cursor)
+					continue ROW
+				}
+
 				// if node is scheduled to connect
 				if time.Time(node.After).After(time.Now()) {
 					glog.V(logger.Debug).Infof("kaddb record %v (PO%03d:%d) skipped. seen at %v (%v ago), scheduled at %v", node.Addr, po, cursor, node.Seen, delta, node.After)
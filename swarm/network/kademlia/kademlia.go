@@ -75,6 +75,7 @@ type Kademlia struct {
 	buckets    [][]Node     // the actual bins
 	db         *KadDb       // kaddb, node record database
 	lock       sync.RWMutex // mutex to access buckets
+	unwanted   func(Address) bool
 }
 
 type Node interface {
@@ -291,10 +292,34 @@ func (self *Kademlia) FindClosest(target Address, max int) []Node {
 	return r.nodes
 }
 
+// EachLiveNode calls f for each connected node in all bins until f returns
+// false, f is called without holding the lock so it may disconnect nodes
+func (self *Kademlia) EachLiveNode(f func(Node) bool) {
+	self.lock.RLock()
+	var nodes []Node
+	for _, bucket := range self.buckets {
+		nodes = append(nodes, bucket...)
+	}
+	self.lock.RUnlock()
+	for _, node := range nodes {
+		if !f(node) {
+			return
+		}
+	}
+}
+
 func (self *Kademlia) Suggest() (*NodeRecord, bool, int) {
 	defer self.lock.RUnlock()
 	self.lock.RLock()
-	return self.db.findBest(self.BucketSize, func(i int) int { return len(self.buckets[i]) })
+	return self.db.findBest(self.BucketSize, func(i int) int { return len(self.buckets[i]) }, self.unwanted)
+}
+
+// SetUnwanted sets the function deciding which known nodes are not to be
+// suggested for connection (e.g., nodes with bad reputation)
+func (self *Kademlia) SetUnwanted(unwanted func(Address) bool) {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	self.unwanted = unwanted
 }
 
 //  adds node records to kaddb (persisted node record db)
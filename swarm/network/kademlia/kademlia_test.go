@@ -123,6 +123,33 @@ func TestBootstrap(t *testing.T) {
 
 }
 
+func TestEachLiveNode(t *testing.T) {
+	addr, _ := gen(Address{}, quickrand).(Address)
+	kad := New(addr, NewKadParams())
+	for po := 0; po < 4; po++ {
+		if err := kad.On(&testNode{addr: RandomAddressAt(addr, po)}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// nodes in all bins are visited
+	seen := make(map[Address]bool)
+	kad.EachLiveNode(func(n Node) bool {
+		seen[n.Addr()] = true
+		return true
+	})
+	if len(seen) != 4 {
+		t.Fatalf("expected 4 nodes, visited %d", len(seen))
+	}
+	var n int
+	kad.EachLiveNode(func(Node) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("expected walk to stop after 1 node, visited %d", n)
+	}
+}
+
 func TestFindClosest(t *testing.T) {
 
 	test := func(test *FindClosestTest) bool {
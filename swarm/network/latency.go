@@ -0,0 +1,96 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+const (
+	defaultHedgeDelay = 500 * time.Millisecond // hedge delay for peers without RTT samples
+	minHedgeDelay     = 20 * time.Millisecond
+	hedgeFactor       = 2   // hedge after this multiple of the expected RTT
+	rttWeight         = 0.2 // weight of a new sample in the moving average
+	latencyBins       = 16  // deeper proximity orders share the last bin
+)
+
+// retrieval latency histograms by proximity order of the delivering peer
+var retrieveLatencyTimers [latencyBins]gometrics.Timer
+
+func init() {
+	for i := range retrieveLatencyTimers {
+		retrieveLatencyTimers[i] = metrics.NewTimer(fmt.Sprintf("bzz/retrieve/latency/po%02d", i))
+	}
+}
+
+// records the latency of a retrieval delivered by a peer with proximity order po
+func updateRetrieveLatency(po int, rtt time.Duration) {
+	if po >= latencyBins {
+		po = latencyBins - 1
+	}
+	retrieveLatencyTimers[po].Update(rtt)
+}
+
+// rttTable keeps an exponentially weighted moving average of the retrieval
+// round trip time per peer, used to decide when to hedge a retrieve request
+type rttTable struct {
+	lock sync.RWMutex
+	rtts map[kademlia.Address]time.Duration
+}
+
+func newRttTable() *rttTable {
+	return &rttTable{
+		rtts: make(map[kademlia.Address]time.Duration),
+	}
+}
+
+// adds a round trip time sample for the peer
+func (self *rttTable) update(addr kademlia.Address, rtt time.Duration) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	avg, ok := self.rtts[addr]
+	if !ok {
+		self.rtts[addr] = rtt
+		return
+	}
+	self.rtts[addr] = avg + time.Duration(rttWeight*float64(rtt-avg))
+}
+
+// hedgeDelay is the time to wait for a delivery from the peer before asking
+// the next one
+func (self *rttTable) hedgeDelay(addr kademlia.Address) time.Duration {
+	self.lock.RLock()
+	avg, ok := self.rtts[addr]
+	self.lock.RUnlock()
+	if !ok {
+		return defaultHedgeDelay
+	}
+	delay := hedgeFactor * avg
+	if delay < minHedgeDelay {
+		return minHedgeDelay
+	}
+	if delay > searchTimeout {
+		return searchTimeout
+	}
+	return delay
+}
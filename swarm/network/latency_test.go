@@ -0,0 +1,52 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+)
+
+func TestHedgeDelay(t *testing.T) {
+	rtts := newRttTable()
+	addr := kademlia.Address{1}
+
+	if delay := rtts.hedgeDelay(addr); delay != defaultHedgeDelay {
+		t.Fatalf("expected default delay %v for unknown peer, got %v", defaultHedgeDelay, delay)
+	}
+	rtts.update(addr, 100*time.Millisecond)
+	if delay := rtts.hedgeDelay(addr); delay != 200*time.Millisecond {
+		t.Fatalf("expected delay 200ms, got %v", delay)
+	}
+	// the average moves towards new samples
+	rtts.update(addr, 200*time.Millisecond)
+	if delay := rtts.hedgeDelay(addr); delay != 240*time.Millisecond {
+		t.Fatalf("expected delay 240ms, got %v", delay)
+	}
+
+	// delays are bounded
+	rtts.update(kademlia.Address{2}, time.Millisecond)
+	if delay := rtts.hedgeDelay(kademlia.Address{2}); delay != minHedgeDelay {
+		t.Fatalf("expected delay %v, got %v", minHedgeDelay, delay)
+	}
+	rtts.update(kademlia.Address{3}, time.Minute)
+	if delay := rtts.hedgeDelay(kademlia.Address{3}); delay != searchTimeout {
+		t.Fatalf("expected delay %v, got %v", searchTimeout, delay)
+	}
+}
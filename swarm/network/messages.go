@@ -46,6 +46,11 @@ const (
 	streamRequestMsg           // 0x09
 	transcodeRequestMsg        // 0x10
 	transcodeAckMsg            // 0x11
+	auditRequestMsg            // 0x12
+	auditResponseMsg           // 0x13
+	cancelRequestMsg           // 0x14
+	receiptRequestMsg          // 0x15
+	receiptMsg                 // 0x16
 )
 
 /*
@@ -133,6 +138,7 @@ type storeRequestMsgData struct {
 	requestTimeout *time.Time // expiry for forwarding - [not serialised][not currently used]
 	storageTimeout *time.Time // expiry of content - [not serialised][not currently used]
 	from           *peer      // [not serialised] protocol registers the requester
+	history        bool       // [not serialised] delivered by history sync
 }
 
 func (self storeRequestMsgData) String() string {
@@ -276,7 +282,7 @@ func (self peersMsgData) String() string {
 	return fmt.Sprintf("from: %v, Key: %x; ID: %v, Peers: %v", from, target, self.Id, self.Peers)
 }
 
-func (self peersMsgData) setTimeout(t *time.Time) {
+func (self *peersMsgData) setTimeout(t *time.Time) {
 	self.timeout = t
 	if t != nil {
 		self.Timeout = uint64(t.UnixNano())
@@ -285,7 +291,7 @@ func (self peersMsgData) setTimeout(t *time.Time) {
 	}
 }
 
-func (self peersMsgData) getTimeout() (t *time.Time) {
+func (self *peersMsgData) getTimeout() (t *time.Time) {
 	if self.Timeout > 0 && self.timeout == nil {
 		timeout := time.Unix(int64(self.Timeout), 0)
 		t = &timeout
@@ -364,3 +370,85 @@ type paymentMsgData struct {
 func (self *paymentMsgData) String() string {
 	return fmt.Sprintf("payment for %d units: %v", self.Units, self.Promise)
 }
+
+/*
+auditRequest
+
+is sent to challenge a peer to prove that it holds the chunk with Key, which
+was stored with it earlier. The peer responds with an auditResponse with the
+same Id. Nonce is random so the proof cannot be precomputed.
+*/
+type auditRequestMsgData struct {
+	Id    uint64      // request id
+	Key   storage.Key // key of the chunk challenged
+	Nonce []byte      // random nonce the proof is computed over
+}
+
+func (self *auditRequestMsgData) String() string {
+	return fmt.Sprintf("audit request %v for chunk %v", self.Id, self.Key.Log())
+}
+
+/*
+auditResponse
+
+is the response to an auditRequest. Proof is the hash of the nonce and the
+chunk data, it is empty if the chunk is not found.
+*/
+type auditResponseMsgData struct {
+	Id    uint64 // id of the audit request
+	Proof []byte // keccak256(nonce | chunk data)
+}
+
+func (self *auditResponseMsgData) String() string {
+	return fmt.Sprintf("audit response %v: %x", self.Id, self.Proof)
+}
+
+/*
+cancelRequest
+
+is sent to peers a retrieve request was forwarded to once the chunk is
+delivered by another peer. The recipient removes the request with Id for Key
+so that the chunk is not delivered twice.
+*/
+type cancelRequestMsgData struct {
+	Key storage.Key // key of the chunk retrieved
+	Id  uint64      // id of the retrieve request
+}
+
+func (self *cancelRequestMsgData) String() string {
+	return fmt.Sprintf("cancel request %v for chunk %v", self.Id, self.Key.Log())
+}
+
+/*
+receiptRequest
+
+is sent to request a storage receipt for the chunk with Key. It is forwarded
+to peers closer to Key until it reaches the node responsible for the chunk,
+which responds with a receipt with the same Id.
+*/
+type receiptRequestMsgData struct {
+	Id    uint64      // request id
+	Key   storage.Key // key of the chunk
+	Nonce uint64      // chosen by the uploader, signed with the receipt
+}
+
+func (self *receiptRequestMsgData) String() string {
+	return fmt.Sprintf("receipt request %v for chunk %v", self.Id, self.Key.Log())
+}
+
+/*
+receipt
+
+is the response to a receiptRequest. Signature is the signature of the
+storer over the hash of Key, Id and the nonce of the request, it is empty
+if the storer does not hold the chunk (yet).
+*/
+type receiptMsgData struct {
+	Id        uint64      // id of the receipt request
+	Key       storage.Key // key of the chunk
+	Signature []byte      // storer's signature
+}
+
+func (self *receiptMsgData) String() string {
+	return fmt.Sprintf("receipt %v for chunk %v: %x", self.Id, self.Key.Log(), self.Signature)
+}
@@ -51,8 +51,8 @@ import (
 )
 
 const (
-	Version            = 0
-	ProtocolLength     = uint64(11)
+	Version            = 1
+	ProtocolLength     = uint64(16)
 	ProtocolMaxMsgSize = 10 * 1024 * 1024
 	NetworkId          = 3
 )
@@ -84,6 +84,15 @@ var errorToString = map[int]string{
 	ErrUnwanted:          "Unwanted peer",
 }
 
+// protocol errors counting against the reputation of the peer
+var misbehaviour = map[int]bool{
+	ErrMsgTooLarge:    true,
+	ErrDecode:         true,
+	ErrInvalidMsgCode: true,
+	ErrExtraStatusMsg: true,
+	ErrSync:           true,
+}
+
 // bzz represents the swarm wire protocol
 // an instance is running on each peer
 type bzz struct {
@@ -112,16 +121,23 @@ type bzz struct {
 	syncParams  *SyncParams         // syncer params
 	syncState   *syncState          // outgoing syncronisation state (contains reference to remote peers db counter)
 	viz         *streamingVizClient.Client
+	audits      *peerAudits       // keys stored with the peer and pending audits
+	traffic     *trafficScheduler // prioritises and throttles outgoing messages
 }
 
 // interface type for handler of storage/retrieval related requests coming
 // via the bzz wire protocol
-// messages: UnsyncedKeys, DeliveryRequest, StoreRequest, RetrieveRequest
+// messages: UnsyncedKeys, DeliveryRequest, StoreRequest, RetrieveRequest, AuditRequest, CancelRequest,
+// ReceiptRequest, Receipt
 type StorageHandler interface {
 	HandleUnsyncedKeysMsg(req *unsyncedKeysMsgData, p *peer) error
 	HandleDeliveryRequestMsg(req *deliveryRequestMsgData, p *peer) error
 	HandleStoreRequestMsg(req *storeRequestMsgData, p *peer)
 	HandleRetrieveRequestMsg(req *retrieveRequestMsgData, p *peer)
+	HandleAuditRequestMsg(req *auditRequestMsgData, p *peer) error
+	HandleCancelRequestMsg(req *cancelRequestMsgData, p *peer)
+	HandleReceiptRequestMsg(req *receiptRequestMsgData, p *peer) error
+	HandleReceiptMsg(req *receiptMsgData, p *peer) error
 }
 
 /*
@@ -139,7 +155,7 @@ func Bzz(cloud StorageHandler, backend chequebook.Backend, hive *Hive, dbaccess
 
 	// a single global request db is created for all peer connections
 	// this is to persist delivery backlog and aid syncronisation
-	requestDb, err := storage.NewLDBDatabase(sy.RequestDbPath)
+	requestDb, err := storage.OpenDatabase(sy.RequestDbBackend, sy.RequestDbPath)
 	if err != nil {
 		return p2p.Protocol{}, fmt.Errorf("error setting up request db: %v", err)
 	}
@@ -191,7 +207,10 @@ func run(requestDb *storage.LDBDatabase, depo StorageHandler, backend chequebook
 		streamDB:    streamDB,
 		forwarder:   forwarder,
 		viz:         viz,
+		audits:      newPeerAudits(),
+		traffic:     newTrafficScheduler(rw, hive.weights, hive.peerRate, hive.bandwidth),
 	}
+	defer self.traffic.stop()
 
 	// handle handshake
 	err = self.handleStatus()
@@ -202,6 +221,7 @@ func run(requestDb *storage.LDBDatabase, depo StorageHandler, backend chequebook
 		// if the handler loop exits, the peer is disconnecting
 		// deregister the peer in the hive
 		self.hive.removePeer(&peer{bzz: self})
+		self.stopAudits()
 		if self.syncer != nil {
 			self.syncer.stop() // quits request db and delivery loops, save requests
 		}
@@ -500,6 +520,57 @@ func (self *bzz) handle() error {
 			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
 		}
 
+	case auditRequestMsg:
+		// challenge to prove custody of a chunk we accepted
+		var req auditRequestMsgData
+		if err := msg.Decode(&req); err != nil {
+			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
+		}
+		glog.V(logger.Debug).Infof("<- %v", &req)
+		if err := self.storage.HandleAuditRequestMsg(&req, &peer{bzz: self}); err != nil {
+			return err
+		}
+
+	case auditResponseMsg:
+		// proof of custody for a chunk we stored with the peer
+		var req auditResponseMsgData
+		if err := msg.Decode(&req); err != nil {
+			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
+		}
+		glog.V(logger.Debug).Infof("<- %v", &req)
+		self.auditResult(req.Id, req.Proof)
+
+	case cancelRequestMsg:
+		// retrieve request we forwarded is served by another peer
+		var req cancelRequestMsgData
+		if err := msg.Decode(&req); err != nil {
+			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
+		}
+		glog.V(logger.Debug).Infof("<- %v", &req)
+		self.storage.HandleCancelRequestMsg(&req, &peer{bzz: self})
+
+	case receiptRequestMsg:
+		// storage receipt requested for a chunk
+		var req receiptRequestMsgData
+		if err := msg.Decode(&req); err != nil {
+			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
+		}
+		glog.V(logger.Debug).Infof("<- %v", &req)
+		if err := self.storage.HandleReceiptRequestMsg(&req, &peer{bzz: self}); err != nil {
+			return err
+		}
+
+	case receiptMsg:
+		// storage receipt for a chunk, ours or relayed
+		var req receiptMsgData
+		if err := msg.Decode(&req); err != nil {
+			return self.protoError(ErrDecode, "<- %v: %v", msg, err)
+		}
+		glog.V(logger.Debug).Infof("<- %v", &req)
+		if err := self.storage.HandleReceiptMsg(&req, &peer{bzz: self}); err != nil {
+			return err
+		}
+
 	case paymentMsg:
 		// swap protocol message for payment, Units paid for, Cheque paid with
 		if self.swapEnabled {
@@ -573,6 +644,10 @@ func (self *bzz) handleStatus() (err error) {
 		if err != nil {
 			return self.protoError(ErrSwap, "%v", err)
 		}
+		// distrusted peers are extended less credit
+		if self.hive.distrusted(self.remoteAddr.Addr) && status.Swap.Profile != nil {
+			self.swap.SetParams(distrustedSwapParams(self.swapParams.Params, status.Swap.PayAt))
+		}
 	}
 
 	glog.V(logger.Info).Infof("Peer %08x is capable (%d/%d)", self.remoteAddr.Addr[:4], status.Version, status.NetworkId)
@@ -685,9 +760,34 @@ func (self *bzz) retrieve(req *retrieveRequestMsgData) error {
 	return self.send(retrieveRequestMsg, req)
 }
 
+// send cancelRequestMsg
+func (self *bzz) cancel(req *cancelRequestMsgData) error {
+	return self.send(cancelRequestMsg, req)
+}
+
+// send receiptRequestMsg
+func (self *bzz) receiptRequest(req *receiptRequestMsgData) error {
+	return self.send(receiptRequestMsg, req)
+}
+
+// send receiptMsg
+func (self *bzz) receipt(req *receiptMsgData) error {
+	return self.send(receiptMsg, req)
+}
+
 // send storeRequestMsg
+// the key is remembered as a candidate for storage audits
 func (self *bzz) store(req *storeRequestMsgData) error {
-	return self.send(storeRequestMsg, req)
+	err := self.send(storeRequestMsg, req)
+	if err == nil {
+		self.audits.stored(req.Key)
+	}
+	return err
+}
+
+// send auditResponseMsg
+func (self *bzz) auditResponse(req *auditResponseMsgData) error {
+	return self.send(auditResponseMsg, req)
 }
 
 // send streamRequestMsg
@@ -753,6 +853,10 @@ func (self *bzz) peers(req *peersMsgData) error {
 func (self *bzz) protoError(code int, format string, params ...interface{}) (err *errs.Error) {
 	err = self.errors.New(code, format, params...)
 	err.Log(glog.V(logger.Info))
+	// misbehaviour of a peer known by its bzz address is recorded in its reputation
+	if self.remoteAddr != nil && misbehaviour[code] {
+		self.hive.reputation.record(self.remoteAddr.Addr, protocolError)
+	}
 	return
 }
 
@@ -761,7 +865,7 @@ func (self *bzz) send(msg uint64, data interface{}) error {
 		return fmt.Errorf("network write blocked")
 	}
 	glog.V(logger.Detail).Infof("-> %v: %v (%T) to %v", msg, data, data, self)
-	err := p2p.Send(self.rw, msg, data)
+	err := self.traffic.send(msg, data, trafficClass(msg, data))
 	if err != nil {
 		fmt.Println("Error sending in protocol: ", err)
 		self.Drop()
@@ -0,0 +1,297 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+/*
+Push-sync with storage receipts
+
+Chunks stored locally are pushed towards their area of responsibility by the
+forwarder (see forwarder.Store). To learn whether a chunk has arrived there,
+the uploader sends a receiptRequestMsg which is routed like a retrieve
+request, each node forwarding it to a peer closer to the chunk than itself.
+The node with no closer peer is the storer: if it holds the chunk, it
+responds with a receiptMsg carrying its signature over the chunk key, the
+request id and a random nonce chosen by the uploader, which is relayed back
+along the request path. As the nonce is new for every request, a receipt
+can't be replayed by nodes which no longer hold the chunk or never did.
+The uploader and the relays recover the bzz address of the storer from the
+signature. As each node forwards to a closer peer, the storer must be at
+least as close to the chunk as the peer the request was sent to, receipts
+signed by farther nodes are rejected.
+*/
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const (
+	receiptTimeout       = 10 * time.Second // time to wait for a receipt
+	receiptRetries       = 5                // number of receipt requests for a chunk
+	receiptRetryInterval = 2 * time.Second  // delay before requesting a receipt again
+)
+
+// Receipt is the signed confirmation of a node that it stores a chunk
+type Receipt struct {
+	Key       storage.Key
+	Id        uint64 // id of the receipt request
+	Nonce     uint64 // nonce of the receipt request
+	Signature []byte // storer's signature over the hash of key, id and nonce
+}
+
+func receiptDigest(key storage.Key, id, nonce uint64) []byte {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], id)
+	binary.BigEndian.PutUint64(buf[8:], nonce)
+	return crypto.Keccak256(key, buf[:])
+}
+
+// Storer returns the bzz address of the node that signed the receipt
+func (self *Receipt) Storer() (kademlia.Address, error) {
+	pub, err := crypto.SigToPub(receiptDigest(self.Key, self.Id, self.Nonce), self.Signature)
+	if err != nil {
+		return kademlia.Address{}, fmt.Errorf("invalid receipt signature: %v", err)
+	}
+	return kademlia.Address(crypto.Keccak256Hash(crypto.FromECDSAPub(pub))), nil
+}
+
+// receiptNonce returns an unpredictable nonce for a receipt request
+func receiptNonce() (uint64, error) {
+	var buf [8]byte
+	if _, err := crand.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// a receipt request forwarded on behalf of a peer, identified by the peer
+// and the request id
+type receiptRelay struct {
+	from    *peer
+	to      *peer // peer the request was forwarded to
+	key     storage.Key
+	nonce   uint64
+	expires time.Time
+}
+
+type relayKey struct {
+	from kademlia.Address
+	id   uint64
+}
+
+// PushSync obtains storage receipts for chunks and serves receipt requests
+// coming from peers
+type PushSync struct {
+	hive       *Hive
+	localStore storage.ChunkStore
+	signer     accounts.Signer // account of the bzz address of this node
+	lock       sync.Mutex
+	pending    map[uint64]chan *Receipt   // own receipt requests by id
+	relays     map[relayKey]*receiptRelay // requests forwarded for peers
+}
+
+func NewPushSync(hive *Hive, localStore storage.ChunkStore, signer accounts.Signer) *PushSync {
+	return &PushSync{
+		hive:       hive,
+		localStore: localStore,
+		signer:     signer,
+		pending:    make(map[uint64]chan *Receipt),
+		relays:     make(map[relayKey]*receiptRelay),
+	}
+}
+
+// Receipt returns a receipt for the chunk with key from the node responsible
+// for it, requesting it again if the chunk has not arrived there yet
+func (self *PushSync) Receipt(key storage.Key) (receipt *Receipt, err error) {
+	for i := 0; i < receiptRetries; i++ {
+		if i > 0 {
+			time.Sleep(receiptRetryInterval)
+		}
+		receipt, err = self.request(key)
+		if err == nil {
+			return receipt, nil
+		}
+		glog.V(logger.Detail).Infof("receipt for %v (attempt %d): %v", key.Log(), i+1, err)
+	}
+	return nil, err
+}
+
+func (self *PushSync) request(key storage.Key) (*Receipt, error) {
+	id := generateId()
+	nonce, err := receiptNonce()
+	if err != nil {
+		return nil, err
+	}
+	peers := self.hive.getPeersCloserThanSelf(key, 1)
+	if len(peers) == 0 {
+		// no closer peer, we are responsible ourselves
+		return self.sign(key, id, nonce)
+	}
+	c := make(chan *Receipt, 1)
+	self.lock.Lock()
+	self.pending[id] = c
+	self.lock.Unlock()
+	defer func() {
+		self.lock.Lock()
+		delete(self.pending, id)
+		self.lock.Unlock()
+	}()
+
+	if err := peers[0].receiptRequest(&receiptRequestMsgData{Id: id, Key: key, Nonce: nonce}); err != nil {
+		return nil, err
+	}
+	select {
+	case receipt := <-c:
+		receipt.Nonce = nonce
+		if !bytes.Equal(receipt.Key, key) {
+			return nil, fmt.Errorf("receipt for wrong chunk %v", receipt.Key.Log())
+		}
+		if len(receipt.Signature) == 0 {
+			return nil, fmt.Errorf("chunk not stored yet")
+		}
+		storer, err := receipt.Storer()
+		if err != nil {
+			return nil, err
+		}
+		if !closerOrEqual(storer, peers[0].Addr(), key) {
+			return nil, fmt.Errorf("receipt for %v signed by %v outside the proximity bin of the chunk", key.Log(), storer)
+		}
+		return receipt, nil
+	case <-time.After(receiptTimeout):
+		return nil, fmt.Errorf("receipt timed out")
+	}
+}
+
+// true if the proximity order of addr to key is at least that of other
+func closerOrEqual(addr, other kademlia.Address, key storage.Key) bool {
+	var target common.Hash
+	copy(target[:], key)
+	return proximity(common.Hash(addr), target) >= proximity(common.Hash(other), target)
+}
+
+// sign returns a receipt for a chunk held locally
+func (self *PushSync) sign(key storage.Key, id, nonce uint64) (*Receipt, error) {
+	chunk, err := self.localStore.Get(key)
+	if err != nil || chunk.SData == nil {
+		return nil, fmt.Errorf("chunk %v not stored", key.Log())
+	}
+	sig, err := accounts.SignRequestHash(self.signer, &accounts.SignRequest{Kind: accounts.ReceiptRequest, Data: key}, receiptDigest(key, id, nonce))
+	if err != nil {
+		return nil, err
+	}
+	return &Receipt{Key: key, Id: id, Nonce: nonce, Signature: sig}, nil
+}
+
+// handles receipt requests from peers: forwarded to a closer peer if any,
+// otherwise answered with a receipt, without signature if the chunk is not
+// stored here
+func (self *PushSync) handleRequest(req *receiptRequestMsgData, p *peer) error {
+	for _, next := range self.hive.getPeersCloserThanSelf(req.Key, 2) {
+		if next.Addr() == p.Addr() {
+			continue
+		}
+		self.lock.Lock()
+		now := time.Now()
+		for k, relay := range self.relays {
+			if relay.expires.Before(now) {
+				delete(self.relays, k)
+			}
+		}
+		self.relays[relayKey{p.Addr(), req.Id}] = &receiptRelay{
+			from:    p,
+			to:      next,
+			key:     req.Key,
+			nonce:   req.Nonce,
+			expires: now.Add(receiptTimeout),
+		}
+		self.lock.Unlock()
+		glog.V(logger.Detail).Infof("receipt request %v for %v forwarded from %v to %v", req.Id, req.Key.Log(), p, next)
+		if err := next.receiptRequest(req); err != nil {
+			glog.V(logger.Warn).Infof("receipt request %v for %v could not be forwarded to %v: %v", req.Id, req.Key.Log(), next, err)
+		}
+		return nil
+	}
+	resp := &receiptMsgData{Id: req.Id, Key: req.Key}
+	if receipt, err := self.sign(req.Key, req.Id, req.Nonce); err == nil {
+		resp.Signature = receipt.Signature
+	}
+	glog.V(logger.Detail).Infof("receipt request %v for %v from %v answered (stored: %v)", req.Id, req.Key.Log(), p, resp.Signature != nil)
+	return p.receipt(resp)
+}
+
+// handles receipts from peers: passed to the requester if the request was
+// ours, otherwise relayed to the peers which sent us a request with the id,
+// if the signature is valid for their request
+func (self *PushSync) handleReceipt(req *receiptMsgData, p *peer) error {
+	self.lock.Lock()
+	c, own := self.pending[req.Id]
+	relays := make(map[relayKey]*receiptRelay)
+	if !own {
+		for k, relay := range self.relays {
+			if k.id == req.Id && relay.to == p && bytes.Equal(relay.key, req.Key) {
+				relays[k] = relay
+			}
+		}
+	}
+	self.lock.Unlock()
+	if own {
+		select {
+		case c <- &Receipt{Key: req.Key, Id: req.Id, Signature: req.Signature}:
+		default:
+		}
+		return nil
+	}
+	if len(relays) == 0 {
+		glog.V(logger.Detail).Infof("unsolicited receipt %v for %v from %v", req.Id, req.Key.Log(), p)
+		return nil
+	}
+	// requesters sharing the id have their own nonce, an invalid receipt
+	// leaves their relay in place until it expires
+	for k, relay := range relays {
+		if len(req.Signature) > 0 {
+			receipt := &Receipt{Key: req.Key, Id: req.Id, Nonce: relay.nonce, Signature: req.Signature}
+			storer, err := receipt.Storer()
+			if err != nil || !closerOrEqual(storer, p.Addr(), req.Key) {
+				glog.V(logger.Detail).Infof("invalid receipt %v for %v from %v not relayed to %v", req.Id, req.Key.Log(), p, relay.from)
+				continue
+			}
+		}
+		self.lock.Lock()
+		_, ok := self.relays[k]
+		delete(self.relays, k)
+		self.lock.Unlock()
+		if !ok {
+			continue
+		}
+		if err := relay.from.receipt(req); err != nil {
+			glog.V(logger.Warn).Infof("receipt %v for %v could not be relayed to %v: %v", req.Id, req.Key.Log(), relay.from, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+type mapChunkStore map[string]*storage.Chunk
+
+func (self mapChunkStore) Put(chunk *storage.Chunk) {
+	self[chunk.Key.String()] = chunk
+}
+
+func (self mapChunkStore) Get(key storage.Key) (*storage.Chunk, error) {
+	chunk, ok := self[key.String()]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return chunk, nil
+}
+
+func TestReceiptSignature(t *testing.T) {
+	prvKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := make(mapChunkStore)
+	ps := NewPushSync(nil, store, accounts.NewKeySigner(prvKey))
+	key := storage.Key(crypto.Keccak256([]byte("chunk")))
+	if _, err := ps.sign(key, 1, 2); err == nil {
+		t.Fatalf("expected no receipt for missing chunk")
+	}
+	store.Put(&storage.Chunk{Key: key, SData: []byte("chunk data")})
+	receipt, err := ps.sign(key, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	storer, err := receipt.Storer()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := kademlia.Address(crypto.Keccak256Hash(crypto.FromECDSAPub(&prvKey.PublicKey)))
+	if storer != expected {
+		t.Fatalf("expected storer %v, got %v", expected, storer)
+	}
+
+	// a receipt signed for another chunk or request does not recover the storer
+	for _, other := range []Receipt{
+		{Key: storage.Key(crypto.Keccak256([]byte("other"))), Id: 1, Nonce: 2},
+		{Key: key, Id: 3, Nonce: 2},
+		{Key: key, Id: 1, Nonce: 3},
+	} {
+		other.Signature = receipt.Signature
+		if storer, err = other.Storer(); err == nil && storer == expected {
+			t.Fatalf("receipt valid for wrong request %v/%v/%v", other.Key.Log(), other.Id, other.Nonce)
+		}
+	}
+}
+
+// testPipePeer connects a peer at addr to the hive, the messages sent to it
+// are read from the returned end of a message pipe
+func testPipePeer(t *testing.T, hive *Hive, addr kademlia.Address) (*peer, p2p.MsgReadWriter) {
+	rw1, rw2 := p2p.MsgPipe()
+	p := &peer{bzz: &bzz{
+		hive:       hive,
+		remoteAddr: &peerAddr{Addr: addr},
+		rw:         rw1,
+		traffic:    newTrafficScheduler(rw1, nil, 0, newTokenBucket(0)),
+	}}
+	if err := hive.kad.On(p, nil); err != nil {
+		t.Fatal(err)
+	}
+	return p, rw2
+}
+
+func readMsg(t *testing.T, rw p2p.MsgReadWriter, code uint64, data interface{}) {
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Code != code {
+		t.Fatalf("expected message %d, got %d", code, msg.Code)
+	}
+	if err := msg.Decode(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signReceipt(t *testing.T, key storage.Key, id, nonce uint64, prvKey *ecdsa.PrivateKey) []byte {
+	sig, err := crypto.Sign(receiptDigest(key, id, nonce), prvKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// testPushSync sets up a node at proximity order 0 to key, with a peer
+// closer to key (po 255) and one as far as the node itself
+func testPushSync(t *testing.T, key storage.Key) (ps *PushSync, closer, farther *peer, closerRW, fartherRW p2p.MsgReadWriter) {
+	var self, closerAddr, fartherAddr kademlia.Address
+	copy(self[:], key)
+	self[0] ^= 0x80
+	copy(fartherAddr[:], self[:])
+	fartherAddr[1] ^= 0x80
+	copy(closerAddr[:], key)
+	closerAddr[31] ^= 0x01
+
+	hive := &Hive{addr: self, kad: kademlia.New(self, kademlia.NewKadParams())}
+	closer, closerRW = testPipePeer(t, hive, closerAddr)
+	farther, fartherRW = testPipePeer(t, hive, fartherAddr)
+	prvKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps = NewPushSync(hive, make(mapChunkStore), accounts.NewKeySigner(prvKey))
+	return
+}
+
+func TestReceiptRouting(t *testing.T) {
+	// the chunk key is the bzz address of the storer
+	storerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := storage.Key(crypto.Keccak256(crypto.FromECDSAPub(&storerKey.PublicKey)))
+	ps, closer, farther, closerRW, fartherRW := testPushSync(t, key)
+	defer closer.traffic.stop()
+	defer farther.traffic.stop()
+
+	// the request is forwarded to the closer peer
+	// writes on the pipe block until the message is read
+	errc := make(chan error, 1)
+	go func() { errc <- ps.handleRequest(&receiptRequestMsgData{Id: 1, Key: key, Nonce: 7}, farther) }()
+	var req receiptRequestMsgData
+	readMsg(t, closerRW, receiptRequestMsg, &req)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if req.Id != 1 || req.Nonce != 7 || !bytes.Equal(req.Key, key) {
+		t.Fatalf("unexpected forwarded request %v", &req)
+	}
+
+	// receipts with a signature for another nonce are not relayed
+	replayed := signReceipt(t, key, 1, 8, storerKey)
+	if err := ps.handleReceipt(&receiptMsgData{Id: 1, Key: key, Signature: replayed}, closer); err != nil {
+		t.Fatal(err)
+	}
+	if len(ps.relays) != 1 {
+		t.Fatalf("relay dropped after invalid receipt")
+	}
+
+	// the receipt of the storer is relayed back to the requester
+	sig := signReceipt(t, key, 1, 7, storerKey)
+	go func() { errc <- ps.handleReceipt(&receiptMsgData{Id: 1, Key: key, Signature: sig}, closer) }()
+	var receipt receiptMsgData
+	readMsg(t, fartherRW, receiptMsg, &receipt)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Id != 1 || !bytes.Equal(receipt.Signature, sig) {
+		t.Fatalf("unexpected relayed receipt %v", &receipt)
+	}
+	if len(ps.relays) != 0 {
+		t.Fatalf("%d relays left", len(ps.relays))
+	}
+
+	// without a closer peer than the requester the request is answered,
+	// unsigned as the chunk is not stored here
+	go func() { errc <- ps.handleRequest(&receiptRequestMsgData{Id: 2, Key: key}, closer) }()
+	readMsg(t, closerRW, receiptMsg, &receipt)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Id != 2 || len(receipt.Signature) != 0 {
+		t.Fatalf("unexpected receipt %v", &receipt)
+	}
+}
+
+func TestReceiptStorer(t *testing.T) {
+	// the chunk key is the bzz address of the storer
+	storerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := storage.Key(crypto.Keccak256(crypto.FromECDSAPub(&storerKey.PublicKey)))
+	ps, closer, farther, closerRW, _ := testPushSync(t, key)
+	defer closer.traffic.stop()
+	defer farther.traffic.stop()
+	farKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, signer := range []*ecdsa.PrivateKey{farKey, storerKey} {
+		type result struct {
+			receipt *Receipt
+			err     error
+		}
+		resc := make(chan result, 1)
+		go func() {
+			receipt, err := ps.request(key)
+			resc <- result{receipt, err}
+		}()
+		var req receiptRequestMsgData
+		readMsg(t, closerRW, receiptRequestMsg, &req)
+		ps.handleReceipt(&receiptMsgData{Id: req.Id, Key: key, Signature: signReceipt(t, key, req.Id, req.Nonce, signer)}, closer)
+		res := <-resc
+		switch {
+		case i == 0 && res.err == nil:
+			t.Fatalf("receipt signed by a node farther from the chunk than the closest peer accepted")
+		case i == 1 && res.err != nil:
+			t.Fatalf("receipt of the storer rejected: %v", res.err)
+		}
+	}
+}
+
+func TestReceiptRelaySameId(t *testing.T) {
+	storerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := storage.Key(crypto.Keccak256(crypto.FromECDSAPub(&storerKey.PublicKey)))
+	ps, closer, farther, closerRW, fartherRW := testPushSync(t, key)
+	defer closer.traffic.stop()
+	defer farther.traffic.stop()
+	otherAddr := farther.Addr()
+	otherAddr[2] ^= 0x80
+	other, _ := testPipePeer(t, ps.hive, otherAddr)
+	defer other.traffic.stop()
+
+	// a second request with the same id does not replace the first one
+	errc := make(chan error, 1)
+	var req receiptRequestMsgData
+	for i, from := range []*peer{farther, other} {
+		go func() { errc <- ps.handleRequest(&receiptRequestMsgData{Id: 1, Key: key, Nonce: uint64(i)}, from) }()
+		readMsg(t, closerRW, receiptRequestMsg, &req)
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ps.relays) != 2 {
+		t.Fatalf("expected 2 relays, got %d", len(ps.relays))
+	}
+
+	// the receipt is relayed only to the requester whose nonce it signs
+	sig := signReceipt(t, key, 1, 0, storerKey)
+	go func() { errc <- ps.handleReceipt(&receiptMsgData{Id: 1, Key: key, Signature: sig}, closer) }()
+	var receipt receiptMsgData
+	readMsg(t, fartherRW, receiptMsg, &receipt)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(receipt.Signature, sig) {
+		t.Fatalf("unexpected relayed receipt %v", &receipt)
+	}
+	if len(ps.relays) != 1 || ps.relays[relayKey{otherAddr, 1}] == nil {
+		t.Fatalf("relay of requester with another nonce dropped")
+	}
+}
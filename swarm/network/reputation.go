@@ -0,0 +1,251 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+/*
+Peer reputation
+
+The hive records the outcome of interactions with peers by bzz address:
+chunks delivered on request, requests timed out, invalid chunks, protocol
+errors and storage audits. Scores decay towards zero with a half life of
+reputationHalfLife so that old offences are forgiven. Reputations are written
+to a database (HiveParams.ReputationDbPath) as they change so they survive
+restarts, crashes and reconnections.
+
+A retrieve request timing out is only held against peers that claimed to
+have the chunk, i.e., promised delivery in their response to the request.
+Peers searching the network for a chunk that does not exist behave correctly.
+
+Peers with a bad reputation
+* are put last when picking peers for retrieval (getPeers ordering)
+* are synced with history priority
+* are not suggested for new connections by kademlia
+* are extended less credit by SWAP
+*/
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/services/swap/swap"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// events affecting the reputation of a peer
+const (
+	chunkDelivered = iota // valid chunk delivered on request
+	requestTimeout        // chunk claimed but not delivered in time
+	invalidChunk          // chunk data not matching its key
+	protocolError         // message failing protocol checks
+	auditPassed
+	auditFailed
+)
+
+// weights of the events in the reputation score
+var reputationWeights = []float64{
+	chunkDelivered: 1,
+	requestTimeout: -1,
+	invalidChunk:   -10,
+	protocolError:  -5,
+	auditPassed:    2,
+	auditFailed:    -5,
+}
+
+const (
+	minReputationScore = -20            // peers with a score below this have a bad reputation
+	reputationHalfLife = 24 * time.Hour // time in which a score decays to half
+)
+
+// Reputation is the record of interactions with a peer
+type Reputation struct {
+	Deliveries     uint64    `json:"deliveries"`
+	Timeouts       uint64    `json:"timeouts"`
+	InvalidChunks  uint64    `json:"invalidChunks"`
+	ProtocolErrors uint64    `json:"protocolErrors"`
+	AuditsPassed   uint64    `json:"auditsPassed"`
+	AuditsFailed   uint64    `json:"auditsFailed"`
+	Score          float64   `json:"score"`   // score at the time of the last update
+	Updated        time.Time `json:"updated"` // time of the last update
+}
+
+// decayed returns the score decayed from the last update until now
+func (self *Reputation) decayed(now time.Time) float64 {
+	elapsed := now.Sub(self.Updated)
+	if self.Updated.IsZero() || elapsed <= 0 {
+		return self.Score
+	}
+	return self.Score * math.Pow(0.5, float64(elapsed)/float64(reputationHalfLife))
+}
+
+func (self *Reputation) record(event int, now time.Time) {
+	switch event {
+	case chunkDelivered:
+		self.Deliveries++
+	case requestTimeout:
+		self.Timeouts++
+	case invalidChunk:
+		self.InvalidChunks++
+	case protocolError:
+		self.ProtocolErrors++
+	case auditPassed:
+		self.AuditsPassed++
+	case auditFailed:
+		self.AuditsFailed++
+	}
+	self.Score = self.decayed(now) + reputationWeights[event]
+	self.Updated = now
+}
+
+// Bad is true if the decayed score of the peer is below minReputationScore
+func (self *Reputation) Bad() bool {
+	return self.decayed(time.Now()) < minReputationScore
+}
+
+// reputationTable keeps the reputation of peers by bzz address
+type reputationTable struct {
+	lock        sync.RWMutex
+	reputations map[kademlia.Address]*Reputation
+	db          *storage.LDBDatabase // nil if not persisted
+}
+
+func newReputationTable() *reputationTable {
+	return &reputationTable{
+		reputations: make(map[kademlia.Address]*Reputation),
+	}
+}
+
+// load opens the reputation database at path with the given backend and
+// reads all records
+func (self *reputationTable) load(backend, path string) error {
+	db, err := storage.OpenDatabase(backend, path)
+	if err != nil {
+		return err
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.db = db
+	it := db.NewIterator()
+	defer it.Release()
+	var n int
+	for it.Next() {
+		var addr kademlia.Address
+		copy(addr[:], it.Key())
+		rep := &Reputation{}
+		if err := json.Unmarshal(it.Value(), rep); err != nil {
+			glog.V(logger.Warn).Infof("invalid reputation record for %v: %v", addr, err)
+			continue
+		}
+		self.reputations[addr] = rep
+		n++
+	}
+	glog.V(logger.Info).Infof("loaded reputation of %v peers from %v", n, path)
+	return nil
+}
+
+// writes the record of a peer to the reputation database, caller holds the lock
+func (self *reputationTable) put(addr kademlia.Address, rep *Reputation) {
+	if self.db == nil {
+		return
+	}
+	data, err := json.Marshal(rep)
+	if err != nil {
+		glog.V(logger.Warn).Infof("unable to encode reputation of %v: %v", addr, err)
+		return
+	}
+	self.db.Put(addr[:], data)
+}
+
+// close closes the reputation database, records are written as they change
+func (self *reputationTable) close() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.db == nil {
+		return
+	}
+	self.db.Close()
+	self.db = nil
+}
+
+func (self *reputationTable) record(addr kademlia.Address, event int) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	rep := self.reputations[addr]
+	if rep == nil {
+		rep = &Reputation{}
+		self.reputations[addr] = rep
+	}
+	bad := rep.Bad()
+	rep.record(event, time.Now())
+	if !bad && rep.Bad() {
+		glog.V(logger.Warn).Infof("peer %v lost its reputation: %+v", addr, rep)
+	}
+	self.put(addr, rep)
+}
+
+// true if the peer has a bad reputation, unknown peers are fine
+func (self *reputationTable) bad(addr kademlia.Address) bool {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	rep := self.reputations[addr]
+	return rep != nil && rep.Bad()
+}
+
+// moves peers with a bad reputation to the end, otherwise keeping the order
+// by distance
+func (self *reputationTable) prioritise(peers []*peer) []*peer {
+	var good, bad []*peer
+	for _, p := range peers {
+		if self.bad(p.Addr()) {
+			bad = append(bad, p)
+		} else {
+			good = append(good, p)
+		}
+	}
+	return append(good, bad...)
+}
+
+func (self *reputationTable) copy() map[kademlia.Address]Reputation {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	reputations := make(map[kademlia.Address]Reputation, len(self.reputations))
+	now := time.Now()
+	for addr, rep := range self.reputations {
+		r := *rep
+		r.Score, r.Updated = rep.decayed(now), now
+		reputations[addr] = r
+	}
+	return reputations
+}
+
+// distrustedSwapParams returns the SWAP parameters for peers with bad
+// reputation: the debt they can run up before they are dropped is halved,
+// but kept above the threshold at which they pay
+func distrustedSwapParams(params *swap.Params, remotePayAt uint) *swap.Params {
+	profile := *params.Profile
+	if profile.DropAt > remotePayAt {
+		profile.DropAt = remotePayAt + (profile.DropAt-remotePayAt)/2
+	}
+	return &swap.Params{
+		Profile:  &profile,
+		Strategy: params.Strategy,
+	}
+}
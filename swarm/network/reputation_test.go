@@ -0,0 +1,148 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/swarm/network/kademlia"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func TestReputationPrioritise(t *testing.T) {
+	table := newReputationTable()
+	peers := []*peer{testPeer(1), testPeer(2), testPeer(3)}
+
+	for i := 0; i < 5; i++ {
+		table.record(peers[0].Addr(), auditFailed)
+	}
+	if !table.bad(peers[0].Addr()) {
+		t.Fatalf("good reputation with score %v", table.copy()[peers[0].Addr()].Score)
+	}
+	table.record(peers[1].Addr(), auditPassed)
+
+	prioritised := table.prioritise(peers)
+	expected := []*peer{peers[1], peers[2], peers[0]}
+	for i, p := range prioritised {
+		if p != expected[i] {
+			t.Fatalf("peer %d: expected %v, got %v", i, expected[i].Addr(), p.Addr())
+		}
+	}
+	rep := table.copy()[peers[0].Addr()]
+	if rep.AuditsPassed != 0 || rep.AuditsFailed != 5 {
+		t.Fatalf("unexpected reputation %+v", rep)
+	}
+}
+
+func TestReputationScore(t *testing.T) {
+	table := newReputationTable()
+	addr := kademlia.Address{1}
+	for i := 0; i < 5; i++ {
+		table.record(addr, chunkDelivered)
+	}
+	// invalid chunks outweigh deliveries
+	for i := 0; i < 3; i++ {
+		if table.bad(addr) {
+			t.Fatalf("bad reputation after %d invalid chunks", i)
+		}
+		table.record(addr, invalidChunk)
+	}
+	if !table.bad(addr) {
+		t.Fatalf("good reputation with score %v", table.copy()[addr].Score)
+	}
+}
+
+func TestReputationDecay(t *testing.T) {
+	now := time.Now()
+	rep := &Reputation{Score: -40, Updated: now}
+	if !rep.Bad() {
+		t.Fatalf("good reputation with score %v", rep.Score)
+	}
+	// two half lives later the score is a quarter
+	if score := rep.decayed(now.Add(2 * reputationHalfLife)); score < -10.01 || score > -9.99 {
+		t.Fatalf("expected score -10, got %v", score)
+	}
+	rep.Updated = now.Add(-2 * reputationHalfLife)
+	if rep.Bad() {
+		t.Fatalf("bad reputation after decay")
+	}
+	// events add to the decayed score
+	rep.record(protocolError, now)
+	if rep.Score < -15.01 || rep.Score > -14.99 || rep.Updated != now {
+		t.Fatalf("unexpected reputation %+v", rep)
+	}
+}
+
+func TestReputationPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-reputation-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reputation")
+
+	table := newReputationTable()
+	if err := table.load("", path); err != nil {
+		t.Fatal(err)
+	}
+	table.record(kademlia.Address{1}, chunkDelivered)
+	table.record(kademlia.Address{2}, protocolError)
+	// records are written as they change and survive a crash
+	table.db.Close()
+
+	table = newReputationTable()
+	if err := table.load("", path); err != nil {
+		t.Fatal(err)
+	}
+	defer table.close()
+	reps := table.copy()
+	if len(reps) != 2 || reps[kademlia.Address{1}].Deliveries != 1 || reps[kademlia.Address{2}].ProtocolErrors != 1 {
+		t.Fatalf("unexpected reputations after reload: %+v", reps)
+	}
+}
+
+func TestTimedOutClaims(t *testing.T) {
+	hive := &Hive{
+		reputation: newReputationTable(),
+		claims:     newClaimTable(),
+	}
+	fwd := NewForwarder(hive)
+	peers := []*peer{testPeer(1), testPeer(2)}
+	sent := []*hedgedRequest{{peer: peers[0], id: 1}, {peer: peers[1], id: 2}}
+	for _, req := range sent {
+		hive.claims.expect(req.id, req.peer.Addr())
+	}
+	hive.claims.claim(2, peers[1].Addr())
+	// claims by other peers are ignored
+	hive.claims.claim(1, testPeer(3).Addr())
+
+	fwd.timedOut(storage.NewChunk(storage.Key{1}, nil), sent)
+	reps := hive.Reputations()
+	if _, ok := reps[peers[0].Addr()]; ok {
+		t.Fatalf("peer not claiming the chunk penalised: %+v", reps[peers[0].Addr()])
+	}
+	if rep := reps[peers[1].Addr()]; rep.Timeouts != 1 {
+		t.Fatalf("peer claiming the chunk not penalised: %+v", rep)
+	}
+	if len(hive.claims.claims) != 0 {
+		t.Fatalf("%d claims left after timeout", len(hive.claims.claims))
+	}
+}
@@ -17,14 +17,14 @@
 package network
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/swarm/storage"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 )
 
 const counterKeyPrefix = 0x01
@@ -115,7 +115,7 @@ func (self *syncDb) bufferRead(deliver func(interface{}, chan bool) bool) {
 	var req interface{}
 	var entry *syncDbEntry
 	var inBatch, inDb int
-	batch := new(leveldb.Batch)
+	batch := self.db.NewBatch()
 	var dbSize chan int
 	quit := self.quit
 	counterValue := make([]byte, 8)
@@ -234,13 +234,13 @@ LOOP:
 }
 
 // writes the batch to the db and returns a new batch object
-func (self *syncDb) writeSyncBatch(batch *leveldb.Batch) *leveldb.Batch {
-	err := self.db.Write(batch)
+func (self *syncDb) writeSyncBatch(batch ethdb.Batch) ethdb.Batch {
+	err := batch.Write()
 	if err != nil {
 		glog.V(logger.Warn).Infof("syncDb[%v/%v] saving batch to db failed: %v", self.key.Log(), self.priority, err)
 		return batch
 	}
-	return new(leveldb.Batch)
+	return self.db.NewBatch()
 }
 
 // abstract type for db entries (TODO could be a feature of Receipts)
@@ -280,8 +280,8 @@ func (self *syncDb) dbRead(useBatches bool, counter uint64, fun func(interface{}
 	var batches, n, cnt, total int
 	var more bool
 	var entry *syncDbEntry
-	var it iterator.Iterator
-	var del *leveldb.Batch
+	var it *storage.Iterator
+	var del ethdb.Batch
 	batchSizes := make(chan int)
 
 	for {
@@ -310,7 +310,7 @@ func (self *syncDb) dbRead(useBatches bool, counter uint64, fun func(interface{}
 			useBatches = true
 			continue
 		}
-		del = new(leveldb.Batch)
+		del = self.db.NewBatch()
 		glog.V(logger.Detail).Infof("syncDb[%v/%v]: new iterator: %x (batch %v, count %v)", self.key.Log(), self.priority, key, batches, cnt)
 
 		for n = 0; !useBatches || n < cnt; it.Next() {
@@ -338,12 +338,22 @@ func (self *syncDb) dbRead(useBatches bool, counter uint64, fun func(interface{}
 			total++
 		}
 		glog.V(logger.Debug).Infof("syncDb[%v/%v] - db session closed, batches: %v, total: %v, session total from db: %v/%v", self.key.Log(), self.priority, batches, total, self.dbTotal, self.total)
-		self.db.Write(del) // this could be async called only when db is idle
+		del.Write() // this could be async called only when db is idle
 		it.Release()
 	}
 }
 
-//
+// number of requests persisted in the request db
+func (self *syncDb) persisted() (n int) {
+	prefix := self.start[:34]
+	it := self.db.NewIterator()
+	defer it.Release()
+	for ok := it.Seek(prefix); ok && bytes.HasPrefix(it.Key(), prefix); ok = it.Next() {
+		n++
+	}
+	return n
+}
+
 func (self *syncDb) stop() {
 	close(self.quit)
 	<-self.done
@@ -54,7 +54,7 @@ func newTestSyncDb(priority, bufferSize, batchSize int, dbdir string, t *testing
 		}
 		dbdir = tmp
 	}
-	db, err := storage.NewLDBDatabase(filepath.Join(dbdir, "requestdb"))
+	db, err := storage.OpenDatabase("", filepath.Join(dbdir, "requestdb"))
 	if err != nil {
 		t.Fatalf("unable to create db: %v", err)
 	}
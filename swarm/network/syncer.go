@@ -121,7 +121,8 @@ func (self syncState) String() string {
 
 // syncer parameters (global, not peer specific)
 type SyncParams struct {
-	RequestDbPath      string // path for request db (leveldb)
+	RequestDbPath      string // path for request db
+	RequestDbBackend   string // database backend of the request db, see ethdb.Open
 	RequestDbBatchSize uint   // nuber of items before batch is saved to requestdb
 	KeyBufferSize      uint   // size of key buffer
 	SyncBatchSize      uint   // maximum batchsize for outgoing requests
@@ -155,6 +156,7 @@ type syncer struct {
 	deliveryRequest chan bool       // one of two triggers needed to send unsyncedKeys
 	newUnsyncedKeys chan bool       // one of two triggers needed to send unsynced keys
 	quit            chan bool       // signal to quit loops
+	stats           *syncStats      // progress counters for the status API
 
 	// DB related fields
 	dbAccess *DbAccess            // access to dbStore
@@ -200,6 +202,7 @@ func newSyncer(
 		quit:            make(chan bool),
 		unsyncedKeys:    unsyncedKeys,
 		store:           store,
+		stats:           newSyncStats(state),
 	}
 
 	// initialising
@@ -271,6 +274,7 @@ func (self *syncer) sync() {
 	// 0. first replay stale requests from request db
 	if state.SessionAt == 0 {
 		glog.V(logger.Debug).Infof("syncer[%v]: nothing to sync", self.key.Log())
+		self.stats.synced()
 		return
 	}
 	glog.V(logger.Debug).Infof("syncer[%v]: start replaying stale requests from request db", self.key.Log())
@@ -288,7 +292,9 @@ func (self *syncer) sync() {
 			state.Start = state.Latest
 			glog.V(logger.Debug).Infof("syncer[%v]: start syncronising backlog (unfinished sync: %v)", self.key.Log(), state)
 			// blocks while the entire history upto state is synced
-			self.syncState(state)
+			if !self.syncState(state) {
+				return
+			}
 			if state.Last < state.SessionAt {
 				state.First = state.Last + 1
 			}
@@ -299,7 +305,9 @@ func (self *syncer) sync() {
 		if state.First < state.LastSeenAt {
 			state.Last = state.LastSeenAt
 			glog.V(logger.Debug).Infof("syncer[%v]: start syncronising history upto last disconnect at %v: %v", self.key.Log(), state.LastSeenAt, state)
-			self.syncState(state)
+			if !self.syncState(state) {
+				return
+			}
 			state.First = state.LastSeenAt
 		}
 		state.Latest = storage.ZeroKey
@@ -315,18 +323,24 @@ func (self *syncer) sync() {
 		state.Last = state.SessionAt
 		glog.V(logger.Debug).Infof("syncer[%v]: start syncronising history since last disconnect at %v up until session start at %v: %v", self.key.Log(), state.LastSeenAt, state.SessionAt, state)
 		// blocks until state syncing is finished
-		self.syncState(state)
+		if !self.syncState(state) {
+			return
+		}
 	}
 	glog.V(logger.Info).Infof("syncer[%v]: syncing all history complete", self.key.Log())
+	self.stats.synced()
 
 }
 
 // wait till syncronised block uptil state is synced
-func (self *syncer) syncState(state *syncState) {
+// returns false if the syncer quit before
+func (self *syncer) syncState(state *syncState) bool {
 	self.syncStates <- state
 	select {
 	case <-state.synced:
+		return true
 	case <-self.quit:
+		return false
 	}
 }
 
@@ -484,6 +498,7 @@ LOOP:
 				glog.V(logger.Warn).Infof("syncer[%v]: unable to send unsynced keys: %v", err)
 			}
 			self.state = state
+			self.stats.setState(&stateCopy)
 			glog.V(logger.Debug).Infof("syncer[%v]: --> %v keys sent: (total: %v (%v), history: %v), sent sync state: %v", self.key.Log(), len(unsynced), keyCounts, keyCount, historyCnt, stateCopy)
 			unsynced = nil
 			keys = nil
@@ -545,6 +560,7 @@ LOOP:
 		glog.V(logger.Detail).Infof("syncer[%v]: (priority %v) added to unsynced keys: %v", self.key.Log(), priority, req)
 		keyCounts[priority]++
 		keyCount++
+		self.stats.keys(priority, keys == history)
 		if keys == history {
 			glog.V(logger.Detail).Infof("syncer[%v]: (priority %v) history item %v (synced = %v)", self.key.Log(), priority, req, state.Synced)
 			historyCnt++
@@ -572,6 +588,7 @@ func (self *syncer) syncDeliveries() {
 	var c = [priorities]int{}
 	var n = [priorities]int{}
 	var total, success uint
+	var priority int
 
 	for {
 		deliveries = self.deliveries[p]
@@ -579,19 +596,21 @@ func (self *syncer) syncDeliveries() {
 		case req = <-deliveries:
 			n[p]++
 			c[p]++
+			priority = p
 		default:
 			if p == Low {
 				// blocking, depletion on all channels, no preference for priority
 				select {
 				case req = <-self.deliveries[High]:
-					n[High]++
+					priority = High
 				case req = <-self.deliveries[Medium]:
-					n[Medium]++
+					priority = Medium
 				case req = <-self.deliveries[Low]:
-					n[Low]++
+					priority = Low
 				case <-self.quit:
 					return
 				}
+				n[priority]++
 				p = High
 			} else {
 				p--
@@ -603,6 +622,8 @@ func (self *syncer) syncDeliveries() {
 		if err != nil {
 			glog.V(logger.Warn).Infof("syncer[%v]: failed to create store request for %v: %v", self.key.Log(), req, err)
 		} else {
+			// deliveries below the priority of new chunks are history
+			msg.history = uint(priority) < self.SyncPriorities[PushReq]
 			err = self.store(msg)
 			if err != nil {
 				glog.V(logger.Warn).Infof("syncer[%v]: failed to deliver %v: %v", self.key.Log(), req, err)
@@ -611,6 +632,7 @@ func (self *syncer) syncDeliveries() {
 				glog.V(logger.Detail).Infof("syncer[%v]: %v successfully delivered", self.key.Log(), req)
 			}
 		}
+		self.stats.delivery(priority, err)
 		if total%self.SyncBatchSize == 0 {
 			glog.V(logger.Debug).Infof("syncer[%v]: deliver Total: %v, Success: %v, High: %v/%v, Medium: %v/%v, Low %v/%v", self.key.Log(), total, success, c[High], n[High], c[Medium], n[Medium], c[Low], n[Low])
 		}
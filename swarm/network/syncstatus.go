@@ -0,0 +1,210 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// SyncStatus is the synchronisation progress with a connected peer
+type SyncStatus struct {
+	Peer            string   `json:"peer"`
+	Syncing         bool     `json:"syncing"`         // false if only deliveries are sent
+	Start           string   `json:"start"`           // key range of the current sync state
+	Stop            string   `json:"stop"`            //
+	First           uint64   `json:"first"`           // storage counter range of the current sync state
+	Last            uint64   `json:"last"`            //
+	SessionAt       uint64   `json:"sessionAt"`       // storage counter at connection
+	LastSeenAt      uint64   `json:"lastSeenAt"`      // storage counter at the last batch of keys sent
+	Latest          string   `json:"latest"`          // history cursor
+	HistoryComplete bool     `json:"historyComplete"` // history is synced upto session start
+	HistoryKeys     uint64   `json:"historyKeys"`     // history keys offered
+	Backlog         []int    `json:"backlog"`         // requests queued in memory by priority
+	KeysSent        []uint64 `json:"keysSent"`        // unsynced keys offered by priority
+	Delivered       []uint64 `json:"delivered"`       // chunks delivered by priority
+	Failed          uint64   `json:"failed"`          // failed deliveries
+	DeliveryRate    float64  `json:"deliveryRate"`    // chunks delivered per second during the session
+	Resyncs         uint64   `json:"resyncs"`         // keys offered by forced resyncs
+}
+
+// SyncDebug is the internal state of the syncer with a connected peer
+type SyncDebug struct {
+	*SyncStatus
+	Synced     bool   `json:"synced"`     // history synced upto the last disconnect as recorded in the sync state
+	Keys       []int  `json:"keys"`       // unsynced keys waiting to be offered by priority
+	Buffered   []int  `json:"buffered"`   // requests in the in-memory request queues by priority
+	Persisted  []int  `json:"persisted"`  // requests persisted in the request db by priority
+	Modes      []bool `json:"modes"`      // sync (offer keys) or deliver directly by request type
+	Priorities []uint `json:"priorities"` // priority by request type
+}
+
+// syncStats collects the counters of a syncer
+// updated by the syncer loops, read by the status API
+type syncStats struct {
+	lock            sync.Mutex
+	started         time.Time
+	state           syncState // last sync state sent to the peer
+	historyComplete bool
+	historyKeys     uint64
+	keysSent        [priorities]uint64
+	delivered       [priorities]uint64
+	failed          uint64
+	resyncs         uint64
+}
+
+func newSyncStats(state *syncState) *syncStats {
+	s := &syncStats{started: time.Now()}
+	s.setState(state)
+	return s
+}
+
+// records a copy of the state so that the status does not race the syncer
+func (self *syncStats) setState(state *syncState) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.state = *state
+	if state.DbSyncState != nil {
+		dbState := *state.DbSyncState
+		self.state.DbSyncState = &dbState
+	}
+}
+
+func (self *syncStats) keys(priority int, history bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.keysSent[priority]++
+	if history {
+		self.historyKeys++
+	}
+}
+
+func (self *syncStats) delivery(priority int, err error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if err != nil {
+		self.failed++
+		return
+	}
+	self.delivered[priority]++
+}
+
+func (self *syncStats) synced() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.historyComplete = true
+}
+
+func (self *syncStats) resynced(n uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.resyncs += n
+}
+
+// status of the syncer
+func (self *syncer) status() *SyncStatus {
+	s := self.stats
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	status := &SyncStatus{
+		Syncing:         self.syncF(),
+		SessionAt:       s.state.SessionAt,
+		LastSeenAt:      s.state.LastSeenAt,
+		Latest:          s.state.Latest.String(),
+		HistoryComplete: s.historyComplete,
+		HistoryKeys:     s.historyKeys,
+		Backlog:         make([]int, priorities),
+		KeysSent:        make([]uint64, priorities),
+		Delivered:       make([]uint64, priorities),
+		Failed:          s.failed,
+		Resyncs:         s.resyncs,
+	}
+	if s.state.DbSyncState != nil {
+		status.Start = s.state.Start.String()
+		status.Stop = s.state.Stop.String()
+		status.First = s.state.First
+		status.Last = s.state.Last
+	}
+	var delivered uint64
+	for i := 0; i < priorities; i++ {
+		status.Backlog[i] = len(self.keys[i]) + len(self.queues[i].buffer)
+		status.KeysSent[i] = s.keysSent[i]
+		status.Delivered[i] = s.delivered[i]
+		delivered += s.delivered[i]
+	}
+	if elapsed := time.Since(s.started).Seconds(); elapsed > 0 {
+		status.DeliveryRate = float64(delivered) / elapsed
+	}
+	return status
+}
+
+// internal state of the syncer
+func (self *syncer) debug() *SyncDebug {
+	d := &SyncDebug{
+		SyncStatus: self.status(),
+		Keys:       make([]int, priorities),
+		Buffered:   make([]int, priorities),
+		Persisted:  make([]int, priorities),
+		Modes:      append([]bool(nil), self.SyncModes...),
+		Priorities: append([]uint(nil), self.SyncPriorities...),
+	}
+	self.stats.lock.Lock()
+	d.Synced = self.stats.state.Synced
+	self.stats.lock.Unlock()
+	for i := 0; i < priorities; i++ {
+		d.Keys[i] = len(self.keys[i])
+		d.Buffered[i] = len(self.queues[i].buffer)
+		d.Persisted[i] = self.queues[i].persisted()
+	}
+	return d
+}
+
+// resync offers the peer all chunks stored with an address in the range
+// (start, stop] again, irrespective of the sync state
+// the keys are queued with history priority in the background
+func (self *syncer) resync(start, stop storage.Key) error {
+	state := &syncState{
+		DbSyncState: &storage.DbSyncState{
+			Start: start,
+			Stop:  stop,
+			First: 0,
+			Last:  self.dbAccess.counter(),
+		},
+	}
+	it := self.dbAccess.iterator(state)
+	if it == nil {
+		return fmt.Errorf("no chunks to resync")
+	}
+	priority := self.SyncPriorities[HistoryReq]
+	go func() {
+		var n uint64
+		for key := it.Next(); key != nil; key = it.Next() {
+			if !self.addKey(key, priority, self.quit) {
+				break
+			}
+			n++
+		}
+		self.stats.resynced(n)
+		glog.V(logger.Debug).Infof("syncer[%v]: resync of %v - %v offered %v keys", self.key.Log(), start.Log(), stop.Log(), n)
+	}()
+	return nil
+}
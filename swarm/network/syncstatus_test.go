@@ -0,0 +1,212 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func TestSyncStatus(t *testing.T) {
+	state := &syncState{
+		DbSyncState: &storage.DbSyncState{
+			Start: storage.ZeroKey,
+			Stop:  storage.Key(bytes.Repeat([]byte{0xff}, 32)),
+			First: 1,
+			Last:  10,
+		},
+		SessionAt: 10,
+	}
+	s := &syncer{
+		syncF: func() bool { return true },
+		stats: newSyncStats(state),
+	}
+	for i := 0; i < priorities; i++ {
+		s.keys[i] = make(chan interface{}, 10)
+		s.queues[i] = &syncDb{buffer: make(chan interface{}, 10)}
+	}
+	// the recorded state is a copy
+	state.First = 5
+
+	s.keys[High] <- storage.ZeroKey
+	s.queues[Low].buffer <- storage.ZeroKey
+	s.queues[Low].buffer <- storage.ZeroKey
+	s.stats.keys(High, false)
+	s.stats.keys(Low, true)
+	s.stats.delivery(Medium, nil)
+	s.stats.delivery(Medium, fmt.Errorf("failed"))
+	s.stats.synced()
+
+	status := s.status()
+	if !status.Syncing || !status.HistoryComplete {
+		t.Fatalf("incorrect sync mode: %+v", status)
+	}
+	if status.First != 1 || status.Last != 10 || status.SessionAt != 10 || status.Stop != strings.Repeat("ff", 32) {
+		t.Fatalf("incorrect sync state: %+v", status)
+	}
+	if status.Backlog[High] != 1 || status.Backlog[Medium] != 0 || status.Backlog[Low] != 2 {
+		t.Fatalf("incorrect backlog: %v", status.Backlog)
+	}
+	if status.KeysSent[High] != 1 || status.KeysSent[Low] != 1 || status.HistoryKeys != 1 {
+		t.Fatalf("incorrect keys sent: %v (history: %v)", status.KeysSent, status.HistoryKeys)
+	}
+	if status.Delivered[Medium] != 1 || status.Failed != 1 || status.DeliveryRate <= 0 {
+		t.Fatalf("incorrect deliveries: %v (failed: %v, rate: %v)", status.Delivered, status.Failed, status.DeliveryRate)
+	}
+}
+
+// testSyncer returns a syncer with request queues on a request db in dir and
+// a chunk store holding keys, its sync loops are not started
+func testSyncer(t *testing.T, dir string, state *syncState, keys ...storage.Key) *syncer {
+	local, err := storage.NewLocalStore(storage.MakeHashFunc("SHA3"), storage.NewStoreParams(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		local.DbStore.Put(storage.NewChunk(key, nil))
+	}
+	db, err := storage.OpenDatabase("", filepath.Join(dir, "requests"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &syncer{
+		SyncParams: NewSyncParams(dir),
+		syncF:      func() bool { return true },
+		key:        storage.Key(make([]byte, 32)),
+		state:      state,
+		syncStates: make(chan *syncState, 20),
+		quit:       make(chan bool),
+		stats:      newSyncStats(state),
+		dbAccess:   NewDbAccess(local),
+	}
+	for i := 0; i < priorities; i++ {
+		s.keys[i] = make(chan interface{}, 10)
+		s.queues[i] = newSyncDb(db, s.key, uint(i), 10, 10, func(interface{}, chan bool) bool { return true })
+	}
+	return s
+}
+
+func TestSyncHistoryComplete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-sync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, quit := range []bool{false, true} {
+		state := &syncState{
+			DbSyncState: &storage.DbSyncState{Start: storage.ZeroKey, Stop: storage.ZeroKey},
+			SessionAt:   10,
+			LastSeenAt:  5,
+			Synced:      true,
+			synced:      make(chan bool),
+		}
+		s := testSyncer(t, filepath.Join(dir, fmt.Sprintf("%v", quit)), state)
+		go s.sync()
+		// history since the last disconnect is synced
+		<-s.syncStates
+		if quit {
+			s.stop()
+		} else {
+			close(state.synced)
+		}
+		// syncStates is closed when sync returns
+		for range s.syncStates {
+		}
+		if complete := s.status().HistoryComplete; complete == quit {
+			t.Fatalf("history complete: %v after quit: %v", complete, quit)
+		}
+		if !quit {
+			s.stop()
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-sync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var keys []storage.Key
+	for i := 1; i <= 3; i++ {
+		keys = append(keys, storage.Key(common.Hex2Bytes(fmt.Sprintf("%d0%062x", i, 0))))
+	}
+	state := &syncState{DbSyncState: &storage.DbSyncState{Start: storage.ZeroKey, Stop: storage.ZeroKey}}
+	s := testSyncer(t, dir, state, keys...)
+	defer s.stop()
+
+	// keys in (0x15.., 0x25..] are offered with history priority
+	start := storage.Key(common.Hex2Bytes("15" + strings.Repeat("0", 62)))
+	stop := storage.Key(common.Hex2Bytes("25" + strings.Repeat("0", 62)))
+	if err := s.resync(start, stop); err != nil {
+		t.Fatal(err)
+	}
+	priority := s.SyncPriorities[HistoryReq]
+	key := (<-s.keys[priority]).(storage.Key)
+	if !bytes.Equal(key, keys[1]) {
+		t.Fatalf("expected key %v, got %v", keys[1], key)
+	}
+	// all keys, in address order
+	if err := s.resync(storage.ZeroKey, storage.Key(bytes.Repeat([]byte{0xff}, 32))); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(keys); i++ {
+		if key := (<-s.keys[priority]).(storage.Key); !bytes.Equal(key, keys[i]) {
+			t.Fatalf("key %d: expected %v, got %v", i, keys[i], key)
+		}
+	}
+}
+
+func TestSyncDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-sync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state := &syncState{DbSyncState: &storage.DbSyncState{Start: storage.ZeroKey, Stop: storage.ZeroKey}, Synced: true}
+	s := testSyncer(t, dir, state)
+	defer s.stop()
+
+	// two requests of low priority are persisted
+	for i := 0; i < 2; i++ {
+		entry, err := s.queues[Low].newSyncDbEntry(storage.Key(make([]byte, 32)), uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.queues[Low].db.Put(entry.key, entry.val)
+	}
+	s.keys[High] <- storage.ZeroKey
+
+	debug := s.debug()
+	if !debug.Synced || debug.Keys[High] != 1 || debug.Persisted[Low] != 2 || debug.Persisted[High] != 0 {
+		t.Fatalf("unexpected sync state: %+v", debug)
+	}
+	if len(debug.Modes) != len(s.SyncModes) || len(debug.Priorities) != len(s.SyncPriorities) {
+		t.Fatalf("unexpected sync modes %v and priorities %v", debug.Modes, debug.Priorities)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+)
+
+const (
+	topicFastSearch  = 100 * time.Millisecond // search period until the topic is found
+	topicSlowSearch  = time.Minute            // search period after enough lookups converged
+	topicFastLookups = 50                     // converged lookups before slowing down
+	maxCandidates    = 64                     // discovered nodes kept by the hive per topic
+)
+
+// Topic is the discv5 topic bzz nodes of a network register under
+func Topic(networkId uint64) discv5.Topic {
+	return discv5.Topic(fmt.Sprintf("bzz@%d", networkId))
+}
+
+// TranscoderTopic is the discv5 topic registered by the nodes of a network
+// which transcode streams
+func TranscoderTopic(networkId uint64) discv5.Topic {
+	return discv5.Topic(fmt.Sprintf("bzz-transcoder@%d", networkId))
+}
+
+// topicNetwork is the part of discv5.Network used for topic discovery
+type topicNetwork interface {
+	RegisterTopic(topic discv5.Topic, stop <-chan struct{})
+	SearchTopic(topic discv5.Topic, setPeriod <-chan time.Duration, found chan<- *discv5.Node, lookup chan<- bool)
+}
+
+// TopicDiscovery registers the node under the topics of its network on
+// discv5 and passes the nodes found by searching them to the hive, so that
+// a node can join the overlay without bootnodes
+type TopicDiscovery struct {
+	disc     topicNetwork
+	register []discv5.Topic
+	search   map[discv5.Topic]func(*discover.Node) // handler of the nodes found by topic
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewTopicDiscovery creates topic discovery for the network; transcoders
+// also register the transcoder topic
+func NewTopicDiscovery(disc *discv5.Network, hive *Hive, networkId uint64, transcoder bool) *TopicDiscovery {
+	return newTopicDiscovery(disc, hive, networkId, transcoder)
+}
+
+func newTopicDiscovery(disc topicNetwork, hive *Hive, networkId uint64, transcoder bool) *TopicDiscovery {
+	self := &TopicDiscovery{
+		disc:     disc,
+		register: []discv5.Topic{Topic(networkId)},
+		search: map[discv5.Topic]func(*discover.Node){
+			Topic(networkId):           hive.discovered,
+			TranscoderTopic(networkId): hive.discoveredTranscoder,
+		},
+		quit: make(chan struct{}),
+	}
+	if transcoder {
+		self.register = append(self.register, TranscoderTopic(networkId))
+	}
+	return self
+}
+
+// Start registers and searches the topics in the background
+func (self *TopicDiscovery) Start() {
+	for _, topic := range self.register {
+		self.wg.Add(1)
+		go func(topic discv5.Topic) {
+			defer self.wg.Done()
+			glog.V(logger.Info).Infof("registering discovery topic %v", topic)
+			self.disc.RegisterTopic(topic, self.quit)
+		}(topic)
+	}
+	for topic, found := range self.search {
+		self.wg.Add(1)
+		go self.searchTopic(topic, found)
+	}
+}
+
+// Stop unregisters the topics and ends the searches
+func (self *TopicDiscovery) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+}
+
+// searchTopic searches the topic quickly until lookups converge, then
+// keeps looking for new nodes at a slower pace
+func (self *TopicDiscovery) searchTopic(topic discv5.Topic, handle func(*discover.Node)) {
+	defer self.wg.Done()
+	setPeriod := make(chan time.Duration, 1)
+	found := make(chan *discv5.Node, 16)
+	lookups := make(chan bool, 16)
+	done := make(chan struct{})
+	go func() {
+		self.disc.SearchTopic(topic, setPeriod, found, lookups)
+		close(done)
+	}()
+
+	setPeriod <- topicFastSearch
+	converged := 0
+	quit := self.quit
+	for {
+		select {
+		case n := <-found:
+			if quit == nil {
+				continue
+			}
+			glog.V(logger.Detail).Infof("discovered %v node %v", topic, n)
+			handle(discover.NewNode(discover.NodeID(n.ID), n.IP, n.UDP, n.TCP))
+		case ok := <-lookups:
+			if !ok || quit == nil {
+				continue
+			}
+			if converged++; converged == topicFastLookups {
+				select {
+				case setPeriod <- topicSlowSearch:
+				default:
+				}
+			}
+		case <-quit:
+			// closing the period channel cancels the search, results are
+			// drained until then so that discovery does not block
+			close(setPeriod)
+			quit = nil
+		case <-done:
+			return
+		}
+	}
+}
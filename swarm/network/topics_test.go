@@ -0,0 +1,204 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+)
+
+// testTopicNetwork records registered topics and finds the given nodes on
+// search, followed by enough converged lookups to slow down the search
+type testTopicNetwork struct {
+	nodes      map[discv5.Topic][]*discv5.Node
+	registered chan discv5.Topic
+	periods    chan time.Duration
+}
+
+func (self *testTopicNetwork) RegisterTopic(topic discv5.Topic, stop <-chan struct{}) {
+	self.registered <- topic
+	<-stop
+}
+
+func (self *testTopicNetwork) SearchTopic(topic discv5.Topic, setPeriod <-chan time.Duration, found chan<- *discv5.Node, lookup chan<- bool) {
+	self.periods <- <-setPeriod
+	for _, n := range self.nodes[topic] {
+		found <- n
+	}
+	for i := 0; i < topicFastLookups; i++ {
+		lookup <- true
+	}
+	for period := range setPeriod {
+		self.periods <- period
+	}
+}
+
+func TestTopics(t *testing.T) {
+	if Topic(326) == Topic(3) {
+		t.Errorf("networks share topic %v", Topic(3))
+	}
+	if Topic(326) == TranscoderTopic(326) {
+		t.Errorf("transcoder topic same as network topic %v", Topic(326))
+	}
+}
+
+func TestHiveDiscovered(t *testing.T) {
+	hive := NewHive(common.Hash{}, NewHiveParams(""), false, false)
+	hive.id = discover.NodeID{1}
+
+	hive.discovered(discover.NewNode(hive.id, net.IP{127, 0, 0, 1}, 30399, 30399))
+	if n := len(hive.candidates); n != 0 {
+		t.Fatalf("self queued as candidate")
+	}
+	// discovering more nodes than the queue holds must not block
+	for i := 0; i < maxCandidates+1; i++ {
+		hive.discovered(discover.NewNode(discover.NodeID{2, byte(i)}, net.IP{127, 0, 0, 1}, 30399, 30399))
+	}
+	if n := len(hive.candidates); n != maxCandidates {
+		t.Fatalf("got %d candidates, want %d", n, maxCandidates)
+	}
+	if n := <-hive.candidates; n.ID != (discover.NodeID{2, 0}) {
+		t.Errorf("candidates out of order, first is %x", n.ID[:2])
+	}
+}
+
+func TestHiveTranscoders(t *testing.T) {
+	hive := NewHive(common.Hash{}, NewHiveParams(""), false, false)
+	hive.id = discover.NodeID{1}
+
+	hive.discoveredTranscoder(discover.NewNode(hive.id, net.IP{127, 0, 0, 1}, 30399, 30399))
+	if n := len(hive.Transcoders()); n != 0 {
+		t.Fatalf("self recorded as transcoder")
+	}
+	for i := 0; i < maxCandidates+1; i++ {
+		hive.discoveredTranscoder(discover.NewNode(discover.NodeID{2, byte(i)}, net.IP{127, 0, 0, 1}, 30399, 30399))
+	}
+	// a known transcoder is updated even if the set is full
+	hive.discoveredTranscoder(discover.NewNode(discover.NodeID{2, 0}, net.IP{127, 0, 0, 2}, 30399, 30399))
+	transcoders := hive.Transcoders()
+	if n := len(transcoders); n != maxCandidates {
+		t.Fatalf("got %d transcoders, want %d", n, maxCandidates)
+	}
+	for _, n := range transcoders {
+		if n.ID == (discover.NodeID{2, maxCandidates}) {
+			t.Errorf("transcoder recorded beyond the limit")
+		}
+		if n.ID == (discover.NodeID{2, 0}) && !n.IP.Equal(net.IP{127, 0, 0, 2}) {
+			t.Errorf("known transcoder not updated: %v", n)
+		}
+	}
+	if n := len(hive.candidates); n != 0 {
+		t.Errorf("transcoders queued as bzz candidates")
+	}
+}
+
+func TestTopicDiscovery(t *testing.T) {
+	hive := NewHive(common.Hash{}, NewHiveParams(""), false, false)
+	hive.id = discover.NodeID{1}
+	bzz := discv5.NewNode(discv5.NodeID{2}, net.IP{127, 0, 0, 1}, 30399, 30399)
+	transcoder := discv5.NewNode(discv5.NodeID{3}, net.IP{127, 0, 0, 1}, 30399, 30399)
+	disc := &testTopicNetwork{
+		nodes: map[discv5.Topic][]*discv5.Node{
+			Topic(3):           {bzz},
+			TranscoderTopic(3): {transcoder},
+		},
+		registered: make(chan discv5.Topic, 2),
+		periods:    make(chan time.Duration, 4),
+	}
+	topics := newTopicDiscovery(disc, hive, 3, true)
+	handled := make(chan discv5.Topic, 2)
+	for topic, found := range topics.search {
+		topic, found := topic, found
+		topics.search[topic] = func(n *discover.Node) {
+			found(n)
+			handled <- topic
+		}
+	}
+	topics.Start()
+
+	registered := map[discv5.Topic]bool{<-disc.registered: true, <-disc.registered: true}
+	if !registered[Topic(3)] || !registered[TranscoderTopic(3)] {
+		t.Fatalf("expected network and transcoder topic registered, got %v", registered)
+	}
+	// both topics are searched fast until lookups converge
+	periods := make(map[time.Duration]int)
+	for i := 0; i < 4; i++ {
+		periods[<-disc.periods]++
+	}
+	if periods[topicFastSearch] != 2 || periods[topicSlowSearch] != 2 {
+		t.Fatalf("unexpected search periods %v", periods)
+	}
+	<-handled
+	<-handled
+	topics.Stop()
+
+	if n := <-hive.candidates; n.ID != discover.NodeID(bzz.ID) {
+		t.Errorf("expected bzz node queued, got %v", n)
+	}
+	if n := len(hive.candidates); n != 0 {
+		t.Errorf("%d unexpected candidates queued", n)
+	}
+	if nodes := hive.Transcoders(); len(nodes) != 1 || nodes[0].ID != discover.NodeID(transcoder.ID) {
+		t.Errorf("expected transcoder %x, got %v", transcoder.ID[:2], nodes)
+	}
+}
+
+func TestHiveStartCallsCandidates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bzz-hive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := NewHiveParams(dir)
+	params.CallInterval = uint64(10 * time.Millisecond)
+	hive := NewHive(common.Hash{}, params, false, false)
+
+	// with no known peers the hive calls nodes found by topic discovery
+	n := discover.NewNode(discover.NodeID{2}, net.IP{127, 0, 0, 1}, 30399, 30399)
+	hive.discovered(n)
+	called := make(chan string, 1)
+	connectPeer := func(url string) error {
+		select {
+		case called <- url:
+		default:
+		}
+		return nil
+	}
+	if err := hive.Start(discover.NodeID{1}, func() string { return "" }, connectPeer); err != nil {
+		t.Fatal(err)
+	}
+	defer hive.Stop()
+
+	select {
+	case url := <-called:
+		if url != n.String() {
+			t.Fatalf("called %v, expected %v", url, n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("discovered node not called")
+	}
+	if len(hive.candidates) != 0 {
+		t.Fatalf("candidate not consumed")
+	}
+}
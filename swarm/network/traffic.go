@@ -0,0 +1,312 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+/*
+Traffic classes and bandwidth limits
+
+Outgoing bzz messages are assigned to traffic classes in order of priority:
+live stream, retrieval, push-sync and history sync. Handshake, peer
+gossip, payment and audit messages are control traffic, written ahead of
+all classes but subject to the same bandwidth limits.
+
+Each peer connection has a scheduler that writes queued messages by weighted
+fair queueing: every class gets a share of the bandwidth of the connection
+proportional to its weight (HiveParams.TrafficWeights), so a large history
+sync cannot starve live video delivery. Writes are throttled by a token
+bucket for the connection (HiveParams.MaxPeerBandwidth) and one shared by
+all connections (HiveParams.MaxBandwidth), both in bytes per second, zero
+meaning unlimited.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// traffic classes in order of priority
+const (
+	liveStreamTraffic  = iota // video stream relay and transcoding
+	retrievalTraffic          // retrieve requests and deliveries
+	pushSyncTraffic           // syncing of new chunks and receipts
+	historySyncTraffic        // syncing of stored chunks
+	trafficClasses            // number of traffic classes
+
+	controlTraffic = -1 // written first, throttled but not weighted
+)
+
+var trafficClassNames = [trafficClasses]string{"stream", "retrieval", "pushsync", "history"}
+
+// default share of bandwidth of the traffic classes
+var defaultTrafficWeights = []uint{8, 4, 2, 1}
+
+var errTrafficStopped = errors.New("peer disconnected")
+
+// counters by traffic class
+var (
+	trafficBytesMeters [trafficClasses]gometrics.Meter
+	trafficMsgCounters [trafficClasses]gometrics.Counter
+	trafficWaitTimers  [trafficClasses]gometrics.Timer
+)
+
+func init() {
+	for i, name := range trafficClassNames {
+		trafficBytesMeters[i] = metrics.NewMeter(fmt.Sprintf("bzz/traffic/%s/bytes", name))
+		trafficMsgCounters[i] = metrics.NewCounter(fmt.Sprintf("bzz/traffic/%s/msgs", name))
+		trafficWaitTimers[i] = metrics.NewTimer(fmt.Sprintf("bzz/traffic/%s/wait", name))
+	}
+}
+
+// trafficClass returns the class of an outgoing message
+func trafficClass(code uint64, data interface{}) int {
+	switch code {
+	case streamRequestMsg, transcodeRequestMsg, transcodeAckMsg:
+		return liveStreamTraffic
+	case retrieveRequestMsg, cancelRequestMsg:
+		return retrievalTraffic
+	case unsyncedKeysMsg, deliveryRequestMsg, receiptRequestMsg, receiptMsg:
+		return pushSyncTraffic
+	case storeRequestMsg:
+		req := data.(*storeRequestMsgData)
+		switch {
+		case req.Id != 0:
+			// delivery in response to a retrieve request
+			return retrievalTraffic
+		case req.history:
+			return historySyncTraffic
+		}
+		return pushSyncTraffic
+	}
+	return controlTraffic
+}
+
+// validTrafficWeights returns the default weights unless there is a
+// positive weight for each traffic class
+func validTrafficWeights(weights []uint) []uint {
+	if len(weights) != trafficClasses {
+		return defaultTrafficWeights
+	}
+	for _, w := range weights {
+		if w == 0 {
+			return defaultTrafficWeights
+		}
+	}
+	return weights
+}
+
+// tokenBucket limits the rate of bytes written, allowing bursts of up to one
+// second worth of traffic
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64 // bytes per second, 0 is unlimited
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate uint64) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// take reserves n bytes and returns the time to wait before writing them
+func (self *tokenBucket) take(n int) time.Duration {
+	if self.rate == 0 {
+		return 0
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	now := self.now()
+	self.tokens += now.Sub(self.last).Seconds() * self.rate
+	if self.tokens > self.rate {
+		self.tokens = self.rate
+	}
+	self.last = now
+	self.tokens -= float64(n)
+	if self.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-self.tokens / self.rate * float64(time.Second))
+}
+
+// message waiting to be written
+type outMsg struct {
+	code    uint64
+	payload []byte
+	class   int
+	finish  float64 // virtual finish time
+	queued  time.Time
+	errC    chan error
+}
+
+// trafficScheduler writes the outgoing messages of a peer connection
+type trafficScheduler struct {
+	rw      p2p.MsgReadWriter
+	weights []uint
+	peer    *tokenBucket // limit of the connection
+	global  *tokenBucket // limit shared by all connections
+	lock    sync.Mutex
+	control []*outMsg
+	queues  [trafficClasses][]*outMsg
+	finish  [trafficClasses]float64 // virtual finish time of the last message by class
+	vtime   float64                 // virtual time, finish time of the last message written
+	after   func(time.Duration) <-chan time.Time
+	wakeup  chan bool
+	quit    chan bool
+}
+
+func newTrafficScheduler(rw p2p.MsgReadWriter, weights []uint, peerRate uint64, global *tokenBucket) *trafficScheduler {
+	self := &trafficScheduler{
+		rw:      rw,
+		weights: validTrafficWeights(weights),
+		peer:    newTokenBucket(peerRate),
+		global:  global,
+		after:   time.After,
+		wakeup:  make(chan bool, 1),
+		quit:    make(chan bool),
+	}
+	go self.loop()
+	return self
+}
+
+// send queues a message in its traffic class and blocks until it is written
+func (self *trafficScheduler) send(code uint64, data interface{}, class int) error {
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	msg := self.queue(code, payload, class)
+	select {
+	case err := <-msg.errC:
+		return err
+	case <-self.quit:
+		return errTrafficStopped
+	}
+}
+
+// queue adds a message to the queue of its class and wakes up the loop
+// control messages are queued ahead of all classes
+func (self *trafficScheduler) queue(code uint64, payload []byte, class int) *outMsg {
+	msg := &outMsg{
+		code:    code,
+		payload: payload,
+		class:   class,
+		queued:  time.Now(),
+		errC:    make(chan error, 1),
+	}
+	self.lock.Lock()
+	if class == controlTraffic {
+		self.control = append(self.control, msg)
+	} else {
+		start := self.finish[class]
+		if start < self.vtime {
+			start = self.vtime
+		}
+		msg.finish = start + float64(len(payload))/float64(self.weights[class])
+		self.finish[class] = msg.finish
+		self.queues[class] = append(self.queues[class], msg)
+	}
+	self.lock.Unlock()
+
+	select {
+	case self.wakeup <- true:
+	default:
+	}
+	return msg
+}
+
+// next removes the first control message or else the queued message with the
+// earliest virtual finish time
+func (self *trafficScheduler) next() *outMsg {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.control) > 0 {
+		msg := self.control[0]
+		self.control = self.control[1:]
+		return msg
+	}
+	class := -1
+	for i, queue := range self.queues {
+		if len(queue) > 0 && (class < 0 || queue[0].finish < self.queues[class][0].finish) {
+			class = i
+		}
+	}
+	if class < 0 {
+		return nil
+	}
+	msg := self.queues[class][0]
+	self.queues[class] = self.queues[class][1:]
+	self.vtime = msg.finish
+	return msg
+}
+
+func (self *trafficScheduler) loop() {
+	for {
+		msg := self.next()
+		if msg == nil {
+			select {
+			case <-self.wakeup:
+				continue
+			case <-self.quit:
+				return
+			}
+		}
+		n := len(msg.payload)
+		wait := self.peer.take(n)
+		if w := self.global.take(n); w > wait {
+			wait = w
+		}
+		if wait > 0 {
+			select {
+			case <-self.after(wait):
+			case <-self.quit:
+				return
+			}
+		}
+		if msg.class != controlTraffic {
+			trafficWaitTimers[msg.class].UpdateSince(msg.queued)
+			trafficBytesMeters[msg.class].Mark(int64(n))
+			trafficMsgCounters[msg.class].Inc(1)
+		}
+		msg.errC <- self.write(msg.code, msg.payload)
+	}
+}
+
+func (self *trafficScheduler) write(code uint64, payload []byte) error {
+	return self.rw.WriteMsg(p2p.Msg{
+		Code:    code,
+		Size:    uint32(len(payload)),
+		Payload: bytes.NewReader(payload),
+	})
+}
+
+// stop fails all queued and future messages
+func (self *trafficScheduler) stop() {
+	close(self.quit)
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// testMsgWriter records the codes of written messages, each write blocks
+// until released
+type testMsgWriter struct {
+	codes   chan uint64
+	release chan bool
+}
+
+func (self *testMsgWriter) ReadMsg() (p2p.Msg, error) {
+	select {}
+}
+
+func (self *testMsgWriter) WriteMsg(msg p2p.Msg) error {
+	self.codes <- msg.Code
+	<-self.release
+	return nil
+}
+
+func TestTrafficSchedulerPriority(t *testing.T) {
+	rw := &testMsgWriter{codes: make(chan uint64, 10), release: make(chan bool)}
+	s := newTrafficScheduler(rw, nil, 0, newTokenBucket(0))
+	defer s.stop()
+	defer close(rw.release)
+
+	// the first message blocks the writer while the others are queued
+	s.queue(storeRequestMsg, make([]byte, 4096), historySyncTraffic)
+	codes := []uint64{<-rw.codes}
+	for i := 0; i < 4; i++ {
+		s.queue(storeRequestMsg, make([]byte, 4096), historySyncTraffic)
+	}
+	s.queue(streamRequestMsg, make([]byte, 4096), liveStreamTraffic)
+	s.queue(peersMsg, make([]byte, 4096), controlTraffic)
+
+	for i := 0; i < 6; i++ {
+		rw.release <- true
+		codes = append(codes, <-rw.codes)
+	}
+	// control and stream messages overtake the queued history but not the
+	// one being written
+	if codes[0] != storeRequestMsg || codes[1] != peersMsg || codes[2] != streamRequestMsg {
+		t.Fatalf("messages not prioritised: %v", codes)
+	}
+}
+
+func TestTrafficThrottling(t *testing.T) {
+	rw := &testMsgWriter{codes: make(chan uint64, 10), release: make(chan bool)}
+	close(rw.release)
+	const rate = 100
+	s := newTrafficScheduler(rw, nil, rate, newTokenBucket(0))
+	defer s.stop()
+
+	// freeze the clock and record the waits of the scheduler
+	now := time.Now()
+	s.peer.lock.Lock()
+	s.peer.now = func() time.Time { return now }
+	s.peer.last = now
+	s.peer.lock.Unlock()
+	waits := make(chan time.Duration, 10)
+	s.after = func(d time.Duration) <-chan time.Time {
+		waits <- d
+		c := make(chan time.Time, 1)
+		c <- now
+		return c
+	}
+
+	// control bytes use up the burst, the data message has to wait for the rest
+	control := s.queue(peersMsg, make([]byte, 80), controlTraffic)
+	data := s.queue(storeRequestMsg, make([]byte, 60), pushSyncTraffic)
+	for _, msg := range []*outMsg{control, data} {
+		if err := <-msg.errC; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := <-rw.codes; code != peersMsg {
+		t.Fatalf("expected control message first, got %v", code)
+	}
+	exp := time.Duration(float64(80+60-rate) / rate * float64(time.Second))
+	select {
+	case wait := <-waits:
+		if wait != exp {
+			t.Fatalf("expected wait of %v, got %v", exp, wait)
+		}
+	default:
+		t.Fatalf("data message not throttled")
+	}
+	if len(waits) != 0 {
+		t.Fatalf("unexpected waits: %d", len(waits))
+	}
+}
+
+func TestTrafficClass(t *testing.T) {
+	for _, test := range []struct {
+		code  uint64
+		data  interface{}
+		class int
+	}{
+		{streamRequestMsg, &streamRequestMsgData{}, liveStreamTraffic},
+		{retrieveRequestMsg, &retrieveRequestMsgData{}, retrievalTraffic},
+		{storeRequestMsg, &storeRequestMsgData{Id: 1}, retrievalTraffic},
+		{storeRequestMsg, &storeRequestMsgData{}, pushSyncTraffic},
+		{storeRequestMsg, &storeRequestMsgData{history: true}, historySyncTraffic},
+		{peersMsg, &peersMsgData{}, controlTraffic},
+	} {
+		if class := trafficClass(test.code, test.data); class != test.class {
+			t.Errorf("message %v: expected class %v, got %v", test.code, test.class, class)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(1000)
+	now := b.last
+	b.now = func() time.Time { return now }
+	if wait := b.take(1000); wait != 0 {
+		t.Fatalf("burst of one second throttled: %v", wait)
+	}
+	if wait := b.take(500); wait != 500*time.Millisecond {
+		t.Fatalf("expected wait of 500ms, got %v", wait)
+	}
+	// tokens refill over time up to the burst of one second
+	now = now.Add(10 * time.Second)
+	if wait := b.take(1500); wait != 500*time.Millisecond {
+		t.Fatalf("expected wait of 500ms, got %v", wait)
+	}
+	if wait := newTokenBucket(0).take(1 << 30); wait != 0 {
+		t.Fatalf("unlimited bucket throttled: %v", wait)
+	}
+}
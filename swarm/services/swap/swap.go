@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/contracts/chequebook"
@@ -74,24 +75,27 @@ type PayProfile struct {
 	PublicKey   string         // check against signature of promise
 	Contract    common.Address // address of chequebook contract
 	Beneficiary common.Address // recipient address for swarm sales revenue
-	privateKey  *ecdsa.PrivateKey
+	signer      accounts.Signer
 	publicKey   *ecdsa.PublicKey
 	owner       common.Address
 	chbook      *chequebook.Chequebook
 	lock        sync.RWMutex
 }
 
-func DefaultSwapParams(contract common.Address, prvkey *ecdsa.PrivateKey) *SwapParams {
-	pubkey := &prvkey.PublicKey
+func DefaultSwapParams(contract common.Address, signer accounts.Signer, pubkey *ecdsa.PublicKey) *SwapParams {
+	profile := &PayProfile{
+		Contract:  contract,
+		signer:    signer,
+		publicKey: pubkey,
+	}
+	// without the account's key the profile is left to the configuration file
+	if pubkey != nil {
+		profile.PublicKey = common.ToHex(crypto.FromECDSAPub(pubkey))
+		profile.Beneficiary = crypto.PubkeyToAddress(*pubkey)
+		profile.owner = profile.Beneficiary
+	}
 	return &SwapParams{
-		PayProfile: &PayProfile{
-			PublicKey:   common.ToHex(crypto.FromECDSAPub(pubkey)),
-			Contract:    contract,
-			Beneficiary: crypto.PubkeyToAddress(*pubkey),
-			privateKey:  prvkey,
-			publicKey:   pubkey,
-			owner:       crypto.PubkeyToAddress(*pubkey),
-		},
+		PayProfile: profile,
 		Params: &swap.Params{
 			Profile: &swap.Profile{
 				BuyAt:  buyAt,
@@ -135,7 +139,7 @@ func NewSwap(local *SwapParams, remote *SwapProfile, backend chequebook.Backend,
 		glog.V(logger.Info).Infof("invalid contract %v for peer %v: %v)", remote.Contract.Hex()[:8], proto, err)
 	} else {
 		// remote contract valid, create inbox
-		in, err = chequebook.NewInbox(local.privateKey, remote.Contract, local.Beneficiary, crypto.ToECDSAPub(common.FromHex(remote.PublicKey)), backend)
+		in, err = chequebook.NewInbox(local.signer, remote.Contract, local.Beneficiary, crypto.ToECDSAPub(common.FromHex(remote.PublicKey)), backend)
 		if err != nil {
 			glog.V(logger.Warn).Infof("unable to set up inbox for chequebook contract %v for peer %v: %v)", remote.Contract.Hex()[:8], proto, err)
 		}
@@ -183,17 +187,19 @@ func (self *SwapParams) Chequebook() *chequebook.Chequebook {
 	return self.chbook
 }
 
-func (self *SwapParams) PrivateKey() *ecdsa.PrivateKey {
-	return self.privateKey
+// Signer returns the signer of cheques and chequebook transactions.
+func (self *SwapParams) Signer() accounts.Signer {
+	return self.signer
 }
 
 // func (self *SwapParams) PublicKey() *ecdsa.PublicKey {
 // 	return self.publicKey
 // }
 
-func (self *SwapParams) SetKey(prvkey *ecdsa.PrivateKey) {
-	self.privateKey = prvkey
-	self.publicKey = &prvkey.PublicKey
+// SetSigner sets the account signing cheques and chequebook transactions.
+func (self *SwapParams) SetSigner(signer accounts.Signer, pubkey *ecdsa.PublicKey) {
+	self.signer = signer
+	self.publicKey = pubkey
 }
 
 // setChequebook(path, backend) wraps the
@@ -213,7 +219,7 @@ func (self *SwapParams) SetChequebook(ctx context.Context, backend chequebook.Ba
 }
 
 func (self *SwapParams) deployChequebook(ctx context.Context, backend chequebook.Backend, path string) error {
-	opts := bind.NewKeyedTransactor(self.privateKey)
+	opts := bind.NewSignerTransactor(self.signer)
 	opts.Value = self.AutoDepositBuffer
 	opts.Context = ctx
 
@@ -266,10 +272,10 @@ func (self *SwapParams) newChequebookFromContract(path string, backend chequeboo
 	}
 
 	chbookpath := filepath.Join(path, "chequebooks", hexkey+".json")
-	self.chbook, err = chequebook.LoadChequebook(chbookpath, self.privateKey, backend, true)
+	self.chbook, err = chequebook.LoadChequebook(chbookpath, self.signer, backend, true)
 
 	if err != nil {
-		self.chbook, err = chequebook.NewChequebook(chbookpath, self.Contract, self.privateKey, backend)
+		self.chbook, err = chequebook.NewChequebook(chbookpath, self.Contract, self.signer, backend)
 		if err != nil {
 			glog.V(logger.Warn).Infof("unable to initialise chequebook (owner: %v): %v", self.owner.Hex(), err)
 			return fmt.Errorf("unable to initialise chequebook (owner: %v): %v", self.owner.Hex(), err)
@@ -297,6 +297,35 @@ type LazyChunkReader struct {
 	hashSize  int64       // inherit from chunker
 }
 
+// ChunkKeys returns the keys of all chunks of the document with root key,
+// retrieving the intermediate chunks from store. A chunk is intermediate if
+// the size of its subtree is larger than its data, in which case its data
+// is the concatenation of the keys of its children.
+func ChunkKeys(key Key, store ChunkStore) ([]Key, error) {
+	chunk, err := store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if chunk.SData == nil || len(chunk.SData) < 8 {
+		return nil, fmt.Errorf("chunk %v not found", key.Log())
+	}
+	keys := []Key{key}
+	data := chunk.SData[8:]
+	size := int64(binary.LittleEndian.Uint64(chunk.SData[:8]))
+	if size <= int64(len(data)) {
+		return keys, nil
+	}
+	hashSize := len(key)
+	for i := 0; i+hashSize <= len(data); i += hashSize {
+		children, err := ChunkKeys(Key(data[i:i+hashSize]), store)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, children...)
+	}
+	return keys, nil
+}
+
 // implements the Joiner interface
 func (self *TreeChunker) Join(key Key, chunkC chan *Chunk) LazySectionReader {
 
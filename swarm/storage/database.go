@@ -23,21 +23,21 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/compression/rle"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
-	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 const openFileLimit = 128
 
+// LDBDatabase wraps the database backends of ethdb for swarm's stores.
 type LDBDatabase struct {
-	db   *leveldb.DB
+	db   ethdb.Database
 	comp bool
 }
 
-func NewLDBDatabase(file string) (*LDBDatabase, error) {
-	// Open the db
-	db, err := leveldb.OpenFile(file, &opt.Options{OpenFilesCacheCapacity: openFileLimit})
+// OpenDatabase opens the database in directory file with the given backend,
+// see ethdb.Open.
+func OpenDatabase(backend, file string) (*LDBDatabase, error) {
+	db, err := ethdb.Open(backend, file, 0, openFileLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -52,14 +52,14 @@ func (self *LDBDatabase) Put(key []byte, value []byte) {
 		value = rle.Compress(value)
 	}
 
-	err := self.db.Put(key, value, nil)
+	err := self.db.Put(key, value)
 	if err != nil {
 		fmt.Println("Error put", err)
 	}
 }
 
 func (self *LDBDatabase) Get(key []byte) ([]byte, error) {
-	dat, err := self.db.Get(key, nil)
+	dat, err := self.db.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +72,7 @@ func (self *LDBDatabase) Get(key []byte) ([]byte, error) {
 }
 
 func (self *LDBDatabase) Delete(key []byte) error {
-	return self.db.Delete(key, nil)
+	return self.db.Delete(key)
 }
 
 func (self *LDBDatabase) LastKnownTD() []byte {
@@ -85,15 +85,56 @@ func (self *LDBDatabase) LastKnownTD() []byte {
 	return data
 }
 
-func (self *LDBDatabase) NewIterator() iterator.Iterator {
-	return self.db.NewIterator(nil, nil)
+// NewIterator returns an iterator over all entries, positioned before the
+// first one.
+func (self *LDBDatabase) NewIterator() *Iterator {
+	return &Iterator{db: self.db, it: self.db.NewIteratorWithPrefix(nil)}
 }
 
-func (self *LDBDatabase) Write(batch *leveldb.Batch) error {
-	return self.db.Write(batch, nil)
+// NewBatch returns a batch whose writes are applied atomically by Write.
+func (self *LDBDatabase) NewBatch() ethdb.Batch {
+	return self.db.NewBatch()
 }
 
 func (self *LDBDatabase) Close() {
-	// Close the leveldb database
 	self.db.Close()
 }
+
+// Iterator is a database iterator which can be repositioned with Seek.
+type Iterator struct {
+	db    ethdb.Database
+	it    ethdb.Iterator
+	valid bool
+}
+
+// Seek moves the iterator to the first entry whose key is not smaller than key
+// and reports whether there is one.
+func (self *Iterator) Seek(key []byte) bool {
+	self.it.Release()
+	self.it = self.db.NewIteratorWithRange(key, nil)
+	return self.Next()
+}
+
+// Next moves the iterator to the next entry and reports whether there is one.
+func (self *Iterator) Next() bool {
+	self.valid = self.it.Next()
+	return self.valid
+}
+
+// Valid reports whether the iterator is positioned at an entry.
+func (self *Iterator) Valid() bool {
+	return self.valid
+}
+
+func (self *Iterator) Key() []byte {
+	return self.it.Key()
+}
+
+func (self *Iterator) Value() []byte {
+	return self.it.Value()
+}
+
+func (self *Iterator) Release() {
+	self.it.Release()
+	self.valid = false
+}
@@ -28,11 +28,10 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 )
 
 const (
@@ -74,12 +73,14 @@ type DbStore struct {
 	lock sync.Mutex
 }
 
-func NewDbStore(path string, hash Hasher, capacity uint64, radius int) (s *DbStore, err error) {
+// NewDbStore opens the chunk database at path with the given database backend,
+// an empty backend selects the default one.
+func NewDbStore(backend, path string, hash Hasher, capacity uint64, radius int) (s *DbStore, err error) {
 	s = new(DbStore)
 
 	s.hashfunc = hash
 
-	s.db, err = NewLDBDatabase(path)
+	s.db, err = OpenDatabase(backend, path)
 	if err != nil {
 		return
 	}
@@ -205,7 +206,7 @@ func (s *DbStore) collectGarbage(ratio float32) {
 	it := s.db.NewIterator()
 	it.Seek(s.gcPos)
 	if it.Valid() {
-		s.gcPos = it.Key()
+		s.gcPos = common.CopyBytes(it.Key())
 	} else {
 		s.gcPos = nil
 	}
@@ -216,7 +217,7 @@ func (s *DbStore) collectGarbage(ratio float32) {
 		if (s.gcPos == nil) || (s.gcPos[0] != kpIndex) {
 			it.Seek(s.gcStartPos)
 			if it.Valid() {
-				s.gcPos = it.Key()
+				s.gcPos = common.CopyBytes(it.Key())
 			} else {
 				s.gcPos = nil
 			}
@@ -237,7 +238,7 @@ func (s *DbStore) collectGarbage(ratio float32) {
 		gcnt++
 		it.Next()
 		if it.Valid() {
-			s.gcPos = it.Key()
+			s.gcPos = common.CopyBytes(it.Key())
 		} else {
 			s.gcPos = nil
 		}
@@ -252,12 +253,12 @@ func (s *DbStore) collectGarbage(ratio float32) {
 	// actual gc
 	for i := 0; i < gcnt; i++ {
 		if s.gcArray[i].value <= cutval {
-			batch := new(leveldb.Batch)
+			batch := s.db.NewBatch()
 			batch.Delete(s.gcArray[i].idxKey)
 			batch.Delete(getDataKey(s.gcArray[i].idx))
 			s.entryCnt--
 			batch.Put(keyEntryCnt, U64ToBytes(s.entryCnt))
-			s.db.Write(batch)
+			batch.Write()
 		}
 	}
 
@@ -293,7 +294,7 @@ func (s *DbStore) Put(chunk *Chunk) {
 		s.collectGarbage(gcArrayFreeRatio)
 	}
 
-	batch := new(leveldb.Batch)
+	batch := s.db.NewBatch()
 
 	batch.Put(getDataKey(s.dataIdx), data)
 
@@ -310,7 +311,7 @@ func (s *DbStore) Put(chunk *Chunk) {
 	batch.Put(keyAccessCnt, U64ToBytes(s.accessCnt))
 	s.accessCnt++
 
-	s.db.Write(batch)
+	batch.Write()
 	if chunk.dbStored != nil {
 		close(chunk.dbStored)
 	}
@@ -325,7 +326,7 @@ func (s *DbStore) tryAccessIdx(ikey []byte, index *dpaDBIndex) bool {
 	}
 	decodeIndex(idata, index)
 
-	batch := new(leveldb.Batch)
+	batch := s.db.NewBatch()
 
 	batch.Put(keyAccessCnt, U64ToBytes(s.accessCnt))
 	s.accessCnt++
@@ -333,7 +334,7 @@ func (s *DbStore) tryAccessIdx(ikey []byte, index *dpaDBIndex) bool {
 	idata = encodeIndex(index)
 	batch.Put(ikey, idata)
 
-	s.db.Write(batch)
+	batch.Write()
 
 	return true
 }
@@ -354,7 +355,7 @@ func (s *DbStore) Get(key Key) (chunk *Chunk, err error) {
 		hasher := s.hashfunc()
 		hasher.Write(data)
 		hash := hasher.Sum(nil)
-		if !bytes.Equal(hash, key) {
+		if !bytes.Equal(hash, key) && !IsValidResourceChunk(key, data) {
 			s.db.Delete(getDataKey(index.Idx))
 			err = fmt.Errorf("invalid chunk. hash=%x, key=%v", hash, key[:])
 			return
@@ -427,7 +428,7 @@ type DbSyncState struct {
 // implements the syncer iterator interface
 // iterates by storage index (~ time of storage = first entry to db)
 type dbSyncIterator struct {
-	it iterator.Iterator
+	it *Iterator
 	DbSyncState
 }
 
@@ -18,18 +18,24 @@ package storage
 
 import (
 	"bytes"
+	"crypto/rand"
 	"io/ioutil"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 func initDbStore(t *testing.T) *DbStore {
+	return initDbStoreBackend(t, "", defaultDbCapacity)
+}
+
+func initDbStoreBackend(t *testing.T, backend string, capacity uint64) *DbStore {
 	dir, err := ioutil.TempDir("", "bzz-storage-test")
 	if err != nil {
 		t.Fatal(err)
 	}
-	m, err := NewDbStore(dir, MakeHashFunc(defaultHash), defaultDbCapacity, defaultRadius)
+	m, err := NewDbStore(backend, dir, MakeHashFunc(defaultHash), capacity, defaultRadius)
 	if err != nil {
 		t.Fatal("can't create store:", err)
 	}
@@ -62,6 +68,28 @@ func TestDbStore2_100_(t *testing.T) {
 	testDbStore(100, 2, t)
 }
 
+func TestDbStoreBoltDB(t *testing.T) {
+	m := initDbStoreBackend(t, ethdb.BoltDB, defaultDbCapacity)
+	defer m.close()
+	testStore(m, 10000, 128, t)
+}
+
+// garbage collection seeks through the index, check it with both backends
+func TestDbStoreGC(t *testing.T) {
+	for _, backend := range ethdb.Backends {
+		m := initDbStoreBackend(t, backend, 50)
+		for i := 0; i < 100; i++ {
+			key := make(Key, 32)
+			rand.Read(key)
+			m.Put(NewChunk(key, nil))
+		}
+		if m.entryCnt > 50 {
+			t.Errorf("%s: %d entries stored, capacity is 50", backend, m.entryCnt)
+		}
+		m.close()
+	}
+}
+
 func TestDbStoreNotFound(t *testing.T) {
 	m := initDbStore(t)
 	defer m.close()
@@ -68,7 +68,7 @@ func NewLocalDPA(datadir string) (*DPA, error) {
 
 	hash := MakeHashFunc("SHA256")
 
-	dbStore, err := NewDbStore(datadir, hash, singletonSwarmDbCapacity, 0)
+	dbStore, err := NewDbStore("", datadir, hash, singletonSwarmDbCapacity, 0)
 	if err != nil {
 		return nil, err
 	}
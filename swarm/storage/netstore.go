@@ -58,10 +58,11 @@ type CloudStore interface {
 }
 
 type StoreParams struct {
-	ChunkDbPath   string
-	DbCapacity    uint64
-	CacheCapacity uint
-	Radius        int
+	ChunkDbPath    string
+	ChunkDbBackend string // database backend of the chunk db, see ethdb.Open
+	DbCapacity     uint64
+	CacheCapacity  uint
+	Radius         int
 }
 
 func NewStoreParams(path string) (self *StoreParams) {
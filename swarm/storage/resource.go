@@ -0,0 +1,159 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// maximum length of a resource topic, keeps updates within a single chunk
+const MaxResourceTopicLength = 256
+
+/*
+ResourceUpdate is a signed, versioned pointer from an (owner, topic) pair to
+content. Each update is stored as a single chunk under the key derived from
+owner, topic and version (see ResourceKey), so the latest version of a
+resource can be looked up without any on-chain transaction.
+
+Unlike content chunks, resource chunks are not addressed by the hash of their
+data; they are valid if the signature recovers to the owner the key was
+derived from.
+*/
+type ResourceUpdate struct {
+	Owner     common.Address
+	Topic     string
+	Version   uint64
+	Content   Key
+	Signature []byte
+}
+
+// wire format of a resource update, the owner is recovered from the signature
+type resourceUpdateData struct {
+	Topic     string
+	Version   uint64
+	Content   []byte
+	Signature []byte
+}
+
+// ResourceKey returns the chunk key of the given version of a resource
+func ResourceKey(owner common.Address, topic string, version uint64) Key {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, version)
+	return Key(crypto.Keccak256(owner[:], []byte(topic), v))
+}
+
+// Key returns the chunk key the update is stored under
+func (self *ResourceUpdate) Key() Key {
+	return ResourceKey(self.Owner, self.Topic, self.Version)
+}
+
+// the digest signed by the owner binds the content to the resource key
+func (self *ResourceUpdate) digest() []byte {
+	return crypto.Keccak256(self.Key(), self.Content)
+}
+
+// Sign sets the owner of the update to the account of signer and signs it
+func (self *ResourceUpdate) Sign(signer accounts.Signer) (err error) {
+	self.Owner = signer.Address()
+	req := &accounts.SignRequest{Kind: accounts.ResourceRequest, Data: self.Content}
+	self.Signature, err = accounts.SignRequestHash(signer, req, self.digest())
+	return
+}
+
+// Verify checks that the update is well formed and signed by its owner
+func (self *ResourceUpdate) Verify() error {
+	if len(self.Topic) == 0 || len(self.Topic) > MaxResourceTopicLength {
+		return fmt.Errorf("invalid resource topic length %d", len(self.Topic))
+	}
+	if len(self.Content) != len(common.Hash{}) {
+		return fmt.Errorf("invalid resource content key length %d", len(self.Content))
+	}
+	pub, err := crypto.SigToPub(self.digest(), self.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid resource signature: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != self.Owner {
+		return fmt.Errorf("resource update signed by %x, not owner %x", signer, self.Owner)
+	}
+	return nil
+}
+
+// Chunk encodes the update as a chunk to be stored under its key
+func (self *ResourceUpdate) Chunk() (*Chunk, error) {
+	data, err := rlp.EncodeToBytes(&resourceUpdateData{
+		Topic:     self.Topic,
+		Version:   self.Version,
+		Content:   self.Content,
+		Signature: self.Signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sdata := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint64(sdata[:8], uint64(len(data)))
+	copy(sdata[8:], data)
+	return &Chunk{
+		Key:   self.Key(),
+		SData: sdata,
+		Size:  int64(len(data)),
+	}, nil
+}
+
+// ParseResourceUpdate decodes and validates the resource update stored as
+// chunk data sdata under key
+func ParseResourceUpdate(key Key, sdata []byte) (*ResourceUpdate, error) {
+	if len(sdata) < 9 {
+		return nil, fmt.Errorf("resource chunk too short")
+	}
+	var data resourceUpdateData
+	if err := rlp.DecodeBytes(sdata[8:], &data); err != nil {
+		return nil, err
+	}
+	update := &ResourceUpdate{
+		Topic:     data.Topic,
+		Version:   data.Version,
+		Content:   Key(data.Content),
+		Signature: data.Signature,
+	}
+	// recover the owner first, Verify then checks the signature against it
+	pub, err := crypto.SigToPub(crypto.Keccak256(key, update.Content), update.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource signature: %v", err)
+	}
+	update.Owner = crypto.PubkeyToAddress(*pub)
+	if !update.Key().isEqual(key) {
+		return nil, fmt.Errorf("resource update does not match key %v", key.Log())
+	}
+	if err := update.Verify(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// IsValidResourceChunk returns true if sdata is a valid resource update
+// stored under key. Chunk stores use it to accept chunks that are not
+// addressed by their content hash.
+func IsValidResourceChunk(key Key, sdata []byte) bool {
+	_, err := ParseResourceUpdate(key, sdata)
+	return err == nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestResourceUpdate(t *testing.T, version uint64) *ResourceUpdate {
+	prvKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := &ResourceUpdate{
+		Topic:   "vod",
+		Version: version,
+		Content: Key(common.Hex2Bytes("4000000000000000000000000000000000000000000000000000000000000001")),
+	}
+	if err := update.Sign(accounts.NewKeySigner(prvKey)); err != nil {
+		t.Fatal(err)
+	}
+	return update
+}
+
+func TestResourceUpdateChunk(t *testing.T) {
+	update := newTestResourceUpdate(t, 3)
+	if err := update.Verify(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	chunk, err := update.Chunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chunk.Key.isEqual(ResourceKey(update.Owner, "vod", 3)) {
+		t.Fatalf("unexpected chunk key %v", chunk.Key.Log())
+	}
+	parsed, err := ParseResourceUpdate(chunk.Key, chunk.SData)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if parsed.Owner != update.Owner || parsed.Version != 3 || !parsed.Content.isEqual(update.Content) {
+		t.Fatalf("parsed update %v does not match %v", parsed, update)
+	}
+
+	// the same data is not valid under the key of another version
+	if IsValidResourceChunk(ResourceKey(update.Owner, "vod", 4), chunk.SData) {
+		t.Fatalf("expected chunk to be invalid under another key")
+	}
+	// tampering with the content invalidates the signature
+	update.Content[0] ^= 0xff
+	if update.Verify() == nil {
+		t.Fatalf("expected tampered update to be invalid")
+	}
+	tampered, _ := update.Chunk()
+	if IsValidResourceChunk(chunk.Key, tampered.SData) {
+		t.Fatalf("expected tampered chunk to be invalid")
+	}
+}
+
+func TestDbStoreResourceChunk(t *testing.T) {
+	m := initDbStore(t)
+	defer m.close()
+	chunk, err := newTestResourceUpdate(t, 1).Chunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put(chunk)
+	stored, err := m.Get(chunk.Key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := ParseResourceUpdate(stored.Key, stored.SData); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -120,10 +120,12 @@ func (key *Key) UnmarshalJSON(value []byte) error {
 // peers and has a channel that is closed when the chunk is retrieved. Multiple
 // local callers can wait on this channel (or combined with a timeout, block with a
 // select).
+// Requesters is accessed concurrently by the network and must be guarded by Lock.
 type RequestStatus struct {
 	Key        Key
 	Source     Peer
 	C          chan bool
+	Lock       sync.RWMutex
 	Requesters map[uint64][]interface{}
 }
 
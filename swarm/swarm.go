@@ -18,10 +18,14 @@ package swarm
 
 import (
 	"bytes"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
+	"net"
+	"path/filepath"
 	"strconv"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/contracts/chequebook"
@@ -45,17 +49,20 @@ import (
 
 // the swarm stack
 type Swarm struct {
-	config      *api.Config            // swarm configuration
-	api         *api.Api               // high level api layer (fs/manifest)
-	dns         api.Resolver           // DNS registrar
-	dbAccess    *network.DbAccess      // access to local chunk db iterator and storage counter
-	storage     storage.ChunkStore     // internal access to storage, common interface to cloud storage backends
-	dpa         *storage.DPA           // distributed preimage archive, the local API to the storage with document level storage/retrieval support
-	depo        network.StorageHandler // remote request handler, interface between bzz protocol and the storage
-	cloud       storage.CloudStore     // procurement, cloud storage backend (can multi-cloud)
-	hive        *network.Hive          // the logistic manager
-	backend     chequebook.Backend     // simple blockchain Backend
-	privateKey  *ecdsa.PrivateKey
+	config      *api.Config             // swarm configuration
+	api         *api.Api                // high level api layer (fs/manifest)
+	dns         api.Resolver            // DNS registrar
+	names       *api.LocalResolver      // local name database, fallback for ENS
+	dbAccess    *network.DbAccess       // access to local chunk db iterator and storage counter
+	storage     storage.ChunkStore      // internal access to storage, common interface to cloud storage backends
+	dpa         *storage.DPA            // distributed preimage archive, the local API to the storage with document level storage/retrieval support
+	depo        network.StorageHandler  // remote request handler, interface between bzz protocol and the storage
+	cloud       storage.CloudStore      // procurement, cloud storage backend (can multi-cloud)
+	hive        *network.Hive           // the logistic manager
+	pushSync    *network.PushSync       // storage receipts for uploads
+	topics      *network.TopicDiscovery // discv5 topic registration and search
+	backend     chequebook.Backend      // simple blockchain Backend
+	signer      accounts.Signer         // signs with the swarm account
 	corsString  string
 	swapEnabled bool
 	streamer    *streaming.Streamer
@@ -66,14 +73,14 @@ type Swarm struct {
 type SwarmAPI struct {
 	Api     *api.Api
 	Backend chequebook.Backend
-	PrvKey  *ecdsa.PrivateKey
+	Signer  accounts.Signer
 }
 
 func (self *Swarm) API() *SwarmAPI {
 	return &SwarmAPI{
 		Api:     self.api,
 		Backend: self.backend,
-		PrvKey:  self.privateKey,
+		Signer:  self.signer,
 	}
 }
 
@@ -93,7 +100,7 @@ func NewSwarm(ctx *node.ServiceContext, backend chequebook.Backend, config *api.
 		config:      config,
 		swapEnabled: swapEnabled,
 		backend:     backend,
-		privateKey:  config.Swap.PrivateKey(),
+		signer:      config.Swap.Signer(),
 		corsString:  cors,
 	}
 	glog.V(logger.Debug).Infof("Setting up Swarm service components")
@@ -127,8 +134,11 @@ func NewSwarm(ctx *node.ServiceContext, backend chequebook.Backend, config *api.
 	self.storage = storage.NewNetStore(hash, lstore, self.cloud, config.StoreParams)
 	glog.V(logger.Debug).Infof("-> swarm net store shared access layer to Swarm Chunk Store")
 
+	// set up push-sync receipts, signed with the key of the bzz address
+	self.pushSync = network.NewPushSync(self.hive, lstore, self.signer)
+
 	// set up Depo (storage handler = cloud storage access layer for incoming remote requests)
-	self.depo = network.NewDepo(hash, lstore, self.storage)
+	self.depo = network.NewDepo(hash, lstore, self.storage, self.pushSync)
 	glog.V(logger.Debug).Infof("-> REmote Access to CHunks")
 
 	self.streamer, err = streaming.NewStreamer(common.HexToHash(self.config.BzzKey))
@@ -148,15 +158,26 @@ func NewSwarm(ctx *node.ServiceContext, backend chequebook.Backend, config *api.
 	glog.V(logger.Debug).Infof("-> Content Store API")
 
 	// set up high level api
-	transactOpts := bind.NewKeyedTransactor(self.privateKey)
-
-	self.dns, err = ens.NewENS(transactOpts, config.EnsRoot, self.backend)
+	self.names, err = api.NewLocalResolver(config.DbBackend, filepath.Join(config.Path, "names"))
 	if err != nil {
 		return nil, err
 	}
-	glog.V(logger.Debug).Infof("-> Swarm Domain Name Registrar @ address %v", config.EnsRoot.Hex())
+	glog.V(logger.Debug).Infof("-> Local Name Resolver")
+	self.dns = self.names
+
+	if self.backend != nil {
+		transactOpts := bind.NewSignerTransactor(self.signer)
+		dns, err := ens.NewENS(transactOpts, config.EnsRoot, self.backend)
+		if err != nil {
+			return nil, err
+		}
+		// names not found in ENS fall back to the local resolver
+		self.dns = api.NewMultiResolver(dns, self.names)
+		glog.V(logger.Debug).Infof("-> Swarm Domain Name Registrar @ address %v", config.EnsRoot.Hex())
+	}
 
 	self.api = api.NewApi(self.dpa, self.dns)
+	self.api.SetReceipter(self.pushSync)
 	// Manifests for Smart Hosting
 	glog.V(logger.Debug).Infof("-> Web3 virtual server API")
 
@@ -197,13 +218,22 @@ func (self *Swarm) Start(net *p2p.Server) error {
 	}
 
 	glog.V(logger.Warn).Infof("Starting Swarm service")
-	self.hive.Start(
+	err := self.hive.Start(
 		discover.PubkeyID(&net.PrivateKey.PublicKey),
 		func() string { return net.ListenAddr },
 		connectPeer,
 	)
+	if err != nil {
+		return err
+	}
 	glog.V(logger.Info).Infof("Swarm network started on bzz address: %v", self.hive.Addr())
 
+	if net.DiscV5 != nil {
+		self.topics = network.NewTopicDiscovery(net.DiscV5, self.hive, self.config.NetworkId, self.config.Transcoder)
+		self.topics.Start()
+		glog.V(logger.Debug).Infof("Swarm topic discovery started")
+	}
+
 	self.dpa.Start()
 	glog.V(logger.Debug).Infof("Swarm DPA started")
 
@@ -235,11 +265,41 @@ func (self *Swarm) Start(net *p2p.Server) error {
 	return nil
 }
 
+// HealthChecks implements node.HealthChecker. The node isn't ready without hive
+// peers, and isn't healthy if the video server doesn't accept connections.
+func (self *Swarm) HealthChecks() []node.HealthCheck {
+	checks := []node.HealthCheck{{Name: "swarm.hive", Readiness: true, Check: self.checkHive}}
+	if self.config.RTMPPort != "" {
+		checks = append(checks, node.HealthCheck{Name: "swarm.rtmp", Check: self.checkRTMP})
+	}
+	return checks
+}
+
+func (self *Swarm) checkHive() error {
+	if self.hive.PeerCount() == 0 {
+		return errors.New("no hive peers")
+	}
+	return nil
+}
+
+// checkRTMP dials the RTMP port of the video server.
+func (self *Swarm) checkRTMP() error {
+	conn, err := net.DialTimeout("tcp", "127.0.0.1:"+self.config.RTMPPort, time.Second)
+	if err != nil {
+		return fmt.Errorf("RTMP server not listening: %v", err)
+	}
+	return conn.Close()
+}
+
 // implements the node.Service interface
 // stops all component services.
 func (self *Swarm) Stop() error {
+	if self.topics != nil {
+		self.topics.Stop()
+	}
 	self.dpa.Stop()
 	self.hive.Stop()
+	self.names.Close()
 	if ch := self.config.Swap.Chequebook(); ch != nil {
 		ch.Stop()
 		ch.Save()
@@ -285,6 +345,24 @@ func (self *Swarm) APIs() []rpc.API {
 			Service:   api.NewControl(self.api, self.hive),
 			Public:    false,
 		},
+		{
+			Namespace: "bzz",
+			Version:   "0.1",
+			Service:   api.NewResource(self.api, self.signer),
+			Public:    false,
+		},
+		{
+			Namespace: "bzz",
+			Version:   "0.1",
+			Service:   api.NewNames(self.names),
+			Public:    false,
+		},
+		{
+			Namespace: "debug",
+			Version:   "0.1",
+			Service:   api.NewDebug(self.hive),
+			Public:    false,
+		},
 		{
 			Namespace: "chequebook",
 			Version:   chequebook.Version,
@@ -319,7 +397,7 @@ func NewLocalSwarm(datadir, port string) (self *Swarm, err error) {
 		return
 	}
 
-	config, err := api.NewConfig(datadir, common.Address{}, prvKey, network.NetworkId, "")
+	config, err := api.NewConfig(datadir, common.Address{}, accounts.NewKeySigner(prvKey), network.NetworkId, "")
 	if err != nil {
 		return
 	}